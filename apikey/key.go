@@ -0,0 +1,122 @@
+// Package apikey - key.go
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Header заголовок, в котором потребители передают ключ.
+const Header = "X-API-Key"
+
+// Области доступа ключей.
+const (
+	ScopeNewsRead      = "news:read"
+	ScopeCommentsRead  = "comments:read"
+	ScopeCommentsWrite = "comments:write"
+)
+
+// Scopes все известные области доступа.
+var Scopes = []string{ScopeNewsRead, ScopeCommentsRead, ScopeCommentsWrite}
+
+var (
+	// ErrInvalidKey возвращается для неизвестного или искаженного ключа.
+	ErrInvalidKey = errors.New("invalid API key")
+	// ErrRevoked возвращается для отозванного ключа.
+	ErrRevoked = errors.New("API key revoked")
+	// ErrRateLimited возвращается, когда ключ исчерпал лимит запросов.
+	ErrRateLimited = errors.New("API key rate limit exceeded")
+	// ErrNotFound возвращается хранилищем для отсутствующего ключа.
+	ErrNotFound = errors.New("API key not found")
+)
+
+// keyPrefix начало каждого ключа, по которому его легко найти в логах и коде.
+const keyPrefix = "gwk"
+
+// Key ключ потребителя API. Сам ключ не хранится: только его префикс для
+// поиска и хеш SHA-256 для проверки.
+type Key struct {
+	ID     int      `json:"id"`
+	Name   string   `json:"name"`
+	Prefix string   `json:"prefix"`
+	Hash   string   `json:"-"`
+	Scopes []string `json:"scopes"`
+	// RateLimit запросов в минуту. 0 означает лимит по умолчанию.
+	RateLimit  int        `json:"rate_limit"`
+	Requests   int64      `json:"requests"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// HasScope сообщает, разрешена ли ключу область scope.
+func (k Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Subject идентификатор ключа в утверждениях запроса.
+func (k Key) Subject() string {
+	return "apikey:" + strconv.Itoa(k.ID)
+}
+
+// ParseScopes разбирает список областей через запятую и проверяет их.
+func ParseScopes(list string) ([]string, error) {
+	var scopes []string
+	for _, scope := range strings.Split(list, ",") {
+		scope = strings.TrimSpace(scope)
+		if scope == "" {
+			continue
+		}
+		known := false
+		for _, s := range Scopes {
+			known = known || s == scope
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown scope %q", scope)
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	return scopes, nil
+}
+
+// generate создает новый ключ вида gwk_<префикс>_<секрет> и возвращает его вместе с префиксом.
+func generate() (raw, prefix string, err error) {
+	buf := make([]byte, 36)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	prefix = hex.EncodeToString(buf[:4])
+	raw = keyPrefix + "_" + prefix + "_" + base64.RawURLEncoding.EncodeToString(buf[4:])
+	return raw, prefix, nil
+}
+
+// parse извлекает префикс из ключа.
+func parse(raw string) (string, bool) {
+	parts := strings.SplitN(raw, "_", 3)
+	if len(parts) != 3 || parts[0] != keyPrefix || len(parts[1]) != 8 || parts[2] == "" {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// hash возвращает хеш ключа. У ключа достаточно энтропии, поэтому медленный
+// хеш паролей не нужен.
+func hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
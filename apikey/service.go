@@ -0,0 +1,228 @@
+// Package apikey - service.go
+package apikey
+
+import (
+	"crypto/subtle"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// cacheTTL сколько ключ хранится в кеше. Отзыв ключа через CLI вступает в
+// силу на шлюзе не позже чем через это время.
+const cacheTTL = 30 * time.Second
+
+// rateWindow окно, в котором действует лимит запросов ключа.
+const rateWindow = time.Minute
+
+// RateStatus состояние лимита запросов ключа.
+type RateStatus struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+// Service выпускает и проверяет ключи, ограничивает частоту запросов и
+// копит счетчики использования до записи в хранилище.
+type Service struct {
+	store Store
+	// DefaultRateLimit лимит запросов в минуту для ключей без собственного лимита.
+	DefaultRateLimit int
+
+	mu      sync.Mutex
+	cache   map[string]cachedKey
+	windows map[int]*rateState
+	usage   map[int]*usageState
+	now     func() time.Time
+}
+
+type cachedKey struct {
+	key     Key
+	expires time.Time
+}
+
+type rateState struct {
+	start time.Time
+	used  int
+}
+
+type usageState struct {
+	requests int64
+	lastUsed time.Time
+}
+
+// NewService создает сервис ключей поверх хранилища store.
+func NewService(store Store) *Service {
+	return &Service{
+		store:            store,
+		DefaultRateLimit: 60,
+		cache:            make(map[string]cachedKey),
+		windows:          make(map[int]*rateState),
+		usage:            make(map[int]*usageState),
+		now:              time.Now,
+	}
+}
+
+// NewServiceFromEnv создает сервис с лимитом по умолчанию из APIKEY_RATE_LIMIT.
+func NewServiceFromEnv(store Store) *Service {
+	s := NewService(store)
+	if limit, err := strconv.Atoi(os.Getenv("APIKEY_RATE_LIMIT")); err == nil && limit > 0 {
+		s.DefaultRateLimit = limit
+	}
+	return s
+}
+
+// Issue выпускает новый ключ. Ключ в открытом виде возвращается только здесь.
+func (s *Service) Issue(name string, scopes []string, rateLimit int) (string, Key, error) {
+	raw, prefix, err := generate()
+	if err != nil {
+		return "", Key{}, err
+	}
+	key := Key{
+		Name:      name,
+		Prefix:    prefix,
+		Hash:      hash(raw),
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+		CreatedAt: s.now(),
+	}
+	key.ID, err = s.store.Create(key)
+	if err != nil {
+		return "", Key{}, err
+	}
+	return raw, key, nil
+}
+
+// Revoke отзывает ключ.
+func (s *Service) Revoke(id int) error {
+	if err := s.store.Revoke(id); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for prefix, cached := range s.cache {
+		if cached.key.ID == id {
+			delete(s.cache, prefix)
+		}
+	}
+	return nil
+}
+
+// Authenticate проверяет ключ, учитывает запрос в лимите и счетчике использования.
+func (s *Service) Authenticate(raw string) (Key, RateStatus, error) {
+	prefix, ok := parse(raw)
+	if !ok {
+		return Key{}, RateStatus{}, ErrInvalidKey
+	}
+	key, err := s.lookup(prefix)
+	if err != nil {
+		return Key{}, RateStatus{}, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hash(raw)), []byte(key.Hash)) != 1 {
+		return Key{}, RateStatus{}, ErrInvalidKey
+	}
+	if key.RevokedAt != nil {
+		return Key{}, RateStatus{}, ErrRevoked
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	status := s.take(key)
+	if status.Remaining < 0 {
+		status.Remaining = 0
+		return key, status, ErrRateLimited
+	}
+
+	u := s.usage[key.ID]
+	if u == nil {
+		u = &usageState{}
+		s.usage[key.ID] = u
+	}
+	u.requests++
+	u.lastUsed = s.now()
+	return key, status, nil
+}
+
+// lookup возвращает ключ из кеша или хранилища.
+func (s *Service) lookup(prefix string) (Key, error) {
+	s.mu.Lock()
+	cached, ok := s.cache[prefix]
+	s.mu.Unlock()
+	if ok && s.now().Before(cached.expires) {
+		return cached.key, nil
+	}
+
+	key, err := s.store.GetByPrefix(prefix)
+	if err == ErrNotFound {
+		return Key{}, ErrInvalidKey
+	} else if err != nil {
+		return Key{}, err
+	}
+
+	s.mu.Lock()
+	s.cache[prefix] = cachedKey{key: key, expires: s.now().Add(cacheTTL)}
+	s.mu.Unlock()
+	return key, nil
+}
+
+// take учитывает запрос в окне ключа. Отрицательный остаток означает превышение лимита.
+func (s *Service) take(key Key) RateStatus {
+	limit := key.RateLimit
+	if limit <= 0 {
+		limit = s.DefaultRateLimit
+	}
+
+	now := s.now()
+	w := s.windows[key.ID]
+	if w == nil || now.Sub(w.start) >= rateWindow {
+		w = &rateState{start: now}
+		s.windows[key.ID] = w
+	}
+	w.used++
+	return RateStatus{Limit: limit, Remaining: limit - w.used, Reset: w.start.Add(rateWindow)}
+}
+
+// Flush записывает накопленные счетчики использования в хранилище.
+func (s *Service) Flush() error {
+	s.mu.Lock()
+	usage := s.usage
+	s.usage = make(map[int]*usageState)
+	s.mu.Unlock()
+
+	var firstErr error
+	for id, u := range usage {
+		if err := s.store.AddUsage(id, u.requests, u.lastUsed); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			// Вернуть несохраненные запросы, чтобы записать их в следующий раз.
+			s.mu.Lock()
+			if pending := s.usage[id]; pending != nil {
+				pending.requests += u.requests
+			} else {
+				s.usage[id] = u
+			}
+			s.mu.Unlock()
+		}
+	}
+	return firstErr
+}
+
+// Run записывает счетчики использования каждые interval до закрытия stop.
+func (s *Service) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Flush(); err != nil {
+				log.Printf("Failed to record API key usage: %v", err)
+			}
+		case <-stop:
+			s.Flush()
+			return
+		}
+	}
+}
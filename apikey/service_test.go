@@ -0,0 +1,97 @@
+// service_test.go
+package apikey
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAuthenticate(t *testing.T) {
+	store := NewMemoryStore()
+	s := NewService(store)
+
+	raw, key, err := s.Issue("partner", []string{ScopeNewsRead}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := store.GetByPrefix(key.Prefix)
+	if stored.Hash == "" || stored.Hash == raw {
+		t.Fatal("expected only the key hash to be stored")
+	}
+
+	got, _, err := s.Authenticate(raw)
+	if err != nil || got.ID != key.ID || !got.HasScope(ScopeNewsRead) || got.HasScope(ScopeCommentsWrite) {
+		t.Errorf("expected key %d with news:read only, got %+v, %v", key.ID, got, err)
+	}
+
+	tampered := raw[:len(raw)-1] + "x"
+	if raw[len(raw)-1] == 'x' {
+		tampered = raw[:len(raw)-1] + "y"
+	}
+	for _, bad := range []string{"", "garbage", tampered, "gwk_00000000_secret"} {
+		if _, _, err := s.Authenticate(bad); err != ErrInvalidKey {
+			t.Errorf("Authenticate(%q) = %v, want ErrInvalidKey", bad, err)
+		}
+	}
+
+	if err := s.Revoke(key.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := s.Authenticate(raw); err != ErrRevoked {
+		t.Errorf("expected ErrRevoked, got %v", err)
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	raw, _, _ := s.Issue("partner", []string{ScopeNewsRead}, 2)
+	for i := 0; i < 2; i++ {
+		if _, rate, err := s.Authenticate(raw); err != nil || rate.Remaining != 1-i {
+			t.Fatalf("request %d: expected remaining %d, got %d, %v", i, 1-i, rate.Remaining, err)
+		}
+	}
+	if _, _, err := s.Authenticate(raw); err != ErrRateLimited {
+		t.Errorf("expected ErrRateLimited, got %v", err)
+	}
+
+	now = now.Add(rateWindow)
+	if _, _, err := s.Authenticate(raw); err != nil {
+		t.Errorf("expected new window to allow request, got %v", err)
+	}
+}
+
+func TestFlushUsage(t *testing.T) {
+	store := NewMemoryStore()
+	s := NewService(store)
+	raw, key, _ := s.Issue("partner", []string{ScopeNewsRead}, 0)
+
+	for i := 0; i < 3; i++ {
+		s.Authenticate(raw)
+	}
+	if err := s.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	s.Authenticate(raw)
+	s.Flush()
+
+	stored, _ := store.GetByPrefix(key.Prefix)
+	if stored.Requests != 4 || stored.LastUsedAt == nil {
+		t.Errorf("expected 4 recorded requests, got %d", stored.Requests)
+	}
+}
+
+func TestParseScopes(t *testing.T) {
+	scopes, err := ParseScopes("news:read, comments:read")
+	if err != nil || len(scopes) != 2 {
+		t.Errorf("expected two scopes, got %v, %v", scopes, err)
+	}
+	if _, err := ParseScopes("news:write"); err == nil {
+		t.Error("expected unknown scope to be rejected")
+	}
+	if _, err := ParseScopes(""); err == nil {
+		t.Error("expected empty scope list to be rejected")
+	}
+}
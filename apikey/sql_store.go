@@ -0,0 +1,120 @@
+// Package apikey - sql_store.go
+package apikey
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+)
+
+// SQLStore ключи в таблице api_keys.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore создает хранилище ключей в базе данных.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// Setup создает таблицу ключей, если она еще не существует.
+func (s *SQLStore) Setup() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS api_keys (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			name VARCHAR(255) NOT NULL,
+			prefix CHAR(8) NOT NULL UNIQUE,
+			key_hash CHAR(64) NOT NULL,
+			scopes VARCHAR(255) NOT NULL,
+			rate_limit INT NOT NULL DEFAULT 0,
+			requests BIGINT NOT NULL DEFAULT 0,
+			last_used_at DATETIME NULL,
+			created_at DATETIME NOT NULL,
+			revoked_at DATETIME NULL
+		)
+	`)
+	return err
+}
+
+func (s *SQLStore) Create(key Key) (int, error) {
+	result, err := s.db.Exec(`
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, rate_limit, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, key.Name, key.Prefix, key.Hash, strings.Join(key.Scopes, ","), key.RateLimit, key.CreatedAt)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+const selectKeys = `
+	SELECT id, name, prefix, key_hash, scopes, rate_limit, requests, last_used_at, created_at, revoked_at
+	FROM api_keys`
+
+func (s *SQLStore) GetByPrefix(prefix string) (Key, error) {
+	key, err := scanKey(s.db.QueryRow(selectKeys+` WHERE prefix = ?`, prefix))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Key{}, ErrNotFound
+	}
+	return key, err
+}
+
+func (s *SQLStore) List() ([]Key, error) {
+	rows, err := s.db.Query(selectKeys + ` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []Key
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	return keys, rows.Err()
+}
+
+func (s *SQLStore) Revoke(id int) error {
+	result, err := s.db.Exec(`UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) AddUsage(id int, requests int64, lastUsed time.Time) error {
+	_, err := s.db.Exec(`UPDATE api_keys SET requests = requests + ?, last_used_at = ? WHERE id = ?`, requests, lastUsed, id)
+	return err
+}
+
+// scanner общий интерфейс sql.Row и sql.Rows.
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanKey(row scanner) (Key, error) {
+	var key Key
+	var scopes string
+	var lastUsed, revoked sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.Hash, &scopes, &key.RateLimit,
+		&key.Requests, &lastUsed, &key.CreatedAt, &revoked)
+	if err != nil {
+		return Key{}, err
+	}
+	key.Scopes = strings.Split(scopes, ",")
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	if revoked.Valid {
+		key.RevokedAt = &revoked.Time
+	}
+	return key, nil
+}
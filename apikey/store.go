@@ -0,0 +1,79 @@
+// Package apikey - store.go
+package apikey
+
+import (
+	"sync"
+	"time"
+)
+
+// Store хранилище ключей.
+type Store interface {
+	Create(key Key) (int, error)
+	GetByPrefix(prefix string) (Key, error)
+	List() ([]Key, error)
+	Revoke(id int) error
+	// AddUsage прибавляет requests к счетчику запросов ключа.
+	AddUsage(id int, requests int64, lastUsed time.Time) error
+}
+
+// MemoryStore ключи в памяти.
+type MemoryStore struct {
+	mu   sync.RWMutex
+	keys []Key
+}
+
+// NewMemoryStore создает пустое хранилище ключей в памяти.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (s *MemoryStore) Create(key Key) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key.ID = len(s.keys) + 1
+	s.keys = append(s.keys, key)
+	return key.ID, nil
+}
+
+func (s *MemoryStore) GetByPrefix(prefix string) (Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, key := range s.keys {
+		if key.Prefix == prefix {
+			return key, nil
+		}
+	}
+	return Key{}, ErrNotFound
+}
+
+func (s *MemoryStore) List() ([]Key, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Key(nil), s.keys...), nil
+}
+
+func (s *MemoryStore) Revoke(id int) error {
+	return s.update(id, func(k *Key) {
+		now := time.Now()
+		k.RevokedAt = &now
+	})
+}
+
+func (s *MemoryStore) AddUsage(id int, requests int64, lastUsed time.Time) error {
+	return s.update(id, func(k *Key) {
+		k.Requests += requests
+		k.LastUsedAt = &lastUsed
+	})
+}
+
+func (s *MemoryStore) update(id int, fn func(*Key)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if s.keys[i].ID == id {
+			fn(&s.keys[i])
+			return nil
+		}
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,120 @@
+// apikey_admin/main.go
+package main
+
+import (
+	"APIGateway/apikey"
+	"database/sql"
+	"flag"
+	"fmt"
+	_ "github.com/go-sql-driver/mysql"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+const usage = `Usage: apikey_admin [-dsn DSN] <command> [flags]
+
+Commands:
+  create -name NAME -scopes news:read,comments:read [-rate N]
+  list
+  revoke -id ID
+`
+
+func main() {
+	dsn := flag.String("dsn", "root:love@tcp(127.0.0.1:3306)/mydatabase?parseTime=true", "database DSN")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	db, err := sql.Open("mysql", *dsn)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	store := apikey.NewSQLStore(db)
+	if err := store.Setup(); err != nil {
+		log.Fatal("Cannot setup API keys:", err)
+	}
+	service := apikey.NewService(store)
+
+	args := flag.Args()
+	switch args[0] {
+	case "create":
+		err = create(service, args[1:])
+	case "list":
+		err = list(store)
+	case "revoke":
+		err = revoke(service, args[1:])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// create выпускает ключ и печатает его. Повторно получить ключ невозможно.
+func create(service *apikey.Service, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	name := fs.String("name", "", "consumer name")
+	scopeList := fs.String("scopes", "", "comma-separated scopes: news:read, comments:read, comments:write")
+	rate := fs.Int("rate", 0, "requests per minute, 0 for the gateway default")
+	fs.Parse(args)
+
+	if *name == "" {
+		return fmt.Errorf("-name is required")
+	}
+	scopes, err := apikey.ParseScopes(*scopeList)
+	if err != nil {
+		return err
+	}
+
+	raw, key, err := service.Issue(*name, scopes, *rate)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Created key %d for %q. Store it now, it will not be shown again:\n%s\n", key.ID, key.Name, raw)
+	return nil
+}
+
+func list(store apikey.Store) error {
+	keys, err := store.List()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tPREFIX\tSCOPES\tRATE\tREQUESTS\tLAST USED\tSTATUS")
+	for _, k := range keys {
+		lastUsed, status := "-", "active"
+		if k.LastUsedAt != nil {
+			lastUsed = k.LastUsedAt.Format(time.RFC3339)
+		}
+		if k.RevokedAt != nil {
+			status = "revoked " + k.RevokedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%v\t%d\t%d\t%s\t%s\n", k.ID, k.Name, k.Prefix, k.Scopes, k.RateLimit, k.Requests, lastUsed, status)
+	}
+	return w.Flush()
+}
+
+func revoke(service *apikey.Service, args []string) error {
+	fs := flag.NewFlagSet("revoke", flag.ExitOnError)
+	id := fs.Int("id", 0, "key ID")
+	fs.Parse(args)
+
+	if *id <= 0 {
+		return fmt.Errorf("-id is required")
+	}
+	if err := service.Revoke(*id); err != nil {
+		return err
+	}
+	fmt.Printf("Revoked key %d\n", *id)
+	return nil
+}
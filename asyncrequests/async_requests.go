@@ -2,18 +2,21 @@
 package asyncrequests
 
 import (
-	"APIGateway/handlers"
+	"APIGateway/database"
 	"sync"
 )
 
+// FetchFunc получает новости и пагинацию от одного сервиса.
+type FetchFunc func(url string, title string, page int, pageSize int) ([]repository.News, *repository.Pagination, error)
+
 // NewsWithError Добавляем дополнительное поле Pagination в структуру
 type NewsWithError struct {
-	News       []handlers.News
-	Pagination *handlers.Pagination
+	News       []repository.News
+	Pagination *repository.Pagination
 	Err        error
 }
 
-func ExecuteAsyncHTTPGets(urls []string, title string, page int, pageSize int) ([]handlers.News, *handlers.Pagination, error) {
+func ExecuteAsyncHTTPGets(fetch FetchFunc, urls []string, title string, page int, pageSize int) ([]repository.News, *repository.Pagination, error) {
 	var wg sync.WaitGroup
 	newsChannel := make(chan NewsWithError, len(urls))
 
@@ -21,7 +24,7 @@ func ExecuteAsyncHTTPGets(urls []string, title string, page int, pageSize int) (
 		wg.Add(1)
 		go func(url string) {
 			defer wg.Done()
-			news, pagination, err := handlers.GetNewsFromService(url, title, page, pageSize)
+			news, pagination, err := fetch(url, title, page, pageSize)
 			newsChannel <- NewsWithError{news, pagination, err}
 		}(url)
 	}
@@ -29,15 +32,17 @@ func ExecuteAsyncHTTPGets(urls []string, title string, page int, pageSize int) (
 	wg.Wait()
 	close(newsChannel)
 
-	var allNews []handlers.News
-	var allPagination *handlers.Pagination
+	var allNews []repository.News
+	allPagination := &repository.Pagination{CurrentPage: page}
 	for nw := range newsChannel {
 		if nw.Err != nil {
 			return nil, allPagination, nw.Err
 		}
 		allNews = append(allNews, nw.News...)
 		// Здесь мы обрабатываем сумму страниц от всех пагинаций
-		allPagination.TotalPages += nw.Pagination.TotalPages
+		if nw.Pagination != nil {
+			allPagination.TotalPages += nw.Pagination.TotalPages
+		}
 	}
 	return allNews, allPagination, nil
 }
@@ -2,14 +2,14 @@
 package asyncrequests
 
 import (
-	"APIGateway/handlers"
+	"APIGateway/database"
 	"testing"
 )
 
 func TestExecuteAsyncHTTPGets(t *testing.T) {
-	handlers.GetNews = func(url string, title string, page int, pageSize int) ([]handlers.News, *handlers.Pagination, error) {
-		news := []handlers.News{{Title: "test title", Author: "test author", Published: "test date"}}
-		pagination := &handlers.Pagination{CurrentPage: 1, TotalPages: 1}
+	fetch := func(url string, title string, page int, pageSize int) ([]repository.News, *repository.Pagination, error) {
+		news := []repository.News{{Title: "test title", Author: "test author", Published: "test date"}}
+		pagination := &repository.Pagination{CurrentPage: 1, TotalPages: 1}
 		return news, pagination, nil
 	}
 
@@ -17,7 +17,7 @@ func TestExecuteAsyncHTTPGets(t *testing.T) {
 	title := "test title"
 	page := 1
 	pageSize := 1
-	news, pagination, err := ExecuteAsyncHTTPGets(urls, title, page, pageSize)
+	news, pagination, err := ExecuteAsyncHTTPGets(fetch, urls, title, page, pageSize)
 
 	if err != nil {
 		t.Errorf("Expected no error, but got: %v", err)
@@ -0,0 +1,113 @@
+// auth_test.go
+package auth
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPassword(t *testing.T) {
+	hash, err := HashPassword("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hash == "correct horse" {
+		t.Fatal("password stored in plain text")
+	}
+	if !CheckPassword(hash, "correct horse") {
+		t.Error("expected correct password to match")
+	}
+	if CheckPassword(hash, "wrong horse") {
+		t.Error("expected wrong password to be rejected")
+	}
+}
+
+func TestValidateCredentials(t *testing.T) {
+	tt := []struct {
+		username, password string
+		want               error
+	}{
+		{"john", "secret-pass", nil},
+		{"jo", "secret-pass", ErrInvalidUsername},
+		{"john doe", "secret-pass", ErrInvalidUsername},
+		{"john", "short", ErrWeakPassword},
+		{"john", strings.Repeat("x", 73), ErrWeakPassword},
+	}
+	for _, tc := range tt {
+		if err := ValidateCredentials(tc.username, tc.password); err != tc.want {
+			t.Errorf("ValidateCredentials(%q, %q) = %v, want %v", tc.username, tc.password, err, tc.want)
+		}
+	}
+}
+
+func TestToken(t *testing.T) {
+	issuer := NewIssuer([]byte("secret"), time.Hour)
+	token, err := issuer.Issue(7, "john")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := issuer.Verify(token)
+	if err != nil {
+		t.Fatalf("expected valid token, got %v", err)
+	}
+	if claims.Subject != "7" || claims.Username != "john" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	other := NewIssuer([]byte("other"), time.Hour)
+	if _, err := other.Verify(token); err != ErrInvalidToken {
+		t.Errorf("expected token signed with another secret to be rejected, got %v", err)
+	}
+
+	parts := strings.Split(token, ".")
+	tampered := parts[0] + "." + strings.TrimRight(parts[1], "=") + "x." + parts[2]
+	if _, err := issuer.Verify(tampered); err != ErrInvalidToken {
+		t.Errorf("expected tampered token to be rejected, got %v", err)
+	}
+
+	issuer.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := issuer.Verify(token); err != ErrInvalidToken {
+		t.Errorf("expected expired token to be rejected, got %v", err)
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	if token, ok := BearerToken("Bearer abc"); !ok || token != "abc" {
+		t.Errorf("expected token abc, got %q", token)
+	}
+	if _, ok := BearerToken("Basic abc"); ok {
+		t.Error("expected non-bearer scheme to be ignored")
+	}
+}
+
+func TestIdentity(t *testing.T) {
+	secret := []byte("identity")
+	h := http.Header{}
+	SetIdentity(h, &Claims{Username: "john", Roles: []string{"moderator"}}, secret)
+	if got := Identity(h, secret); got == nil || got.Username != "john" || !got.HasRole("moderator") {
+		t.Fatalf("expected claims for john, got %+v", got)
+	}
+	if got := Identity(h, []byte("other")); got != nil {
+		t.Errorf("expected a header signed with another secret to be rejected, got %+v", got)
+	}
+	if got := Identity(h, nil); got != nil {
+		t.Errorf("expected no identity without a secret, got %+v", got)
+	}
+
+	data, _ := json.Marshal(identity{Claims: &Claims{Username: "john"}, ExpiresAt: time.Now().Add(-time.Second).Unix()})
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	h.Set(IdentityHeader, payload+"."+signIdentity(payload, secret))
+	if got := Identity(h, secret); got != nil {
+		t.Errorf("expected an expired header to be rejected, got %+v", got)
+	}
+
+	SetIdentity(h, nil, secret)
+	if h.Get(IdentityHeader) != "" {
+		t.Error("expected the header removed without claims")
+	}
+}
@@ -0,0 +1,87 @@
+// Package auth - context.go
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// IdentityHeader заголовок, в котором шлюз передает сервисам проверенные
+// утверждения пользователя. Заголовок подписан HMAC-SHA256 общим секретом
+// шлюза и сервисов, поэтому подделать его без секрета нельзя.
+const IdentityHeader = "X-Authenticated-Identity"
+
+// IdentityTTL срок действия подписанного заголовка идентичности.
+const IdentityTTL = time.Minute
+
+// IdentitySecretFromEnv возвращает общий секрет заголовка идентичности из
+// IDENTITY_SECRET. Пустой секрет отключает передачу идентичности.
+func IdentitySecretFromEnv() []byte {
+	return []byte(os.Getenv("IDENTITY_SECRET"))
+}
+
+// identity содержимое заголовка IdentityHeader.
+type identity struct {
+	Claims    *Claims `json:"claims"`
+	ExpiresAt int64   `json:"exp"`
+}
+
+type contextKey struct{}
+
+// WithClaims возвращает контекст с утверждениями пользователя.
+func WithClaims(ctx context.Context, claims *Claims) context.Context {
+	return context.WithValue(ctx, contextKey{}, claims)
+}
+
+// ClaimsFromContext возвращает утверждения пользователя из контекста или nil.
+func ClaimsFromContext(ctx context.Context) *Claims {
+	claims, _ := ctx.Value(contextKey{}).(*Claims)
+	return claims
+}
+
+// SetIdentity записывает утверждения в заголовок IdentityHeader запроса к сервису
+// и подписывает их секретом secret. Без утверждений или секрета заголовок удаляется.
+func SetIdentity(h http.Header, claims *Claims, secret []byte) {
+	h.Del(IdentityHeader)
+	if claims == nil || len(secret) == 0 {
+		return
+	}
+	data, err := json.Marshal(identity{Claims: claims, ExpiresAt: time.Now().Add(IdentityTTL).Unix()})
+	if err != nil {
+		return
+	}
+	payload := base64.RawURLEncoding.EncodeToString(data)
+	h.Set(IdentityHeader, payload+"."+signIdentity(payload, secret))
+}
+
+// Identity проверяет подпись и срок заголовка IdentityHeader и возвращает
+// утверждения или nil, если заголовка нет, секрет пуст или подпись неверна.
+func Identity(h http.Header, secret []byte) *Claims {
+	value := h.Get(IdentityHeader)
+	if value == "" || len(secret) == 0 {
+		return nil
+	}
+	payload, signature, ok := strings.Cut(value, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signIdentity(payload, secret))) {
+		return nil
+	}
+	var id identity
+	if err := decodeSegment(payload, &id); err != nil || id.Claims == nil || time.Now().Unix() > id.ExpiresAt {
+		return nil
+	}
+	return id.Claims
+}
+
+// signIdentity возвращает подпись содержимого заголовка в base64url.
+func signIdentity(payload string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
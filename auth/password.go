@@ -0,0 +1,44 @@
+// Package auth - password.go
+package auth
+
+import (
+	"errors"
+	"regexp"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrInvalidUsername возвращается для имени вне допустимого формата.
+	ErrInvalidUsername = errors.New("username must be 3-32 characters: letters, digits, '.', '_' or '-'")
+	// ErrWeakPassword возвращается для слишком короткого или длинного пароля.
+	ErrWeakPassword = errors.New("password must be 8-72 bytes long")
+)
+
+var usernamePattern = regexp.MustCompile(`^[a-zA-Z0-9_.-]{3,32}$`)
+
+// ValidateCredentials проверяет формат имени пользователя и пароля при регистрации.
+func ValidateCredentials(username, password string) error {
+	if !usernamePattern.MatchString(username) {
+		return ErrInvalidUsername
+	}
+	// bcrypt учитывает только первые 72 байта пароля.
+	if len(password) < 8 || len(password) > 72 {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+// HashPassword возвращает bcrypt-хэш пароля.
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword сравнивает пароль с bcrypt-хэшем.
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
@@ -0,0 +1,161 @@
+// Package auth - token.go
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken возвращается для поддельного, испорченного или просроченного токена.
+var ErrInvalidToken = errors.New("invalid token")
+
+// DefaultIssuer значение iss в токенах, выпущенных шлюзом.
+const DefaultIssuer = "APIGateway"
+
+// Claims утверждения токена доступа.
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Username  string   `json:"name"`
+	Roles     []string `json:"roles,omitempty"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+}
+
+// HasRole сообщает, есть ли у владельца токена роль role.
+func (c *Claims) HasRole(role string) bool {
+	return c != nil && contains(c.Roles, role)
+}
+
+// Audience получатели токена. В JWT поле aud бывает строкой или массивом строк.
+type Audience []string
+
+// UnmarshalJSON принимает aud и в виде строки, и в виде массива.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+// Contains сообщает, входит ли aud в список получателей.
+func (a Audience) Contains(aud string) bool {
+	return contains(a, aud)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Issuer выпускает и проверяет токены доступа JWT, подписанные HS256.
+type Issuer struct {
+	Secret []byte
+	Name   string
+	// Audience получатель, записываемый в aud. Пустой не записывается.
+	Audience string
+	TTL      time.Duration
+	now      func() time.Time
+}
+
+// NewIssuer создает выпускающего токены с секретом secret.
+func NewIssuer(secret []byte, ttl time.Duration) *Issuer {
+	return &Issuer{Secret: secret, Name: DefaultIssuer, TTL: ttl, now: time.Now}
+}
+
+// NewIssuerFromEnv создает выпускающего по переменным JWT_SECRET, JWT_TTL и JWT_AUDIENCE.
+// Без JWT_SECRET генерируется случайный секрет, и токены перестают действовать после перезапуска.
+func NewIssuerFromEnv() *Issuer {
+	secret := []byte(os.Getenv("JWT_SECRET"))
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			panic(err)
+		}
+		log.Println("JWT_SECRET not set, using a random secret")
+	}
+
+	ttl, err := time.ParseDuration(os.Getenv("JWT_TTL"))
+	if err != nil || ttl <= 0 {
+		ttl = time.Hour
+	}
+	issuer := NewIssuer(secret, ttl)
+	issuer.Audience = os.Getenv("JWT_AUDIENCE")
+	return issuer
+}
+
+// Issue выпускает токен доступа для пользователя с ролями roles.
+func (i *Issuer) Issue(userID int, username string, roles ...string) (string, error) {
+	now := i.now()
+	claims := Claims{
+		Subject:   strconv.Itoa(userID),
+		Username:  username,
+		Roles:     roles,
+		Issuer:    i.Name,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: now.Add(i.TTL).Unix(),
+	}
+	if i.Audience != "" {
+		claims.Audience = Audience{i.Audience}
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(i.sign(signingInput)), nil
+}
+
+// Verify проверяет подпись, срок действия и издателя токена и возвращает его утверждения.
+func (i *Issuer) Verify(token string) (*Claims, error) {
+	v := &Verifier{Secret: i.Secret, Issuers: []string{i.Name}, Audience: i.Audience, now: i.now}
+	return v.Verify(token)
+}
+
+func (i *Issuer) sign(signingInput string) []byte {
+	mac := hmac.New(sha256.New, i.Secret)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+// decodeSegment декодирует часть токена в формате base64url JSON.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// BearerToken извлекает токен из заголовка Authorization.
+func BearerToken(header string) (string, bool) {
+	const prefix = "Bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(prefix):]), true
+}
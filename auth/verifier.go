@@ -0,0 +1,185 @@
+// Package auth - verifier.go
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+)
+
+// Verifier проверяет токены JWT: HS256 с общим секретом, RS256 и ES256
+// с открытыми ключами из JWKS. Issuers и Audience проверяются, если заданы.
+//
+// Токены по ключам JWKS выпускает внешний издатель, поэтому их субъект и имя
+// получают префикс издателя, а роли переводятся через Roles.
+type Verifier struct {
+	Secret   []byte
+	Keys     *KeySet
+	Issuers  []string
+	Audience string
+	// Roles сопоставляет роли внешних токенов локальным ролям. Роли вне
+	// списка отбрасываются; без списка внешний пользователь остается читателем.
+	Roles map[string]string
+	// Leeway допустимое расхождение часов при проверке exp и nbf.
+	Leeway time.Duration
+	now    func() time.Time
+}
+
+// VerifierFromEnv создает проверяющего с секретом secret и настройками из
+// переменных JWT_JWKS (путь к файлу или URL), JWT_ISSUER (издатели внешних
+// токенов через запятую), JWT_AUDIENCE и JWT_ROLE_MAP (пары внешняя=локальная
+// роль через запятую, например "idp-moderator=moderator"). Токены шлюза
+// принимаются всегда.
+func VerifierFromEnv(secret []byte) (*Verifier, error) {
+	v := &Verifier{
+		Secret:   secret,
+		Issuers:  []string{DefaultIssuer},
+		Audience: os.Getenv("JWT_AUDIENCE"),
+		Roles:    make(map[string]string),
+		Leeway:   30 * time.Second,
+	}
+	for _, iss := range strings.Split(os.Getenv("JWT_ISSUER"), ",") {
+		if iss = strings.TrimSpace(iss); iss != "" {
+			v.Issuers = append(v.Issuers, iss)
+		}
+	}
+	for _, pair := range strings.Split(os.Getenv("JWT_ROLE_MAP"), ",") {
+		external, local, ok := strings.Cut(pair, "=")
+		if external, local = strings.TrimSpace(external), strings.TrimSpace(local); ok && external != "" && local != "" {
+			v.Roles[external] = local
+		}
+	}
+	if source := os.Getenv("JWT_JWKS"); source != "" {
+		keys, err := NewKeySet(source)
+		if err != nil {
+			return nil, err
+		}
+		v.Keys = keys
+	}
+	return v, nil
+}
+
+// Verify проверяет подпись и утверждения токена и возвращает их.
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature) {
+		return nil, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if !v.validClaims(&claims) {
+		return nil, ErrInvalidToken
+	}
+	if header.Alg != "HS256" && !v.external(&claims) {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+// external переводит утверждения внешнего токена в пространство шлюза:
+// субъект и имя получают префикс издателя, чтобы внешний "john" не совпал
+// с локальным, а роли проходят только через Roles. Токен без sub отвергается.
+func (v *Verifier) external(c *Claims) bool {
+	if c.Subject == "" {
+		return false
+	}
+	name := c.Username
+	if name == "" {
+		name = c.Subject
+	}
+	c.Subject = c.Issuer + "|" + c.Subject
+	c.Username = c.Issuer + "|" + name
+
+	var roles []string
+	for _, role := range c.Roles {
+		if local, ok := v.Roles[role]; ok && !contains(roles, local) {
+			roles = append(roles, local)
+		}
+	}
+	c.Roles = roles
+	return true
+}
+
+// verifySignature проверяет подпись алгоритмом alg. Алгоритм определяет и
+// тип ключа, поэтому токен RS256 не пройдет проверку общим секретом и наоборот.
+func (v *Verifier) verifySignature(alg, kid, signingInput string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signingInput))
+
+	switch alg {
+	case "HS256":
+		if len(v.Secret) == 0 {
+			return false
+		}
+		mac := hmac.New(sha256.New, v.Secret)
+		mac.Write([]byte(signingInput))
+		return hmac.Equal(signature, mac.Sum(nil))
+	case "RS256":
+		key, ok := v.publicKey(kid).(*rsa.PublicKey)
+		return ok && rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) == nil
+	case "ES256":
+		key, ok := v.publicKey(kid).(*ecdsa.PublicKey)
+		if !ok || key.Curve != elliptic.P256() || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(key, digest[:], r, s)
+	}
+	return false
+}
+
+func (v *Verifier) publicKey(kid string) crypto.PublicKey {
+	if v.Keys == nil {
+		return nil
+	}
+	return v.Keys.Key(kid)
+}
+
+// validClaims проверяет срок действия, издателя и получателя токена.
+func (v *Verifier) validClaims(c *Claims) bool {
+	now := time.Now
+	if v.now != nil {
+		now = v.now
+	}
+	t := now()
+
+	if c.ExpiresAt == 0 || !t.Before(time.Unix(c.ExpiresAt, 0).Add(v.Leeway)) {
+		return false
+	}
+	if c.NotBefore != 0 && t.Add(v.Leeway).Before(time.Unix(c.NotBefore, 0)) {
+		return false
+	}
+	if len(v.Issuers) > 0 && !contains(v.Issuers, c.Issuer) {
+		return false
+	}
+	if v.Audience != "" && !c.Audience.Contains(v.Audience) {
+		return false
+	}
+	return true
+}
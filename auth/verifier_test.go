@@ -0,0 +1,187 @@
+// verifier_test.go
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// signToken подписывает утверждения claims ключом key алгоритмом alg.
+func signToken(t *testing.T, alg, kid string, key crypto.Signer, claims Claims) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	var signature []byte
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, _ = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// writeJWKS записывает открытые ключи в файл JWKS и возвращает его путь.
+func writeJWKS(t *testing.T, rsaKey *rsa.PrivateKey, ecKey *ecdsa.PrivateKey) string {
+	t.Helper()
+	b64 := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	jwks := fmt.Sprintf(`{"keys":[
+		{"kty":"RSA","kid":"rsa-1","use":"sig","n":%q,"e":%q},
+		{"kty":"EC","kid":"ec-1","crv":"P-256","x":%q,"y":%q},
+		{"kty":"RSA","kid":"enc-1","use":"enc","n":"AQAB","e":"AQAB"}
+	]}`,
+		b64(rsaKey.N.Bytes()), b64(big.NewInt(int64(rsaKey.E)).Bytes()),
+		b64(ecKey.X.FillBytes(make([]byte, 32))), b64(ecKey.Y.FillBytes(make([]byte, 32))))
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, []byte(jwks), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestVerifierAsymmetric(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keys, err := NewKeySet(writeJWKS(t, rsaKey, ecKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keys.Key("enc-1") != nil {
+		t.Error("expected encryption key to be skipped")
+	}
+
+	v := &Verifier{Secret: []byte("secret"), Keys: keys, Issuers: []string{"idp"}, Audience: "gateway", Roles: map[string]string{"moderator": "moderator"}}
+	now := time.Now()
+	valid := Claims{Subject: "1", Username: "john", Roles: []string{"moderator"}, Issuer: "idp", Audience: Audience{"gateway"}, ExpiresAt: now.Add(time.Hour).Unix()}
+
+	tt := []struct {
+		name   string
+		alg    string
+		kid    string
+		key    crypto.Signer
+		modify func(*Claims)
+		valid  bool
+	}{
+		{"RS256", "RS256", "rsa-1", rsaKey, nil, true},
+		{"ES256", "ES256", "ec-1", ecKey, nil, true},
+		{"unknown kid", "RS256", "rsa-2", rsaKey, nil, false},
+		{"key of another type", "ES256", "rsa-1", ecKey, nil, false},
+		{"expired", "RS256", "rsa-1", rsaKey, func(c *Claims) { c.ExpiresAt = now.Add(-time.Hour).Unix() }, false},
+		{"without exp", "RS256", "rsa-1", rsaKey, func(c *Claims) { c.ExpiresAt = 0 }, false},
+		{"not yet valid", "RS256", "rsa-1", rsaKey, func(c *Claims) { c.NotBefore = now.Add(time.Hour).Unix() }, false},
+		{"wrong issuer", "RS256", "rsa-1", rsaKey, func(c *Claims) { c.Issuer = "other" }, false},
+		{"wrong audience", "RS256", "rsa-1", rsaKey, func(c *Claims) { c.Audience = Audience{"other"} }, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			claims := valid
+			if tc.modify != nil {
+				tc.modify(&claims)
+			}
+			got, err := v.Verify(signToken(t, tc.alg, tc.kid, tc.key, claims))
+			if tc.valid && (err != nil || !got.HasRole("moderator")) {
+				t.Errorf("expected valid token, got %+v, %v", got, err)
+			}
+			if !tc.valid && err != ErrInvalidToken {
+				t.Errorf("expected ErrInvalidToken, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifierExternalIdentity(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keys, err := NewKeySet(writeJWKS(t, rsaKey, ecKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := []byte("secret")
+	v := &Verifier{Secret: secret, Keys: keys, Issuers: []string{DefaultIssuer, "idp"}, Roles: map[string]string{"idp-moderator": "moderator"}}
+	exp := time.Now().Add(time.Hour).Unix()
+
+	tt := []struct {
+		name      string
+		claims    Claims
+		wantSub   string
+		wantName  string
+		wantRoles []string
+	}{
+		{"mapped role", Claims{Subject: "42", Username: "john", Roles: []string{"idp-moderator"}, Issuer: "idp", ExpiresAt: exp}, "idp|42", "idp|john", []string{"moderator"}},
+		{"unmapped roles dropped", Claims{Subject: "42", Username: "john", Roles: []string{"admin"}, Issuer: "idp", ExpiresAt: exp}, "idp|42", "idp|john", nil},
+		{"gateway issuer is still external", Claims{Subject: "1", Username: "admin", Roles: []string{"admin"}, Issuer: DefaultIssuer, ExpiresAt: exp}, DefaultIssuer + "|1", DefaultIssuer + "|admin", nil},
+		{"name from subject", Claims{Subject: "42", Issuer: "idp", ExpiresAt: exp}, "idp|42", "idp|42", nil},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := v.Verify(signToken(t, "RS256", "rsa-1", rsaKey, tc.claims))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.Subject != tc.wantSub || got.Username != tc.wantName || !reflect.DeepEqual(got.Roles, tc.wantRoles) {
+				t.Errorf("expected %s %s %v, got %s %s %v", tc.wantSub, tc.wantName, tc.wantRoles, got.Subject, got.Username, got.Roles)
+			}
+		})
+	}
+
+	if _, err := v.Verify(signToken(t, "RS256", "rsa-1", rsaKey, Claims{Username: "john", Issuer: "idp", ExpiresAt: exp})); err != ErrInvalidToken {
+		t.Errorf("expected token without sub to be rejected, got %v", err)
+	}
+
+	// Токены шлюза подписаны общим секретом и остаются как есть.
+	token, _ := NewIssuer(secret, time.Hour).Issue(1, "john", "admin")
+	if got, err := v.Verify(token); err != nil || got.Subject != "1" || got.Username != "john" || !got.HasRole("admin") {
+		t.Errorf("expected gateway token unchanged, got %+v, %v", got, err)
+	}
+}
+
+func TestVerifierRejectsAlgorithmConfusion(t *testing.T) {
+	// Токен HS256 не должен проходить проверку без общего секрета,
+	// даже если у проверяющего есть открытые ключи.
+	issuer := NewIssuer([]byte("secret"), time.Hour)
+	token, _ := issuer.Issue(1, "john")
+
+	v := &Verifier{Issuers: []string{DefaultIssuer}}
+	if _, err := v.Verify(token); err != ErrInvalidToken {
+		t.Errorf("expected ErrInvalidToken, got %v", err)
+	}
+
+	v.Secret = issuer.Secret
+	if _, err := v.Verify(token); err != nil {
+		t.Errorf("expected gateway token to be valid, got %v", err)
+	}
+}
+
+func TestAudienceJSON(t *testing.T) {
+	var claims Claims
+	if err := json.Unmarshal([]byte(`{"aud":"gateway"}`), &claims); err != nil || !claims.Audience.Contains("gateway") {
+		t.Errorf("expected single audience, got %v, %v", claims.Audience, err)
+	}
+	if err := json.Unmarshal([]byte(`{"aud":["a","gateway"]}`), &claims); err != nil || !claims.Audience.Contains("gateway") {
+		t.Errorf("expected audience list, got %v, %v", claims.Audience, err)
+	}
+}
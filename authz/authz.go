@@ -0,0 +1,115 @@
+// Package authz - authz.go
+package authz
+
+import (
+	"APIGateway/auth"
+	"errors"
+)
+
+// Роли пользователей.
+const (
+	Reader    = "reader"
+	Commenter = "commenter"
+	Moderator = "moderator"
+	Admin     = "admin"
+)
+
+// Permission право на действие.
+type Permission string
+
+// Права. Права с суффиксом :own действуют только на собственные ресурсы, :any — на любые.
+const (
+	ReadComments     Permission = "comments:read"
+	CreateComment    Permission = "comments:create"
+	ReactToComments  Permission = "comments:react"
+	ReportComments   Permission = "comments:report"
+	EditOwnComment   Permission = "comments:edit:own"
+	DeleteOwnComment Permission = "comments:delete:own"
+	HideAnyComment   Permission = "comments:hide:any"
+	DeleteAnyComment Permission = "comments:delete:any"
+	ReviewComments   Permission = "comments:review"
+	ManageDictionary Permission = "dictionary:manage"
+	ManageUsers      Permission = "users:manage"
+	ViewQuota        Permission = "quota:view"
+	ManageIngestion  Permission = "news:ingest"
+)
+
+// Matrix права каждой роли.
+var Matrix = map[string][]Permission{
+	Reader: {ReadComments, ReportComments},
+	Commenter: {
+		ReadComments, ReportComments, CreateComment, ReactToComments, EditOwnComment, DeleteOwnComment,
+	},
+	Moderator: {
+		ReadComments, ReportComments, CreateComment, ReactToComments, EditOwnComment, DeleteOwnComment,
+		HideAnyComment, DeleteAnyComment, ReviewComments,
+	},
+	Admin: {
+		ReadComments, ReportComments, CreateComment, ReactToComments, EditOwnComment, DeleteOwnComment,
+		HideAnyComment, DeleteAnyComment, ReviewComments,
+		ManageDictionary, ManageUsers, ViewQuota, ManageIngestion,
+	},
+}
+
+var (
+	// ErrUnauthenticated возвращается, когда действие требует входа.
+	ErrUnauthenticated = errors.New("authentication required")
+	// ErrForbidden возвращается, когда у пользователя нет нужного права.
+	ErrForbidden = errors.New("insufficient permissions")
+)
+
+// ValidRole сообщает, известна ли роль.
+func ValidRole(role string) bool {
+	_, ok := Matrix[role]
+	return ok
+}
+
+// RolesOf возвращает роли пользователя. Анонимный пользователь и пользователь
+// без ролей считаются читателями.
+func RolesOf(claims *auth.Claims) []string {
+	if claims == nil || len(claims.Roles) == 0 {
+		return []string{Reader}
+	}
+	return claims.Roles
+}
+
+// Can сообщает, дает ли хотя бы одна из ролей право perm.
+func Can(roles []string, perm Permission) bool {
+	for _, role := range roles {
+		for _, p := range Matrix[role] {
+			if p == perm {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Authorize проверяет право perm пользователя с утверждениями claims.
+func Authorize(claims *auth.Claims, perm Permission) error {
+	if Can(RolesOf(claims), perm) {
+		return nil
+	}
+	if claims == nil {
+		return ErrUnauthenticated
+	}
+	return ErrForbidden
+}
+
+// AuthorizeOwned проверяет право на ресурс владельца owner: право anyPerm
+// действует на любой ресурс, ownPerm — только на ресурсы самого пользователя.
+// owner сравнивается с субъектом токена, а не с отображаемым именем.
+// Пустое anyPerm разрешает действие только над своими ресурсами.
+func AuthorizeOwned(claims *auth.Claims, anyPerm, ownPerm Permission, owner string) error {
+	if claims == nil {
+		return ErrUnauthenticated
+	}
+	roles := RolesOf(claims)
+	if anyPerm != "" && Can(roles, anyPerm) {
+		return nil
+	}
+	if owner != "" && claims.Subject == owner && Can(roles, ownPerm) {
+		return nil
+	}
+	return ErrForbidden
+}
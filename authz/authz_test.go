@@ -0,0 +1,81 @@
+// authz_test.go
+package authz
+
+import (
+	"APIGateway/auth"
+	"testing"
+)
+
+func TestMatrix(t *testing.T) {
+	tt := []struct {
+		role string
+		perm Permission
+		want bool
+	}{
+		{Reader, ReadComments, true},
+		{Reader, CreateComment, false},
+		{Reader, ReportComments, true},
+		{Commenter, CreateComment, true},
+		{Commenter, EditOwnComment, true},
+		{Commenter, DeleteOwnComment, true},
+		{Commenter, DeleteAnyComment, false},
+		{Commenter, ReviewComments, false},
+		{Moderator, HideAnyComment, true},
+		{Moderator, DeleteAnyComment, true},
+		{Moderator, ReviewComments, true},
+		{Moderator, ManageDictionary, false},
+		{Admin, ManageDictionary, true},
+		{Admin, ManageUsers, true},
+		{Admin, DeleteAnyComment, true},
+		{"unknown", ReadComments, false},
+	}
+	for _, tc := range tt {
+		if got := Can([]string{tc.role}, tc.perm); got != tc.want {
+			t.Errorf("Can(%s, %s) = %v, want %v", tc.role, tc.perm, got, tc.want)
+		}
+	}
+}
+
+func TestAuthorize(t *testing.T) {
+	commenter := &auth.Claims{Username: "john", Roles: []string{Commenter}}
+	tt := []struct {
+		name   string
+		claims *auth.Claims
+		perm   Permission
+		want   error
+	}{
+		{"anonymous reads", nil, ReadComments, nil},
+		{"anonymous writes", nil, CreateComment, ErrUnauthenticated},
+		{"user without roles", &auth.Claims{Username: "john"}, CreateComment, ErrForbidden},
+		{"commenter writes", commenter, CreateComment, nil},
+		{"commenter reviews", commenter, ReviewComments, ErrForbidden},
+		{"several roles", &auth.Claims{Roles: []string{Reader, Moderator}}, ReviewComments, nil},
+	}
+	for _, tc := range tt {
+		if err := Authorize(tc.claims, tc.perm); err != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
+
+func TestAuthorizeOwned(t *testing.T) {
+	tt := []struct {
+		name   string
+		claims *auth.Claims
+		owner  string
+		want   error
+	}{
+		{"author deletes own", &auth.Claims{Subject: "1", Username: "john", Roles: []string{Commenter}}, "1", nil},
+		{"author deletes other", &auth.Claims{Subject: "1", Username: "john", Roles: []string{Commenter}}, "2", ErrForbidden},
+		{"display name is not ownership", &auth.Claims{Subject: "apikey:7", Username: "john", Roles: []string{Commenter}}, "1", ErrForbidden},
+		{"reader deletes own", &auth.Claims{Subject: "1", Username: "john", Roles: []string{Reader}}, "1", ErrForbidden},
+		{"moderator deletes any", &auth.Claims{Subject: "3", Username: "mod", Roles: []string{Moderator}}, "2", nil},
+		{"anonymous comment", &auth.Claims{Username: "", Roles: []string{Commenter}}, "", ErrForbidden},
+		{"anonymous user", nil, "1", ErrUnauthenticated},
+	}
+	for _, tc := range tt {
+		if err := AuthorizeOwned(tc.claims, DeleteAnyComment, DeleteOwnComment, tc.owner); err != tc.want {
+			t.Errorf("%s: got %v, want %v", tc.name, err, tc.want)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+// Package censor - admin.go
+package censor
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// AdminHandler HTTP API управления словарем:
+// GET возвращает правила (с фильтром ?lang=), POST добавляет правило,
+// DELETE ?lang=&word= удаляет правило.
+func (e *Engine) AdminHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		lang := r.URL.Query().Get("lang")
+		rules := []Rule{}
+		for _, rule := range e.Rules() {
+			if lang == "" || rule.Lang == lang {
+				rules = append(rules, rule)
+			}
+		}
+		writeJSON(w, http.StatusOK, rules)
+	case http.MethodPost:
+		var rule Rule
+		if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid rule format"})
+			return
+		}
+		if err := rule.Validate(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := e.Add(rule); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, rule)
+	case http.MethodDelete:
+		query := r.URL.Query()
+		if query.Get("word") == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "'word' parameter is required"})
+			return
+		}
+		if err := e.Remove(query.Get("lang"), query.Get("word")); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, POST, DELETE")
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "Method not allowed"})
+	}
+}
+
+// ReloadHandler принудительно перечитывает словарь.
+func (e *Engine) ReloadHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "Method not allowed"})
+		return
+	}
+	if err := e.Reload(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"rules": len(e.Rules())})
+}
+
+func writeJSON(w http.ResponseWriter, code int, payload interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(payload)
+}
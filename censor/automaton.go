@@ -0,0 +1,84 @@
+// Package censor - automaton.go
+package censor
+
+// automaton автомат Ахо-Корасик над рунами. Находит все вхождения всех
+// шаблонов за один проход по тексту.
+type automaton struct {
+	nodes    []acNode
+	patterns [][]rune
+}
+
+type acNode struct {
+	next map[rune]int32
+	fail int32
+	// out номера шаблонов, оканчивающихся в этом узле, включая достижимые по ссылкам неудач.
+	out []int32
+}
+
+// buildAutomaton строит автомат по шаблонам. Номер шаблона — его индекс в patterns.
+func buildAutomaton(patterns [][]rune) *automaton {
+	a := &automaton{nodes: []acNode{{}}, patterns: patterns}
+
+	for i, pattern := range patterns {
+		node := int32(0)
+		for _, r := range pattern {
+			next, ok := a.nodes[node].next[r]
+			if !ok {
+				next = int32(len(a.nodes))
+				a.nodes = append(a.nodes, acNode{})
+				if a.nodes[node].next == nil {
+					a.nodes[node].next = make(map[rune]int32)
+				}
+				a.nodes[node].next[r] = next
+			}
+			node = next
+		}
+		a.nodes[node].out = append(a.nodes[node].out, int32(i))
+	}
+
+	// Ссылки неудач строятся обходом в ширину: у узла глубины d ссылка ведет
+	// в узел меньшей глубины, который уже обработан.
+	queue := make([]int32, 0, len(a.nodes))
+	for _, child := range a.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		node := queue[0]
+		queue = queue[1:]
+		for r, child := range a.nodes[node].next {
+			fail := a.nodes[node].fail
+			for fail != 0 && !a.has(fail, r) {
+				fail = a.nodes[fail].fail
+			}
+			if next, ok := a.nodes[fail].next[r]; ok && next != child {
+				a.nodes[child].fail = next
+			}
+			a.nodes[child].out = append(a.nodes[child].out, a.nodes[a.nodes[child].fail].out...)
+			queue = append(queue, child)
+		}
+	}
+	return a
+}
+
+func (a *automaton) has(node int32, r rune) bool {
+	_, ok := a.nodes[node].next[r]
+	return ok
+}
+
+// scan проходит по тексту и вызывает emit для каждого вхождения шаблона
+// с его номером и позициями [start, end) в text.
+func (a *automaton) scan(text []rune, emit func(pattern, start, end int)) {
+	node := int32(0)
+	for i, r := range text {
+		for node != 0 && !a.has(node, r) {
+			node = a.nodes[node].fail
+		}
+		if next, ok := a.nodes[node].next[r]; ok {
+			node = next
+		}
+		for _, p := range a.nodes[node].out {
+			end := i + 1
+			emit(int(p), end-len(a.patterns[p]), end)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+// automaton_test.go
+package censor
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestAutomatonFindsAllMatches(t *testing.T) {
+	patterns := [][]rune{[]rune("he"), []rune("she"), []rune("his"), []rune("hers"), []rune("е")}
+	a := buildAutomaton(patterns)
+
+	var got []string
+	a.scan([]rune("ushers и еж"), func(p, start, end int) {
+		got = append(got, fmt.Sprintf("%s@%d-%d", string(patterns[p]), start, end))
+	})
+	sort.Strings(got)
+
+	want := []string{"he@2-4", "hers@2-6", "she@1-4", "е@9-10"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestAutomatonMatchesNaiveSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []rune("abcй")
+	randomRunes := func(n int) []rune {
+		runes := make([]rune, n)
+		for i := range runes {
+			runes[i] = alphabet[rng.Intn(len(alphabet))]
+		}
+		return runes
+	}
+
+	for round := 0; round < 50; round++ {
+		var patterns [][]rune
+		for i := 0; i < 10; i++ {
+			patterns = append(patterns, randomRunes(1+rng.Intn(4)))
+		}
+		text := randomRunes(200)
+
+		got := map[string]bool{}
+		buildAutomaton(patterns).scan(text, func(p, start, end int) {
+			got[fmt.Sprint(p, start, end)] = true
+		})
+
+		want := map[string]bool{}
+		for p, pattern := range patterns {
+			for start := naiveIndex(text, pattern, 0); start >= 0; start = naiveIndex(text, pattern, start+1) {
+				want[fmt.Sprint(p, start, start+len(pattern))] = true
+			}
+		}
+
+		if len(got) != len(want) {
+			t.Fatalf("round %d: got %d matches, want %d", round, len(got), len(want))
+		}
+		for m := range want {
+			if !got[m] {
+				t.Fatalf("round %d: missing match %s", round, m)
+			}
+		}
+	}
+}
+
+// naiveIndex прежний поиск подстроки, используется как эталон.
+func naiveIndex(haystack, needle []rune, from int) int {
+	for i := from; i+len(needle) <= len(haystack); i++ {
+		found := true
+		for j := range needle {
+			if haystack[i+j] != needle[j] {
+				found = false
+				break
+			}
+		}
+		if found {
+			return i
+		}
+	}
+	return -1
+}
+
+// benchmarkTerms генерирует n словарных слов и комментарий, содержащий одно из них.
+func benchmarkTerms(n int) ([]string, string) {
+	rng := rand.New(rand.NewSource(42))
+	letters := []rune("абвгдежзиклмнопрстуфхцчшщыэюя")
+	terms := make([]string, n)
+	for i := range terms {
+		word := make([]rune, 5+rng.Intn(6))
+		for j := range word {
+			word[j] = letters[rng.Intn(len(letters))]
+		}
+		terms[i] = string(word)
+	}
+
+	comment := strings.Repeat("Это вполне обычный комментарий к новости про погоду. ", 10) + terms[n/2]
+	return terms, comment
+}
+
+// BenchmarkCheck сканирование автоматом Ахо-Корасик после нормализации.
+func BenchmarkCheck(b *testing.B) {
+	for _, n := range []int{10, 1000, 5000} {
+		terms, comment := benchmarkTerms(n)
+		set, err := Compile(WordRules(terms, 1))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.Run(fmt.Sprint("terms=", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				set.Check(comment, "")
+			}
+		})
+	}
+}
+
+// BenchmarkNaiveLoop прежняя реализация: проход по тексту для каждого слова.
+func BenchmarkNaiveLoop(b *testing.B) {
+	for _, n := range []int{10, 1000, 5000} {
+		terms, comment := benchmarkTerms(n)
+		keys := make([]*collapsed, len(terms))
+		for i, term := range terms {
+			keys[i] = collapse(normalize(term))
+		}
+		b.Run(fmt.Sprint("terms=", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				text := collapse(normalize(comment))
+				for _, key := range keys {
+					naiveIndex(text.runes, key.runes, 0)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkRegexpPerWord исходный ModerateComment: компиляция регулярного выражения на каждое слово.
+func BenchmarkRegexpPerWord(b *testing.B) {
+	for _, n := range []int{10, 1000, 5000} {
+		terms, comment := benchmarkTerms(n)
+		b.Run(fmt.Sprint("terms=", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				lower := strings.ToLower(comment)
+				for _, term := range terms {
+					if match, _ := regexp.MatchString(term, lower); match {
+						break
+					}
+				}
+			}
+		})
+	}
+}
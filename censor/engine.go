@@ -0,0 +1,139 @@
+// Package censor - engine.go
+package censor
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Engine движок правил цензуры. Хранит скомпилированный набор правил и
+// подменяет его атомарно при изменении словаря.
+type Engine struct {
+	store Store
+
+	mu      sync.Mutex
+	version string
+	rules   atomic.Value // *RuleSet
+}
+
+// NewEngine создает движок и загружает правила из store.
+func NewEngine(store Store) (*Engine, error) {
+	e := &Engine{store: store}
+	if err := e.Reload(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// DefaultEngine создает движок со встроенным словарем.
+func DefaultEngine() *Engine {
+	e, err := NewEngine(NewMemoryStore(DefaultRules))
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// EngineFromEnv создает движок по источнику из StoreFromEnv.
+func EngineFromEnv() (*Engine, error) {
+	store, err := StoreFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	return NewEngine(store)
+}
+
+// Store возвращает источник правил движка.
+func (e *Engine) Store() Store {
+	return e.store
+}
+
+// Check проверяет текст текущим набором правил.
+func (e *Engine) Check(text string, lang string) Verdict {
+	return e.rules.Load().(*RuleSet).Check(text, lang)
+}
+
+// Rules возвращает действующие правила.
+func (e *Engine) Rules() []Rule {
+	return e.rules.Load().(*RuleSet).Rules()
+}
+
+// Reload перечитывает словарь. При ошибке остается прежний набор правил.
+func (e *Engine) Reload() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	version, err := e.store.Version()
+	if err != nil {
+		return err
+	}
+	rules, err := e.store.Load()
+	if err != nil {
+		return err
+	}
+	set, err := Compile(rules)
+	if err != nil {
+		return err
+	}
+
+	e.rules.Store(set)
+	e.version = version
+	return nil
+}
+
+// Add добавляет правило в словарь и сразу применяет его.
+func (e *Engine) Add(rule Rule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	if err := e.store.Add(rule); err != nil {
+		return err
+	}
+	return e.Reload()
+}
+
+// Remove удаляет правило из словаря и сразу применяет изменение.
+func (e *Engine) Remove(lang, word string) error {
+	if err := e.store.Remove(lang, word); err != nil {
+		return err
+	}
+	return e.Reload()
+}
+
+// Watch периодически проверяет версию словаря и перезагружает его при изменении.
+// Останавливается при закрытии stop.
+func (e *Engine) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if err := e.reloadIfChanged(); err != nil {
+				log.Printf("Dictionary reload failed: %v", err)
+			}
+		}
+	}
+}
+
+// reloadIfChanged перезагружает словарь, если его версия изменилась.
+func (e *Engine) reloadIfChanged() error {
+	version, err := e.store.Version()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	changed := version != e.version
+	e.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+	log.Printf("Dictionary changed, reloading (version %s)", version)
+	return e.Reload()
+}
@@ -0,0 +1,170 @@
+// engine_test.go
+package censor
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEngineFileStoreHotReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dictionary.json")
+	if err := ioutil.WriteFile(path, []byte(`{"en": [{"word": "foo"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	engine, err := NewEngine(NewFileStore(path))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if engine.Check("foo", "en").Decision != Blocked {
+		t.Errorf("expected foo to be blocked")
+	}
+
+	// Файл меняется в обход API, движок должен заметить новую версию.
+	time.Sleep(10 * time.Millisecond)
+	if err := ioutil.WriteFile(path, []byte(`{"ru": [{"word": "бар", "mode": "word"}]}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.reloadIfChanged(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if engine.Check("foo", "").Decision != Allowed {
+		t.Errorf("expected foo to be allowed after reload")
+	}
+	if engine.Check("бар", "ru").Decision != Blocked {
+		t.Errorf("expected бар to be blocked after reload")
+	}
+}
+
+func TestEngineKeepsRulesOnInvalidReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dictionary.json")
+	ioutil.WriteFile(path, []byte(`{"en": [{"word": "foo"}]}`), 0644)
+
+	engine, _ := NewEngine(NewFileStore(path))
+	ioutil.WriteFile(path, []byte(`{"en": [{"word": "(", "mode": "regex"}]}`), 0644)
+
+	if err := engine.Reload(); err == nil {
+		t.Errorf("expected reload error")
+	}
+	if engine.Check("foo", "").Decision != Blocked {
+		t.Errorf("expected previous rules to stay active")
+	}
+}
+
+func TestFileStoreAddRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dictionary.json")
+	engine, err := NewEngine(NewMemoryStore(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	engine.store = NewFileStore(path)
+
+	if err := engine.Add(Rule{Word: "foo", Lang: "en", Mode: WholeWord}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	data, _ := ioutil.ReadFile(path)
+	if !strings.Contains(string(data), `"en"`) || !strings.Contains(string(data), `"foo"`) {
+		t.Errorf("unexpected dictionary file: %s", data)
+	}
+
+	if err := engine.Remove("en", "foo"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(engine.Rules()) != 0 {
+		t.Errorf("expected no rules, got %v", engine.Rules())
+	}
+}
+
+func TestAdminHandler(t *testing.T) {
+	engine, _ := NewEngine(NewMemoryStore(nil))
+
+	tt := []struct {
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{"POST", "/dictionary", `{"word":"foo","lang":"en","mode":"word"}`, http.StatusCreated},
+		{"POST", "/dictionary", `{"word":"(","mode":"regex"}`, http.StatusBadRequest},
+		{"GET", "/dictionary?lang=en", "", http.StatusOK},
+		{"DELETE", "/dictionary?lang=en", "", http.StatusBadRequest},
+		{"PUT", "/dictionary", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range tt {
+		rec := httptest.NewRecorder()
+		engine.AdminHandler(rec, httptest.NewRequest(tc.method, tc.target, strings.NewReader(tc.body)))
+		if rec.Code != tc.wantStatus {
+			t.Errorf("%s %s: got status %d, want %d", tc.method, tc.target, rec.Code, tc.wantStatus)
+		}
+	}
+
+	if engine.Check("foo", "en").Decision != Blocked {
+		t.Errorf("expected added rule to apply without reload")
+	}
+
+	rec := httptest.NewRecorder()
+	engine.AdminHandler(rec, httptest.NewRequest("DELETE", "/dictionary?lang=en&word=foo", nil))
+	if rec.Code != http.StatusNoContent || engine.Check("foo", "en").Decision != Allowed {
+		t.Errorf("expected rule to be removed, got status %d", rec.Code)
+	}
+}
+
+func TestSQLStoreVersionTracksUpdates(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s := NewSQLStore(db)
+
+	// Правка существующего правила не меняет ни количество, ни MAX(id).
+	for _, updated := range []string{"2024-05-01 10:00:00.000000", "2024-05-01 10:05:00.000000"} {
+		mock.ExpectQuery(`SELECT COUNT\(\*\), MAX\(id\), MAX\(updated_at\) FROM forbidden_words`).
+			WillReturnRows(sqlmock.NewRows([]string{"count", "max_id", "updated"}).AddRow(3, 7, updated))
+	}
+	before, err := s.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	after, err := s.Version()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if before == after {
+		t.Errorf("expected the version to change after an update, got %q twice", before)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAdminFromEnvRequiresSharedStore(t *testing.T) {
+	cases := []struct {
+		name    string
+		admin   string
+		store   Store
+		enabled bool
+		err     error
+	}{
+		{"memory store", "", NewMemoryStore(DefaultRules), false, ErrNoSharedStore},
+		{"memory store, admin off", "false", NewMemoryStore(DefaultRules), false, nil},
+		{"file store", "", NewFileStore(filepath.Join(t.TempDir(), "dictionary.json")), true, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv("DICTIONARY_ADMIN", c.admin)
+			enabled, err := AdminFromEnv(c.store)
+			if enabled != c.enabled || err != c.err {
+				t.Errorf("expected (%v, %v), got (%v, %v)", c.enabled, c.err, enabled, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// Package censor - normalize.go
+package censor
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// normalized текст, приведенный к «скелету» для сопоставления. Для каждой руны
+// хранится диапазон исходных рун [starts[i], ends[i]), из которого она получена.
+type normalized struct {
+	runes  []rune
+	starts []int
+	ends   []int
+}
+
+func (n *normalized) push(r rune, start, end int) {
+	n.runes = append(n.runes, r)
+	n.starts = append(n.starts, start)
+	n.ends = append(n.ends, end)
+}
+
+// normalize прогоняет текст через конвейер нормализации:
+// NFKC, удаление невидимых и комбинируемых символов, замену похожих символов
+// других алфавитов на латиницу, нижний регистр, leetspeak и удаление разделителей.
+func normalize(text string) *normalized {
+	n := decompose(text)
+	n = mapLeet(n)
+	n = stripSeparators(n)
+	return n
+}
+
+// decompose выполняет NFKC по сегментам, сохраняя привязку к исходным рунам.
+func decompose(text string) *normalized {
+	// runeIndex[b] — номер руны, начинающейся с байта b.
+	runeIndex := make([]int, len(text)+1)
+	count := 0
+	for b := range text {
+		runeIndex[b] = count
+		count++
+	}
+	runeIndex[len(text)] = count
+
+	n := &normalized{}
+	var it norm.Iter
+	it.InitString(norm.NFKC, text)
+	for !it.Done() {
+		from := it.Pos()
+		segment := it.Next()
+		to := it.Pos()
+
+		for len(segment) > 0 {
+			r, size := utf8.DecodeRune(segment)
+			segment = segment[size:]
+			if isInvisible(r) {
+				continue
+			}
+			r = unicode.ToLower(r)
+			if c, ok := confusables[r]; ok {
+				r = c
+			}
+			n.push(r, runeIndex[from], runeIndex[to])
+		}
+	}
+	return n
+}
+
+// isInvisible определяет символы нулевой ширины, форматирующие и комбинируемые знаки.
+func isInvisible(r rune) bool {
+	return unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) || unicode.Is(unicode.Cf, r)
+}
+
+// confusables строчные символы кириллицы и греческого алфавита, которые сами
+// или в верхнем регистре похожи на латинские. Применяется после перевода в
+// нижний регистр, чтобы «Н» и «н» давали один и тот же скелет.
+var confusables = map[rune]rune{
+	'а': 'a', 'в': 'b', 'е': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'ѕ': 's', 'і': 'i', 'ј': 'j', 'ԁ': 'd',
+	'ԛ': 'q', 'ԝ': 'w', 'һ': 'h',
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ζ': 'z', 'η': 'h', 'ι': 'i', 'κ': 'k', 'μ': 'm',
+	'ν': 'n', 'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'y', 'χ': 'x',
+}
+
+// Замены leetspeak. Символ заменяется, если рядом с ним (не считая пробелов)
+// стоит буква; символы из leetStrict — только внутри слова. Рядом с кириллицей
+// используется таблица leetCyrillic.
+var (
+	leet = map[rune]rune{
+		'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+		'@': 'a', '$': 's',
+	}
+	leetCyrillic = map[rune]rune{
+		'0': 'o', '1': 'и', '3': 'з', '4': 'ч', '6': 'б', '@': 'a',
+	}
+	leetStrict = map[rune]rune{'!': 'i', '|': 'i'}
+)
+
+// mapLeet заменяет символы leetspeak, стоящие рядом с буквами. Замененный
+// символ считается буквой для соседей, поэтому "a$$" превращается в "ass".
+func mapLeet(n *normalized) *normalized {
+	runes := append([]rune(nil), n.runes...)
+
+	// neighbor возвращает ближайшую к i в направлении step руну, пропуская пробелы.
+	neighbor := func(i, step int) rune {
+		for i += step; i >= 0 && i < len(runes); i += step {
+			if !unicode.IsSpace(runes[i]) {
+				return runes[i]
+			}
+		}
+		return 0
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for i, r := range runes {
+			left, right := neighbor(i, -1), neighbor(i, 1)
+			var mapped rune
+			var ok bool
+			switch {
+			case unicode.Is(unicode.Cyrillic, left) || unicode.Is(unicode.Cyrillic, right):
+				mapped, ok = leetCyrillic[r]
+			default:
+				mapped, ok = leet[r]
+			}
+			if ok && (unicode.IsLetter(left) || unicode.IsLetter(right)) {
+				runes[i], changed = mapped, true
+				continue
+			}
+			if i > 0 && i+1 < len(runes) && unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i+1]) {
+				if mapped, ok := leetStrict[r]; ok {
+					runes[i], changed = mapped, true
+				}
+			}
+		}
+	}
+
+	return &normalized{runes: runes, starts: n.starts, ends: n.ends}
+}
+
+// stripSeparators удаляет пунктуацию внутри слов (q.w-e_rty) и разделители
+// между одиночными буквами, написанными вразрядку (q w e r t y).
+func stripSeparators(n *normalized) *normalized {
+	return joinSpacedLetters(stripJoiners(n))
+}
+
+// stripJoiners удаляет символы-связки, стоящие между двумя буквами.
+func stripJoiners(n *normalized) *normalized {
+	stripped := &normalized{}
+	for i, r := range n.runes {
+		if isJoiner(r) && i > 0 && i+1 < len(n.runes) &&
+			unicode.IsLetter(n.runes[i-1]) && unicode.IsLetter(n.runes[i+1]) {
+			continue
+		}
+		stripped.push(r, n.starts[i], n.ends[i])
+	}
+	return stripped
+}
+
+// joinSpacedLetters склеивает цепочки из трех и более одиночных букв,
+// разделенных только разделителями.
+func joinSpacedLetters(n *normalized) *normalized {
+	drop := make([]bool, len(n.runes))
+	var chain []int
+	flush := func() {
+		if len(chain) >= 3 {
+			for k := 0; k+1 < len(chain); k++ {
+				for j := chain[k] + 1; j < chain[k+1]; j++ {
+					drop[j] = true
+				}
+			}
+		}
+		chain = chain[:0]
+	}
+
+	for i := 0; i < len(n.runes); {
+		if !unicode.IsLetter(n.runes[i]) {
+			if !isSeparator(n.runes[i]) {
+				flush()
+			}
+			i++
+			continue
+		}
+
+		j := i
+		for j < len(n.runes) && unicode.IsLetter(n.runes[j]) {
+			j++
+		}
+		if j-i > 1 {
+			flush()
+		} else {
+			chain = append(chain, i)
+		}
+		i = j
+	}
+	flush()
+
+	joined := &normalized{}
+	for i, r := range n.runes {
+		if !drop[i] {
+			joined.push(r, n.starts[i], n.ends[i])
+		}
+	}
+	return joined
+}
+
+// isJoiner символы, которыми разбивают слово изнутри.
+func isJoiner(r rune) bool {
+	switch r {
+	case '.', '_', '-', '*', '~', '\'', '`', '"', '·', '•', '/', '\\':
+		return true
+	}
+	return false
+}
+
+// isSeparator символы, допустимые между буквами, написанными вразрядку.
+func isSeparator(r rune) bool {
+	return unicode.IsSpace(r) || isJoiner(r) || r == ',' || r == '+'
+}
+
+// collapsed нормализованный текст, в котором серии одинаковых рун сжаты в одну.
+// counts хранит длину каждой серии.
+type collapsed struct {
+	normalized
+	counts []int
+}
+
+// collapse сжимает повторяющиеся руны: "qqwwerrty" -> "qwerty".
+func collapse(n *normalized) *collapsed {
+	c := &collapsed{}
+	for i, r := range n.runes {
+		last := len(c.runes) - 1
+		if last >= 0 && c.runes[last] == r {
+			c.ends[last] = n.ends[i]
+			c.counts[last]++
+			continue
+		}
+		c.push(r, n.starts[i], n.ends[i])
+		c.counts = append(c.counts, 1)
+	}
+	return c
+}
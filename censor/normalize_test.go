@@ -0,0 +1,86 @@
+// normalize_test.go
+package censor
+
+import (
+	"bufio"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tt := []struct {
+		input string
+		want  string
+	}{
+		{"QWERTY", "qwerty"},
+		{"ｑｗｅｒｔｙ", "qwerty"},
+		{"qwеrty", "qwerty"},
+		{"q​w", "qw"},
+		{"q w e r t y", "qwerty"},
+		{"hello world", "hello world"},
+		{"h3ll0 2024", "hello 2024"},
+		{"q.w-e_r", "qwer"},
+		{"end.", "end."},
+	}
+
+	for _, tc := range tt {
+		if got := string(normalize(tc.input).runes); got != tc.want {
+			t.Errorf("normalize(%q) = %q, want %q", tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestNormalizeKeepsSourceSpans(t *testing.T) {
+	set, _ := Compile([]Rule{{Word: "qwerty"}})
+
+	verdict := set.Check("xx q.w​3.r.t.y!", "")
+	if len(verdict.Matches) != 1 {
+		t.Fatalf("expected one match, got %v", verdict.Matches)
+	}
+	if m := verdict.Matches[0]; m.Start != 3 || m.End != 14 {
+		t.Errorf("got span [%d, %d), want [3, 14)", m.Start, m.End)
+	}
+	if verdict.Masked != "xx q*********y!" {
+		t.Errorf("unexpected masked text: %q", verdict.Masked)
+	}
+}
+
+func TestEvasionCorpus(t *testing.T) {
+	rules := append([]Rule{{Word: "ass", Lang: "en", Mode: WholeWord, Severity: 1}}, DefaultRules...)
+	set, err := Compile(rules)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	file, err := os.Open("testdata/evasion_corpus.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		parts := strings.SplitN(text, "\t", 2)
+		if len(parts) != 2 {
+			t.Fatalf("line %d: expected decision and text separated by tab", line)
+		}
+		input, err := strconv.Unquote(`"` + parts[1] + `"`)
+		if err != nil {
+			t.Fatalf("line %d: %s", line, err)
+		}
+
+		if got := set.Check(input, "").Decision; got != Decision(parts[0]) {
+			t.Errorf("line %d: %q got %q, want %q", line, parts[1], got, parts[0])
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,296 @@
+// Package censor - rules.go
+package censor
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MatchMode способ сопоставления правила с текстом.
+type MatchMode string
+
+const (
+	// Substring срабатывает на любое вхождение слова, в том числе внутри других слов.
+	Substring MatchMode = "substring"
+	// WholeWord срабатывает только на слово целиком.
+	WholeWord MatchMode = "word"
+	// Regex трактует Word как регулярное выражение.
+	Regex MatchMode = "regex"
+)
+
+// Rule правило цензуры: запрещенное слово, язык списка, способ сопоставления
+// и серьезность от 0 до 1. Пустой Lang означает правило для всех языков.
+type Rule struct {
+	Word     string    `json:"word"`
+	Lang     string    `json:"lang,omitempty"`
+	Mode     MatchMode `json:"mode,omitempty"`
+	Severity float64   `json:"severity"`
+}
+
+// DefaultRules встроенный словарь, используемый без внешнего источника.
+var DefaultRules = []Rule{
+	{Word: "qwerty", Lang: "en", Mode: Substring, Severity: 1},
+	{Word: "zxvbnm", Lang: "en", Mode: Substring, Severity: 1},
+	{Word: "йцукен", Lang: "ru", Mode: Substring, Severity: 1},
+	{Word: "пизда", Lang: "ru", Mode: Substring, Severity: 1},
+}
+
+// WordRules создает правила с одинаковой серьезностью для списка слов.
+func WordRules(words []string, severity float64) []Rule {
+	rules := make([]Rule, 0, len(words))
+	for _, word := range words {
+		rules = append(rules, Rule{Word: word, Mode: Substring, Severity: severity})
+	}
+	return rules
+}
+
+type compiledRule struct {
+	Rule
+	runes  []rune
+	counts []int
+	re     *regexp.Regexp
+}
+
+// RuleSet скомпилированный набор правил. Слова всех правил собраны в один
+// автомат Ахо-Корасик, регулярные выражения проверяются отдельно.
+type RuleSet struct {
+	rules    []Rule
+	compiled []compiledRule
+	matcher  *automaton
+	// byPattern номера правил для каждого шаблона автомата.
+	byPattern [][]int
+	regexes   []int
+}
+
+// Validate проверяет корректность правила.
+func (r Rule) Validate() error {
+	if r.Word == "" {
+		return fmt.Errorf("rule word is empty")
+	}
+	switch r.Mode {
+	case "", Substring, WholeWord:
+		if len(normalize(r.Word).runes) == 0 {
+			return fmt.Errorf("rule %q is empty after normalization", r.Word)
+		}
+	case Regex:
+		if _, err := regexp.Compile(r.Word); err != nil {
+			return fmt.Errorf("invalid regex rule %q: %w", r.Word, err)
+		}
+	default:
+		return fmt.Errorf("unknown rule mode %q", r.Mode)
+	}
+	if r.Severity < 0 || r.Severity > 1 {
+		return fmt.Errorf("rule severity must be between 0 and 1")
+	}
+	return nil
+}
+
+// Compile проверяет и компилирует правила. Правила без режима считаются
+// подстроками, без серьезности — блокирующими.
+func Compile(rules []Rule) (*RuleSet, error) {
+	set := &RuleSet{}
+	var patterns [][]rune
+	patternIndex := make(map[string]int)
+
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+		if rule.Mode == "" {
+			rule.Mode = Substring
+		}
+		if rule.Severity == 0 {
+			rule.Severity = 1
+		}
+
+		index := len(set.compiled)
+		compiled := compiledRule{Rule: rule}
+		if rule.Mode == Regex {
+			compiled.re = regexp.MustCompile("(?i)" + rule.Word)
+			set.regexes = append(set.regexes, index)
+		} else {
+			key := collapse(normalize(rule.Word))
+			compiled.runes, compiled.counts = key.runes, key.counts
+
+			p, ok := patternIndex[string(key.runes)]
+			if !ok {
+				p = len(patterns)
+				patternIndex[string(key.runes)] = p
+				patterns = append(patterns, key.runes)
+				set.byPattern = append(set.byPattern, nil)
+			}
+			set.byPattern[p] = append(set.byPattern[p], index)
+		}
+		set.rules = append(set.rules, rule)
+		set.compiled = append(set.compiled, compiled)
+	}
+
+	set.matcher = buildAutomaton(patterns)
+	return set, nil
+}
+
+// Rules возвращает исходные правила набора.
+func (s *RuleSet) Rules() []Rule {
+	return append([]Rule(nil), s.rules...)
+}
+
+// Check проверяет текст по правилам языка lang и общим правилам.
+// Пустой lang проверяет текст по всем спискам. Слова сравниваются с
+// нормализованным текстом, позиции совпадений указывают на исходный текст.
+func (s *RuleSet) Check(text string, lang string) Verdict {
+	runes := []rune(text)
+	t := newTarget(runes)
+
+	var matches []Match
+	seen := make(map[Match]bool)
+	severities := make(map[string]float64)
+	add := func(rule compiledRule, m Match) {
+		if seen[m] {
+			return
+		}
+		seen[m] = true
+		matches = append(matches, m)
+		severities[rule.Word] = rule.Severity
+	}
+	inLang := func(rule compiledRule) bool {
+		return lang == "" || rule.Lang == "" || rule.Lang == lang
+	}
+
+	c := t.collapsed
+	s.matcher.scan(c.runes, func(p, start, end int) {
+		for _, index := range s.byPattern[p] {
+			rule := s.compiled[index]
+			if !inLang(rule) {
+				continue
+			}
+			if m, ok := rule.accept(c, start, end); ok {
+				add(rule, m)
+			}
+		}
+	})
+
+	for _, index := range s.regexes {
+		rule := s.compiled[index]
+		if !inLang(rule) {
+			continue
+		}
+		for _, m := range rule.findRegex(t) {
+			add(rule, m)
+		}
+	}
+	sortMatches(matches)
+
+	return newVerdict(runes, matches, severities)
+}
+
+// target проверяемый текст во всех формах, нужных правилам.
+type target struct {
+	lower      string
+	normalized *normalized
+	skeleton   string
+	collapsed  *collapsed
+}
+
+func newTarget(runes []rune) *target {
+	n := normalize(string(runes))
+	return &target{
+		lower:      string(toLower(runes)),
+		normalized: n,
+		skeleton:   string(n.runes),
+		collapsed:  collapse(n),
+	}
+}
+
+// Check компилирует правила и проверяет по ним текст.
+// Некорректные правила приводят к пустому набору.
+func Check(text string, rules []Rule) Verdict {
+	set, err := Compile(rules)
+	if err != nil {
+		set, _ = Compile(nil)
+	}
+	return set.Check(text, "")
+}
+
+// accept проверяет найденное автоматом вхождение [start, end) в сжатом тексте
+// и переводит его в позиции исходного текста.
+func (r compiledRule) accept(c *collapsed, start, end int) (Match, bool) {
+	if !r.countsMatch(c.counts[start:end]) {
+		return Match{}, false
+	}
+	if r.Mode == WholeWord && !isWordBoundary(c.runes, start, end) {
+		return Match{}, false
+	}
+	return Match{Rule: r.Word, Start: c.starts[start], End: c.ends[end-1]}, true
+}
+
+// findRegex ищет совпадения регулярного выражения и в исходном, и в нормализованном тексте.
+func (r compiledRule) findRegex(t *target) []Match {
+	n := t.normalized
+	matches := r.findRegexIn(t.lower, func(i int) int { return i }, func(i int) int { return i + 1 })
+	return append(matches, r.findRegexIn(t.skeleton,
+		func(i int) int { return n.starts[i] },
+		func(i int) int { return n.ends[i] })...)
+}
+
+// findRegexIn ищет совпадения регулярного выражения в s. startOf и endOf переводят
+// номер руны s в позиции исходного текста.
+func (r compiledRule) findRegexIn(s string, startOf, endOf func(int) int) []Match {
+	var matches []Match
+	for _, loc := range r.re.FindAllStringIndex(s, -1) {
+		if loc[0] == loc[1] {
+			continue
+		}
+		first := utf8.RuneCountInString(s[:loc[0]])
+		last := first + utf8.RuneCountInString(s[loc[0]:loc[1]]) - 1
+		matches = append(matches, Match{Rule: r.Word, Start: startOf(first), End: endOf(last)})
+	}
+	return matches
+}
+
+// countsMatch проверяет, что каждая серия букв в тексте не короче серии в правиле:
+// правило "ass" совпадает с "asss", но не с "as".
+func (r compiledRule) countsMatch(counts []int) bool {
+	for i, count := range counts {
+		if count < r.counts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// isWordBoundary проверяет, что совпадение [start, end) не окружено буквами или цифрами.
+func isWordBoundary(runes []rune, start, end int) bool {
+	if start > 0 && isWordRune(runes[start-1]) {
+		return false
+	}
+	if end < len(runes) && isWordRune(runes[end]) {
+		return false
+	}
+	return true
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// sortMatches упорядочивает совпадения по позиции в тексте.
+func sortMatches(matches []Match) {
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Start != matches[j].Start {
+			return matches[i].Start < matches[j].Start
+		}
+		return matches[i].End < matches[j].End
+	})
+}
+
+// toLower переводит руны в нижний регистр, сохраняя их количество и позиции.
+func toLower(runes []rune) []rune {
+	lower := make([]rune, len(runes))
+	for i, r := range runes {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
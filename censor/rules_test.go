@@ -0,0 +1,77 @@
+// rules_test.go
+package censor
+
+import "testing"
+
+func TestRuleModes(t *testing.T) {
+	set, err := Compile([]Rule{
+		{Word: "ass", Mode: WholeWord},
+		{Word: "bad", Mode: Substring},
+		{Word: `c[a@]t+`, Mode: Regex},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	tt := []struct {
+		text    string
+		matches []Match
+	}{
+		{"classic", nil},
+		{"you ass!", []Match{{Rule: "ass", Start: 4, End: 7}}},
+		{"badge", []Match{{Rule: "bad", Start: 0, End: 3}}},
+		{"ёж C@TTT", []Match{{Rule: `c[a@]t+`, Start: 3, End: 8}}},
+	}
+
+	for _, tc := range tt {
+		verdict := set.Check(tc.text, "")
+		if len(verdict.Matches) != len(tc.matches) {
+			t.Errorf("%q: got matches %v, want %v", tc.text, verdict.Matches, tc.matches)
+			continue
+		}
+		for i := range tc.matches {
+			if verdict.Matches[i] != tc.matches[i] {
+				t.Errorf("%q: got match %v, want %v", tc.text, verdict.Matches[i], tc.matches[i])
+			}
+		}
+	}
+}
+
+func TestRuleLanguages(t *testing.T) {
+	set, _ := Compile([]Rule{
+		{Word: "foo", Lang: "en"},
+		{Word: "бар", Lang: "ru"},
+		{Word: "baz"},
+	})
+
+	tt := []struct {
+		lang    string
+		text    string
+		blocked bool
+	}{
+		{"en", "foo", true},
+		{"ru", "foo", false},
+		{"ru", "бар", true},
+		{"ru", "baz", true},
+		{"", "бар foo", true},
+	}
+
+	for _, tc := range tt {
+		if blocked := set.Check(tc.text, tc.lang).Decision == Blocked; blocked != tc.blocked {
+			t.Errorf("lang %q, text %q: got blocked=%v, want %v", tc.lang, tc.text, blocked, tc.blocked)
+		}
+	}
+}
+
+func TestCompileRejectsInvalidRules(t *testing.T) {
+	for _, rule := range []Rule{
+		{Word: ""},
+		{Word: "(", Mode: Regex},
+		{Word: "x", Mode: "fuzzy"},
+		{Word: "x", Severity: 2},
+	} {
+		if _, err := Compile([]Rule{rule}); err == nil {
+			t.Errorf("expected error for rule %+v", rule)
+		}
+	}
+}
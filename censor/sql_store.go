@@ -0,0 +1,100 @@
+// Package censor - sql_store.go
+package censor
+
+import (
+	"database/sql"
+	"fmt"
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// SQLStore словарь в таблице forbidden_words.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore создает словарь, хранящийся в базе данных.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// Setup создает таблицу словаря, если она еще не существует.
+func (s *SQLStore) Setup() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS forbidden_words (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			lang VARCHAR(16) NOT NULL DEFAULT '',
+			word VARCHAR(255) NOT NULL,
+			mode VARCHAR(16) NOT NULL DEFAULT 'substring',
+			severity DOUBLE NOT NULL DEFAULT 1,
+			updated_at DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6),
+			UNIQUE KEY lang_word (lang, word)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	return s.addColumnIfMissing("updated_at", "DATETIME(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6) ON UPDATE CURRENT_TIMESTAMP(6)")
+}
+
+// addColumnIfMissing добавляет колонку в таблицу словаря, созданную до её появления.
+func (s *SQLStore) addColumnIfMissing(column, definition string) error {
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = 'forbidden_words' AND COLUMN_NAME = ?
+	`, column).Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	_, err = s.db.Exec("ALTER TABLE forbidden_words ADD COLUMN " + column + " " + definition)
+	return err
+}
+
+func (s *SQLStore) Load() ([]Rule, error) {
+	rows, err := s.db.Query(`SELECT lang, word, mode, severity FROM forbidden_words`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []Rule
+	for rows.Next() {
+		var rule Rule
+		if err := rows.Scan(&rule.Lang, &rule.Word, &rule.Mode, &rule.Severity); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+// Version возвращает количество правил, максимальный идентификатор и время
+// последнего изменения правила, чтобы правки существующих строк тоже меняли версию.
+func (s *SQLStore) Version() (string, error) {
+	var count int
+	var maxID sql.NullInt64
+	var updated sql.NullString
+	err := s.db.QueryRow(`SELECT COUNT(*), MAX(id), MAX(updated_at) FROM forbidden_words`).Scan(&count, &maxID, &updated)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d-%d-%s", count, maxID.Int64, updated.String), nil
+}
+
+func (s *SQLStore) Add(rule Rule) error {
+	if rule.Mode == "" {
+		rule.Mode = Substring
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO forbidden_words (lang, word, mode, severity)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE mode = VALUES(mode), severity = VALUES(severity)
+	`, rule.Lang, rule.Word, rule.Mode, rule.Severity)
+	return err
+}
+
+func (s *SQLStore) Remove(lang, word string) error {
+	_, err := s.db.Exec(`DELETE FROM forbidden_words WHERE lang = ? AND word = ?`, lang, word)
+	return err
+}
@@ -0,0 +1,211 @@
+// Package censor - store.go
+package censor
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Store источник словарей запрещенных слов.
+type Store interface {
+	// Load возвращает все правила.
+	Load() ([]Rule, error)
+	// Version возвращает метку, которая меняется при изменении словаря.
+	Version() (string, error)
+	Add(rule Rule) error
+	Remove(lang, word string) error
+}
+
+// StoreFromEnv выбирает источник словарей: файл из DICTIONARY_PATH, таблицу
+// в базе DICTIONARY_DSN или встроенные DefaultRules.
+func StoreFromEnv() (Store, error) {
+	if path := os.Getenv("DICTIONARY_PATH"); path != "" {
+		return NewFileStore(path), nil
+	}
+	if dsn := os.Getenv("DICTIONARY_DSN"); dsn != "" {
+		db, err := sql.Open("mysql", dsn)
+		if err != nil {
+			return nil, err
+		}
+		store := NewSQLStore(db)
+		return store, store.Setup()
+	}
+	return NewMemoryStore(DefaultRules), nil
+}
+
+// ErrNoSharedStore возвращается, если словарь правят через API, а хранится
+// он только в памяти одного процесса: шлюз и сервис комментариев правок не увидят.
+var ErrNoSharedStore = errors.New("censor: dictionary admin requires DICTIONARY_PATH or DICTIONARY_DSN")
+
+// Shared сообщает, видят ли изменения словаря другие процессы.
+func Shared(store Store) bool {
+	_, local := store.(*MemoryStore)
+	return !local
+}
+
+// AdminFromEnv решает, публиковать ли административные маршруты словаря.
+// DICTIONARY_ADMIN=false отключает их; включенные маршруты требуют общего
+// источника правил, иначе возвращается ErrNoSharedStore.
+func AdminFromEnv(store Store) (bool, error) {
+	if os.Getenv("DICTIONARY_ADMIN") == "false" {
+		return false, nil
+	}
+	if !Shared(store) {
+		return false, ErrNoSharedStore
+	}
+	return true, nil
+}
+
+// MemoryStore словарь в памяти.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	rules   []Rule
+	version int
+}
+
+// NewMemoryStore создает словарь в памяти с начальными правилами.
+func NewMemoryStore(rules []Rule) *MemoryStore {
+	return &MemoryStore{rules: append([]Rule(nil), rules...)}
+}
+
+func (s *MemoryStore) Load() ([]Rule, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]Rule(nil), s.rules...), nil
+}
+
+func (s *MemoryStore) Version() (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return fmt.Sprint(s.version), nil
+}
+
+func (s *MemoryStore) Add(rule Rule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = append(removeRule(s.rules, rule.Lang, rule.Word), rule)
+	s.version++
+	return nil
+}
+
+func (s *MemoryStore) Remove(lang, word string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = removeRule(s.rules, lang, word)
+	s.version++
+	return nil
+}
+
+// FileStore словарь в JSON-файле, сгруппированный по языкам:
+// {"ru": [{"word": "...", "mode": "word"}], "en": [...]}.
+type FileStore struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileStore создает словарь, хранящийся в файле path.
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Load() ([]Rule, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+func (s *FileStore) load() ([]Rule, error) {
+	data, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+
+	var byLang map[string][]Rule
+	if err := json.Unmarshal(data, &byLang); err != nil {
+		return nil, fmt.Errorf("invalid dictionary file %s: %w", s.path, err)
+	}
+
+	var rules []Rule
+	for lang, list := range byLang {
+		for _, rule := range list {
+			rule.Lang = lang
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+// Version возвращает время изменения и размер файла.
+func (s *FileStore) Version() (string, error) {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d-%d", info.ModTime().UnixNano(), info.Size()), nil
+}
+
+func (s *FileStore) Add(rule Rule) error {
+	return s.update(func(rules []Rule) []Rule {
+		return append(removeRule(rules, rule.Lang, rule.Word), rule)
+	})
+}
+
+func (s *FileStore) Remove(lang, word string) error {
+	return s.update(func(rules []Rule) []Rule {
+		return removeRule(rules, lang, word)
+	})
+}
+
+// update изменяет правила и атомарно перезаписывает файл.
+func (s *FileStore) update(change func([]Rule) []Rule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules, err := s.load()
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	byLang := make(map[string][]Rule)
+	for _, rule := range change(rules) {
+		lang := rule.Lang
+		rule.Lang = ""
+		byLang[lang] = append(byLang[lang], rule)
+	}
+
+	data, err := json.MarshalIndent(byLang, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), ".dictionary-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// removeRule удаляет правило с указанными языком и словом.
+func removeRule(rules []Rule, lang, word string) []Rule {
+	kept := rules[:0:0]
+	for _, rule := range rules {
+		if rule.Lang != lang || rule.Word != word {
+			kept = append(kept, rule)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,74 @@
+// Package censor - verdict.go
+package censor
+
+// Decision решение цензуры по комментарию.
+type Decision string
+
+const (
+	Allowed     Decision = "allowed"
+	Blocked     Decision = "blocked"
+	NeedsReview Decision = "needs_review"
+)
+
+// Пороги суммарной серьезности для принятия решения.
+const (
+	BlockThreshold  = 0.8
+	ReviewThreshold = 0.4
+)
+
+// Match совпадение правила в тексте. Start и End задаются в рунах, End не включается.
+type Match struct {
+	Rule  string `json:"rule"`
+	Start int    `json:"start"`
+	End   int    `json:"end"`
+}
+
+// Verdict подробный ответ цензуры.
+type Verdict struct {
+	Decision Decision `json:"decision"`
+	Matches  []Match  `json:"matches,omitempty"`
+	Severity float64  `json:"severity"`
+	Masked   string   `json:"masked,omitempty"`
+}
+
+// newVerdict считает серьезность по сработавшим правилам и строит маскированный текст.
+func newVerdict(runes []rune, matches []Match, severities map[string]float64) Verdict {
+	verdict := Verdict{Decision: Allowed, Matches: matches}
+	if len(matches) == 0 {
+		return verdict
+	}
+
+	// Каждое сработавшее правило независимо повышает вероятность нарушения.
+	clean := 1.0
+	for _, severity := range severities {
+		clean *= 1 - severity
+	}
+	verdict.Severity = 1 - clean
+
+	switch {
+	case verdict.Severity >= BlockThreshold:
+		verdict.Decision = Blocked
+	case verdict.Severity >= ReviewThreshold:
+		verdict.Decision = NeedsReview
+	}
+
+	verdict.Masked = Mask(runes, matches)
+	return verdict
+}
+
+// Mask заменяет совпадения звездочками, оставляя первую и последнюю букву: q****y.
+// Короткие совпадения маскируются целиком.
+func Mask(runes []rune, matches []Match) string {
+	masked := make([]rune, len(runes))
+	copy(masked, runes)
+	for _, m := range matches {
+		from, to := m.Start+1, m.End-1
+		if m.End-m.Start <= 2 {
+			from, to = m.Start, m.End
+		}
+		for i := from; i < to; i++ {
+			masked[i] = '*'
+		}
+	}
+	return string(masked)
+}
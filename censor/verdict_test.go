@@ -0,0 +1,53 @@
+// verdict_test.go
+package censor
+
+import "testing"
+
+func TestCheck(t *testing.T) {
+	rules := []Rule{{Word: "qwerty", Severity: 1}, {Word: "spam", Severity: 0.5}}
+
+	tt := []struct {
+		name     string
+		text     string
+		decision Decision
+		matches  []Match
+		masked   string
+	}{
+		{"clean text", "hello world", Allowed, nil, ""},
+		{"blocked word", "say QWERTY now", Blocked, []Match{{Rule: "qwerty", Start: 4, End: 10}}, "say Q****Y now"},
+		{"review word", "spam spam", NeedsReview, []Match{{Rule: "spam", Start: 0, End: 4}, {Rule: "spam", Start: 5, End: 9}}, "s**m s**m"},
+		{"cyrillic spans", "ой qwerty", Blocked, []Match{{Rule: "qwerty", Start: 3, End: 9}}, "ой q****y"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			verdict := Check(tc.text, rules)
+			if verdict.Decision != tc.decision {
+				t.Errorf("got decision %q, want %q", verdict.Decision, tc.decision)
+			}
+			if len(verdict.Matches) != len(tc.matches) {
+				t.Fatalf("got matches %v, want %v", verdict.Matches, tc.matches)
+			}
+			for i := range tc.matches {
+				if verdict.Matches[i] != tc.matches[i] {
+					t.Errorf("got match %v, want %v", verdict.Matches[i], tc.matches[i])
+				}
+			}
+			if verdict.Masked != tc.masked {
+				t.Errorf("got masked %q, want %q", verdict.Masked, tc.masked)
+			}
+		})
+	}
+}
+
+func TestCheckSeverityCombinesRules(t *testing.T) {
+	rules := []Rule{{Word: "foo", Severity: 0.5}, {Word: "bar", Severity: 0.6}}
+
+	verdict := Check("foo bar", rules)
+	if verdict.Severity < 0.79 || verdict.Severity > 0.81 {
+		t.Errorf("expected combined severity 0.8, got %v", verdict.Severity)
+	}
+	if verdict.Decision != Blocked {
+		t.Errorf("expected blocked, got %q", verdict.Decision)
+	}
+}
@@ -2,25 +2,60 @@
 package main
 
 import (
+	"APIGateway/auth"
+	"APIGateway/authz"
+	"APIGateway/censor"
+	"APIGateway/middleware"
 	"encoding/json"
 	"io/ioutil"
+	"log"
 	"net/http"
-	"strings"
+	"os"
+	"time"
 )
 
-// Comment структура комментария.
+// Comment структура комментария. Lang ограничивает проверку словарем одного языка.
 type Comment struct {
 	Text string `json:"text"`
+	Lang string `json:"lang,omitempty"`
 }
 
-var forbiddenWords = []string{"qwerty", "йцукен", "zxvbnm"} // Specific words not allowed
+// engine общий движок правил; main подменяет его источником из окружения.
+var engine = censor.DefaultEngine()
 
 func main() {
+	var err error
+	engine, err = censor.EngineFromEnv()
+	if err != nil {
+		log.Fatal("Cannot load dictionary:", err)
+	}
+	go engine.Watch(5*time.Second, nil)
+
+	// Правки словаря должны дойти до шлюза и сервиса комментариев, поэтому без
+	// общего файла или базы маршруты администрирования не поднимаются.
+	admin, err := censor.AdminFromEnv(engine.Store())
+	if err != nil {
+		log.Fatal("Cannot enable dictionary admin (set DICTIONARY_ADMIN=false to run without it):", err)
+	}
+
+	// Словари меняют только администраторы; токены выпускает шлюз с тем же JWT_SECRET.
+	verifier, err := auth.VerifierFromEnv([]byte(os.Getenv("JWT_SECRET")))
+	if err != nil {
+		log.Fatal("Cannot configure token verification:", err)
+	}
+	authenticator := middleware.NewAuthenticator(verifier)
+	manage := middleware.RequirePermission(authz.ManageDictionary)
+
 	http.HandleFunc("/censor", censorHandler)
+	if admin {
+		http.Handle("/dictionary", authenticator.Protect(manage, http.HandlerFunc(engine.AdminHandler)))
+		http.Handle("/dictionary/reload", authenticator.Protect(manage, http.HandlerFunc(engine.ReloadHandler)))
+	}
 	http.ListenAndServe(":8080", nil)
 }
 
 // censorHandler обработчик HTTP-запросов, проверяет комментарии на наличие запрещенных слов.
+// Отвечает вердиктом в формате JSON: 200 для allowed и needs_review, 400 для blocked.
 func censorHandler(w http.ResponseWriter, r *http.Request) {
 	body, err := ioutil.ReadAll(r.Body)
 	if err != nil {
@@ -35,13 +70,13 @@ func censorHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	lowercaseComment := strings.ToLower(comment.Text)
-	for _, word := range forbiddenWords {
-		if strings.Contains(lowercaseComment, word) {
-			w.WriteHeader(http.StatusBadRequest)
-			return
-		}
-	}
+	verdict := engine.Check(comment.Text, comment.Lang)
 
-	w.WriteHeader(http.StatusOK)
+	w.Header().Set("Content-Type", "application/json")
+	if verdict.Decision == censor.Blocked {
+		w.WriteHeader(http.StatusBadRequest)
+	} else {
+		w.WriteHeader(http.StatusOK)
+	}
+	json.NewEncoder(w).Encode(verdict)
 }
@@ -2,6 +2,7 @@
 package main
 
 import (
+	"APIGateway/censor"
 	"bytes"
 	"encoding/json"
 	"net/http"
@@ -42,3 +43,25 @@ func TestCensorHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestCensorHandlerVerdict(t *testing.T) {
+	reqBody, _ := json.Marshal(Comment{Text: "my qwerty password"})
+	req, _ := http.NewRequest(http.MethodPost, "/censor", bytes.NewBuffer(reqBody))
+	rec := httptest.NewRecorder()
+
+	censorHandler(rec, req)
+
+	var verdict censor.Verdict
+	if err := json.NewDecoder(rec.Body).Decode(&verdict); err != nil {
+		t.Fatalf("failed to decode verdict: %s", err)
+	}
+	if verdict.Decision != censor.Blocked {
+		t.Errorf("unexpected decision: got %q, want %q", verdict.Decision, censor.Blocked)
+	}
+	if len(verdict.Matches) != 1 || verdict.Matches[0].Start != 3 || verdict.Matches[0].End != 9 {
+		t.Errorf("unexpected matches: %v", verdict.Matches)
+	}
+	if verdict.Masked != "my q****y password" {
+		t.Errorf("unexpected masked text: %q", verdict.Masked)
+	}
+}
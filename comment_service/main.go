@@ -2,35 +2,81 @@
 package main
 
 import (
+	"APIGateway/auth"
+	"APIGateway/censor"
 	"APIGateway/database"
 	"APIGateway/handlers"
+	"APIGateway/idempotency"
 	"APIGateway/middleware"
+	"APIGateway/stream"
 	"database/sql"
 	_ "github.com/go-sql-driver/mysql"
 	"log"
 	"net/http"
+	"time"
 )
 
 func main() {
-	db, err := sql.Open("mysql", "root:love@tcp(127.0.0.1:3306)/mydatabase")
+	db, err := sql.Open("mysql", "root:love@tcp(127.0.0.1:3306)/mydatabase?parseTime=true")
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	repo := repository.NewRepository(db)
+	moderator, err := censor.EngineFromEnv()
+	if err != nil {
+		log.Fatal("Cannot load dictionary:", err)
+	}
+	go moderator.Watch(5*time.Second, nil)
+	repository.Moderator = moderator
+
+	// Изменения комментариев рассылаются в потоки /comments/stream этого процесса.
+	events := stream.NewHub(stream.DefaultBuffer, stream.DefaultHistory)
+	repo := repository.NewPublishingRepository(db, events)
 	err = repo.SetupDatabase()
 	if err != nil {
 		log.Fatal("Cannot setup database:", err)
 	}
 
 	handler := handlers.NewHandler(repo)
+	handler.Users = repository.NewUserRepository(db)
+	handler.Events = events
 
-	addCommentHandler := middleware.LoggingMiddleware(http.HandlerFunc(handler.AddComment))
-	getCommentsHandler := middleware.LoggingMiddleware(http.HandlerFunc(handler.GetComments))
+	// Ключи хранятся в общей базе, поэтому повтор через любой экземпляр получает первый ответ.
+	idempotencyKeys := idempotency.NewSQLStore(db)
+	if err := idempotencyKeys.Setup(); err != nil {
+		log.Fatal("Cannot setup idempotency keys:", err)
+	}
+	handler.Idempotency = idempotency.NewServiceFromEnv(idempotencyKeys)
+	go handler.Idempotency.Run(time.Hour, nil)
+
+	// Утверждения из заголовка идентичности принимаются, только если он подписан
+	// общим со шлюзом секретом. Без заголовка пользователь входит по своему токену.
+	identitySecret := auth.IdentitySecretFromEnv()
+	if len(identitySecret) == 0 {
+		log.Println("IDENTITY_SECRET not set, forwarded identities are ignored")
+	}
+	route := func(path string, h http.HandlerFunc) {
+		http.Handle(path, middleware.LoggingMiddleware(middleware.TrustedIdentity(identitySecret, h)))
+	}
 
-	http.Handle("/comments/get", getCommentsHandler)
-	http.Handle("/comments/add", addCommentHandler)
+	route("/moderation/pending", handler.PendingComments)
+	route("/moderation/approve", handler.ApproveComment)
+	route("/moderation/reject", handler.RejectComment)
+	route("/moderation/log", handler.ModerationLog)
+	route("/moderation/reported", handler.ReportedComments)
+	route("/comments/get", handler.GetComments)
+	route("/comments/stream", handler.StreamComments)
+	route("/comments/rss", handler.CommentsRSS)
+	route("/comments/atom", handler.CommentsAtom)
+	route("/comments/add", handler.AddComment)
+	route("/comments/hide", handler.HideComment)
+	route("/comments/edit", handler.EditComment)
+	route("/comments/delete", handler.DeleteComment)
+	route("/comments/revisions", handler.CommentRevisions)
+	route("/comments/vote", handler.VoteComment)
+	route("/comments/react", handler.ReactToComment)
+	route("/comments/report", handler.ReportComment)
 
 	log.Println("Comment service started on port 8081")
 	http.ListenAndServe(":8081", nil)
@@ -0,0 +1,63 @@
+// Package repository - events.go
+package repository
+
+import "database/sql"
+
+// Типы событий комментариев.
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+)
+
+// CommentEvent изменение комментария, видимое читателям.
+type CommentEvent struct {
+	Type    string
+	Comment Comment
+}
+
+// Publisher получает события об изменении комментариев после их сохранения.
+type Publisher interface {
+	Publish(event CommentEvent)
+}
+
+// Publishers рассылает события нескольким получателям по порядку.
+type Publishers []Publisher
+
+func (p Publishers) Publish(event CommentEvent) {
+	for _, publisher := range p {
+		publisher.Publish(event)
+	}
+}
+
+// NewPublishingRepository создает репозиторий, сообщающий events о сохраненных,
+// измененных и удаленных комментариях.
+func NewPublishingRepository(db *sql.DB, events Publisher) RepositoryInterface {
+	return &Repository{db: db, events: events}
+}
+
+// publish сообщает об изменении комментария id событием typ.
+func (r *Repository) publish(typ string, id int) {
+	if r.events == nil {
+		return
+	}
+	comments, err := r.queryComments(`
+		SELECT id, author, owner, text, news_id, parent_id, status, created_at
+		FROM comments
+		WHERE id = ?
+	`, id)
+	if err != nil || len(comments) == 0 {
+		return
+	}
+	r.events.Publish(eventFor(typ, comments[0]))
+}
+
+// eventFor создает событие typ для комментария. Только одобренные комментарии
+// попадают в событие целиком; любой другой статус означает для читателей удаление,
+// и событие содержит лишь идентификаторы.
+func eventFor(typ string, c Comment) CommentEvent {
+	if c.Status != StatusApproved {
+		return CommentEvent{Type: EventDeleted, Comment: Comment{ID: c.ID, NewsID: c.NewsID}}
+	}
+	return CommentEvent{Type: typ, Comment: c}
+}
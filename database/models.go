@@ -13,6 +13,18 @@ type Result struct {
 	Error    error
 }
 
+// Статусы комментария.
+const (
+	StatusApproved      = "approved"
+	StatusPendingReview = "pending_review"
+	StatusRejected      = "rejected"
+	StatusHidden        = "hidden"
+	StatusDeleted       = "deleted"
+)
+
+// DeletedPlaceholder текст, которым заменяются удаленные комментарии в ветках.
+const DeletedPlaceholder = "[deleted]"
+
 // Comment структура для представления комментария.
 type Comment struct {
 	ID        int       `json:"id"`
@@ -20,6 +32,117 @@ type Comment struct {
 	Text      string    `json:"text"`
 	NewsID    int       `json:"news_id"`
 	ParentID  *int      `json:"parent_id,omitempty"`
+	Status    string    `json:"status,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Upvotes   int       `json:"upvotes"`
+	Downvotes int       `json:"downvotes"`
+	// Reactions количество реакций по каждому эмодзи.
+	Reactions map[string]int `json:"reactions,omitempty"`
+	// Owner субъект токена автора (sub), по нему проверяется владение.
+	// Пуст у анонимных комментариев: их не может править никто, кроме модераторов.
+	Owner string `json:"-"`
+}
+
+// Причины жалоб на комментарий.
+const (
+	ReportSpam           = "spam"
+	ReportAbuse          = "abuse"
+	ReportOffTopic       = "offtopic"
+	ReportMisinformation = "misinformation"
+	ReportOther          = "other"
+)
+
+// ReportReasons допустимые причины жалоб.
+var ReportReasons = []string{ReportSpam, ReportAbuse, ReportOffTopic, ReportMisinformation, ReportOther}
+
+// IsReportReason сообщает, допустима ли причина жалобы.
+func IsReportReason(reason string) bool {
+	for _, r := range ReportReasons {
+		if r == reason {
+			return true
+		}
+	}
+	return false
+}
+
+// Report жалоба пользователя на комментарий.
+type Report struct {
+	CommentID int       `json:"comment_id"`
+	Reporter  string    `json:"reporter"`
+	Reason    string    `json:"reason"`
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReportResult итог подачи жалобы.
+type ReportResult struct {
+	// Reports число жалоб на комментарий с учетом новой.
+	Reports int `json:"reports"`
+	// Hidden сообщает, что жалоба скрыла комментарий до проверки модератором.
+	Hidden bool `json:"hidden"`
+}
+
+// ReportedComment комментарий с жалобами для модераторов.
+type ReportedComment struct {
+	Comment
+	Reports        int            `json:"reports"`
+	Reasons        map[string]int `json:"reasons"`
+	LastReportedAt time.Time      `json:"last_reported_at"`
+}
+
+// CommentStats голоса и реакции комментария.
+type CommentStats struct {
+	Upvotes   int
+	Downvotes int
+	Reactions map[string]int
+}
+
+// AllowedReactions эмодзи, которыми можно реагировать на комментарии.
+var AllowedReactions = []string{"👍", "❤️", "😂", "😮", "😢", "😡"}
+
+// IsAllowedReaction сообщает, можно ли реагировать эмодзи emoji.
+func IsAllowedReaction(emoji string) bool {
+	for _, r := range AllowedReactions {
+		if r == emoji {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact заменяет текст и автора удаленного комментария заглушкой.
+func (c *Comment) Redact() {
+	if c.Status == StatusDeleted {
+		c.Author = ""
+		c.Text = DeletedPlaceholder
+	}
+}
+
+// Revision прежняя версия текста комментария.
+type Revision struct {
+	ID        int       `json:"id"`
+	CommentID int       `json:"comment_id"`
+	Text      string    `json:"text"`
+	Editor    string    `json:"editor"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// User зарегистрированный пользователь.
+type User struct {
+	ID           int       `json:"id"`
+	Username     string    `json:"username"`
+	PasswordHash string    `json:"-"`
+	Role         string    `json:"role"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// ModerationEntry запись журнала модерации комментария.
+type ModerationEntry struct {
+	ID        int       `json:"id"`
+	CommentID int       `json:"comment_id"`
+	Moderator string    `json:"moderator"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
@@ -36,3 +159,18 @@ type NewsFullDetailed struct {
 	Content  string    `json:"content"`
 	Comments []Comment `json:"comments"`
 }
+
+// News структура для представления новости, полученной от сервиса новостей.
+type News struct {
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	Published string `json:"published"`
+	Content   string `json:"content"`
+	Source    string `json:"source,omitempty"`
+}
+
+// Pagination структура для представления информации о страницах.
+type Pagination struct {
+	CurrentPage int `json:"current_page"`
+	TotalPages  int `json:"total_pages"`
+}
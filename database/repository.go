@@ -2,60 +2,177 @@
 package repository
 
 import (
+	"APIGateway/censor"
 	"database/sql"
-	_ "github.com/go-sql-driver/mysql"
-	"regexp"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
 	"strings"
+	"time"
 )
 
+// mysqlDuplicateEntry код ошибки MySQL при нарушении уникального ключа.
+const mysqlDuplicateEntry = 1062
+
 // Repository обертка над DB.
 type Repository struct {
 	db *sql.DB
+	// events получает изменения комментариев. nil отключает события.
+	events Publisher
 }
 
+var (
+	// ErrNotPending возвращается при попытке промодерировать комментарий, который не ждет проверки.
+	ErrNotPending = errors.New("comment is not pending review")
+	// ErrCommentNotFound возвращается, если комментарий не найден или уже удален.
+	ErrCommentNotFound = errors.New("comment not found")
+	// ErrInvalidVote возвращается для голоса, отличного от 1, -1 и 0.
+	ErrInvalidVote = errors.New("vote must be 1, -1 or 0")
+	// ErrAlreadyReported возвращается при повторной жалобе пользователя на комментарий.
+	ErrAlreadyReported = errors.New("comment already reported")
+)
+
 // RepositoryInterface interface declaration
 type RepositoryInterface interface {
 	Save(c Comment) error
 	GetCommentsByNewsID(newsID int) ([]Comment, error)
+	GetVisibleComments(newsID int, viewer string) ([]Comment, error)
+	GetPendingComments(limit, offset int) ([]Comment, error)
+	GetApprovedComments(afterID, limit int) ([]Comment, error)
+	SetCommentStatus(id int, status, moderator, reason string) error
+	GetComment(id int) (Comment, error)
+	ChangeCommentStatus(id int, status, actor, reason string) error
+	UpdateComment(id int, text, status, editor string) error
+	DeleteComment(id int, actor, reason string) error
+	GetRevisions(commentID int) ([]Revision, error)
+	Vote(commentID int, voter string, value int) (CommentStats, error)
+	React(commentID int, user, emoji string, add bool) error
+	GetCommentStats(ids []int) (map[int]CommentStats, error)
+	ReportComment(report Report, threshold int) (ReportResult, error)
+	GetReportedComments(limit, offset int) ([]ReportedComment, error)
+	GetModerationLog(commentID int) ([]ModerationEntry, error)
 	SetupDatabase() error
 }
 
+var (
+	// ErrUserExists возвращается при регистрации занятого имени пользователя.
+	ErrUserExists = errors.New("user already exists")
+	// ErrUserNotFound возвращается, если пользователь не найден.
+	ErrUserNotFound = errors.New("user not found")
+)
+
+// UserRepositoryInterface хранилище пользователей.
+type UserRepositoryInterface interface {
+	CreateUser(u User) (int, error)
+	GetUserByUsername(username string) (User, error)
+	SetUserRole(username, role string) error
+}
+
+// NewUserRepository создает хранилище пользователей поверх DB.
+// Таблица пользователей создается в SetupDatabase.
+func NewUserRepository(db *sql.DB) UserRepositoryInterface {
+	return &Repository{db: db}
+}
+
 // NewRepository создает новый репозиторий.
 func NewRepository(db *sql.DB) RepositoryInterface {
 	return &Repository{db: db}
 }
 
-// ForbiddenWords запрещённые слова для комментариев.
-var ForbiddenWords = []string{"qwerty", "йцукен", "zxvbnm"}
+// Moderator движок правил для локальной модерации, общий с сервисом цензуры.
+var Moderator = censor.DefaultEngine()
 
 // ModerateComment проверяет, что комментарий не содержит запрещенных слов.
 func ModerateComment(comment *Comment) {
-	lowercaseBody := strings.ToLower(comment.Text)
-	for _, word := range ForbiddenWords {
-		match, _ := regexp.MatchString(word, lowercaseBody)
-		if match {
-			comment.Text = "This comment has been moderated"
-			break
-		}
+	if Moderator.Check(comment.Text, "").Decision != censor.Allowed {
+		comment.Text = "This comment has been moderated"
 	}
 }
 
 // Save сохраняет объект комментария в базу данных.
 func (r *Repository) Save(comment Comment) error {
-	_, err := r.db.Exec(`
-		INSERT INTO comments (author, text, news_id, parent_id, created_at)
-		VALUES (?, ?, ?, ?, ?)
-	`, comment.Author, comment.Text, comment.NewsID, comment.ParentID, comment.CreatedAt)
-	return err
+	if comment.Status == "" {
+		comment.Status = StatusApproved
+	}
+	res, err := r.db.Exec(`
+		INSERT INTO comments (author, owner, text, news_id, parent_id, status, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, comment.Author, comment.Owner, comment.Text, comment.NewsID, comment.ParentID, comment.Status, comment.CreatedAt)
+	if err != nil || r.events == nil || comment.Status != StatusApproved {
+		return err
+	}
+
+	// Без идентификатора событие бесполезно, но комментарий уже сохранен.
+	if id, err := res.LastInsertId(); err == nil {
+		comment.ID = int(id)
+		r.events.Publish(CommentEvent{Type: EventCreated, Comment: comment})
+	}
+	return nil
 }
 
-// GetCommentsByNewsID извлекает все комментарии, связанные с определенной новостью.
+// deletedWithReplies условие для удаленных комментариев, на которые есть ответы.
+// Они остаются в выдаче заглушками, чтобы не рвать ветки обсуждения.
+const deletedWithReplies = `(status = 'deleted' AND EXISTS (
+	SELECT 1 FROM comments AS reply
+	WHERE reply.parent_id = comments.id AND reply.status IN ('approved', 'deleted')
+))`
+
+// GetCommentsByNewsID извлекает все одобренные комментарии, связанные с определенной новостью,
+// и заглушки удаленных комментариев с ответами.
 func (r *Repository) GetCommentsByNewsID(newsID int) ([]Comment, error) {
-	rows, err := r.db.Query(`
-		SELECT id, author, text, news_id, parent_id, created_at 
+	return r.queryThread(`
+		SELECT id, author, owner, text, news_id, parent_id, status, created_at 
 		FROM comments 
-		WHERE news_id = ?
+		WHERE news_id = ? AND (status = 'approved' OR `+deletedWithReplies+`)
 	`, newsID)
+}
+
+// GetVisibleComments извлекает одобренные комментарии новости и комментарии,
+// ожидающие проверки, владельцем которых является viewer (субъект токена).
+// Анонимные комментарии владельца не имеют и в эту выборку не попадают.
+func (r *Repository) GetVisibleComments(newsID int, viewer string) ([]Comment, error) {
+	return r.queryThread(`
+		SELECT id, author, owner, text, news_id, parent_id, status, created_at 
+		FROM comments 
+		WHERE news_id = ? AND (status = 'approved' OR (status = 'pending_review' AND owner <> '' AND owner = ?) OR `+deletedWithReplies+`)
+	`, newsID, viewer)
+}
+
+// queryThread извлекает комментарии ветки и заменяет удаленные заглушками.
+func (r *Repository) queryThread(query string, args ...interface{}) ([]Comment, error) {
+	comments, err := r.queryComments(query, args...)
+	for i := range comments {
+		comments[i].Redact()
+	}
+	return comments, err
+}
+
+// GetPendingComments извлекает комментарии, ожидающие проверки, начиная со старых.
+func (r *Repository) GetPendingComments(limit, offset int) ([]Comment, error) {
+	return r.queryComments(`
+		SELECT id, author, owner, text, news_id, parent_id, status, created_at 
+		FROM comments 
+		WHERE status = 'pending_review'
+		ORDER BY created_at, id
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+}
+
+// GetApprovedComments извлекает до limit одобренных комментариев с номером больше
+// afterID по возрастанию номера. Используется для построения поискового индекса.
+func (r *Repository) GetApprovedComments(afterID, limit int) ([]Comment, error) {
+	return r.queryComments(`
+		SELECT id, author, owner, text, news_id, parent_id, status, created_at
+		FROM comments
+		WHERE status = 'approved' AND id > ?
+		ORDER BY id
+		LIMIT ?
+	`, afterID, limit)
+}
+
+// queryComments выполняет запрос и считывает комментарии.
+func (r *Repository) queryComments(query string, args ...interface{}) ([]Comment, error) {
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +181,7 @@ func (r *Repository) GetCommentsByNewsID(newsID int) ([]Comment, error) {
 	var comments []Comment
 	for rows.Next() {
 		var comment Comment
-		if err := rows.Scan(&comment.ID, &comment.Author, &comment.Text, &comment.NewsID, &comment.ParentID, &comment.CreatedAt); err != nil {
+		if err := rows.Scan(&comment.ID, &comment.Author, &comment.Owner, &comment.Text, &comment.NewsID, &comment.ParentID, &comment.Status, &comment.CreatedAt); err != nil {
 			return nil, err
 		}
 		comments = append(comments, comment)
@@ -73,17 +190,619 @@ func (r *Repository) GetCommentsByNewsID(newsID int) ([]Comment, error) {
 	return comments, nil
 }
 
+// SetCommentStatus одобряет или отклоняет комментарий, ожидающий проверки,
+// и записывает решение в журнал модерации.
+func (r *Repository) SetCommentStatus(id int, status, moderator, reason string) error {
+	err := r.inTx(func(tx *sql.Tx) error {
+		return setStatus(tx, `
+			UPDATE comments SET status = ? WHERE id = ? AND status = 'pending_review'
+		`, ErrNotPending, id, status, moderator, reason)
+	})
+	// Отклоненный комментарий читатели не видели, сообщать о нем незачем.
+	if err == nil && status == StatusApproved {
+		r.publish(EventCreated, id)
+	}
+	return err
+}
+
+// ChangeCommentStatus скрывает комментарий в любом статусе, кроме удаленного,
+// и записывает действие в журнал модерации.
+func (r *Repository) ChangeCommentStatus(id int, status, actor, reason string) error {
+	err := r.inTx(func(tx *sql.Tx) error {
+		return setStatus(tx, changeStatus, ErrCommentNotFound, id, status, actor, reason)
+	})
+	if err == nil {
+		r.publish(EventCreated, id)
+	}
+	return err
+}
+
+// UpdateComment заменяет текст комментария, сохраняя прежний в истории правок.
+// Статус меняется на status, потому что новый текст заново проходит цензуру.
+func (r *Repository) UpdateComment(id int, text, status, editor string) error {
+	err := r.inTx(func(tx *sql.Tx) error {
+		if err := saveRevision(tx, id, editor); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`UPDATE comments SET text = ?, status = ? WHERE id = ?`, text, status, id)
+		return err
+	})
+	if err == nil {
+		r.publish(EventUpdated, id)
+	}
+	return err
+}
+
+// DeleteComment мягко удаляет комментарий: текст сохраняется в истории правок,
+// а сам комментарий получает статус deleted.
+func (r *Repository) DeleteComment(id int, actor, reason string) error {
+	err := r.inTx(func(tx *sql.Tx) error {
+		if err := saveRevision(tx, id, actor); err != nil {
+			return err
+		}
+		return setStatus(tx, changeStatus, ErrCommentNotFound, id, StatusDeleted, actor, reason)
+	})
+	if err == nil {
+		r.publish(EventDeleted, id)
+	}
+	return err
+}
+
+// GetRevisions извлекает прежние версии комментария, начиная со старых.
+func (r *Repository) GetRevisions(commentID int) ([]Revision, error) {
+	rows, err := r.db.Query(`
+		SELECT id, comment_id, text, editor, created_at
+		FROM comment_revisions
+		WHERE comment_id = ?
+		ORDER BY created_at, id
+	`, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var revisions []Revision
+	for rows.Next() {
+		var rev Revision
+		if err := rows.Scan(&rev.ID, &rev.CommentID, &rev.Text, &rev.Editor, &rev.CreatedAt); err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+
+	return revisions, rows.Err()
+}
+
+// Vote записывает голос voter за комментарий: 1 — за, -1 — против, 0 отменяет голос.
+// У каждого пользователя один голос; повторный голос заменяет прежний.
+// Счетчики меняются на разницу между голосами под блокировкой строки комментария,
+// поэтому одновременные голоса не теряются.
+func (r *Repository) Vote(commentID int, voter string, value int) (CommentStats, error) {
+	if value < -1 || value > 1 {
+		return CommentStats{}, ErrInvalidVote
+	}
+
+	var stats CommentStats
+	err := r.inTx(func(tx *sql.Tx) error {
+		err := tx.QueryRow(`
+			SELECT upvotes, downvotes FROM comments WHERE id = ? AND status = 'approved' FOR UPDATE
+		`, commentID).Scan(&stats.Upvotes, &stats.Downvotes)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCommentNotFound
+		} else if err != nil {
+			return err
+		}
+
+		var previous int
+		err = tx.QueryRow(`
+			SELECT value FROM comment_votes WHERE comment_id = ? AND voter = ?
+		`, commentID, voter).Scan(&previous)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if previous == value {
+			return nil
+		}
+
+		if value == 0 {
+			_, err = tx.Exec(`DELETE FROM comment_votes WHERE comment_id = ? AND voter = ?`, commentID, voter)
+		} else {
+			_, err = tx.Exec(`
+				INSERT INTO comment_votes (comment_id, voter, value, created_at) VALUES (?, ?, ?, ?)
+				ON DUPLICATE KEY UPDATE value = VALUES(value), created_at = VALUES(created_at)
+			`, commentID, voter, value, time.Now())
+		}
+		if err != nil {
+			return err
+		}
+
+		up, down := voteDelta(previous, value)
+		stats.Upvotes += up
+		stats.Downvotes += down
+		_, err = tx.Exec(`
+			UPDATE comments SET upvotes = upvotes + ?, downvotes = downvotes + ? WHERE id = ?
+		`, up, down, commentID)
+		return err
+	})
+	return stats, err
+}
+
+// voteDelta возвращает изменение счетчиков при замене голоса previous на value.
+func voteDelta(previous, value int) (up, down int) {
+	count := func(v int) (int, int) {
+		switch v {
+		case 1:
+			return 1, 0
+		case -1:
+			return 0, 1
+		}
+		return 0, 0
+	}
+	oldUp, oldDown := count(previous)
+	newUp, newDown := count(value)
+	return newUp - oldUp, newDown - oldDown
+}
+
+// React добавляет или снимает реакцию user на одобренный комментарий.
+func (r *Repository) React(commentID int, user, emoji string, add bool) error {
+	var exists int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM comments WHERE id = ? AND status = 'approved'`, commentID).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if exists == 0 {
+		return ErrCommentNotFound
+	}
+
+	if add {
+		_, err = r.db.Exec(`
+			INSERT IGNORE INTO comment_reactions (comment_id, user, emoji, created_at) VALUES (?, ?, ?, ?)
+		`, commentID, user, emoji, time.Now())
+	} else {
+		_, err = r.db.Exec(`
+			DELETE FROM comment_reactions WHERE comment_id = ? AND user = ? AND emoji = ?
+		`, commentID, user, emoji)
+	}
+	return err
+}
+
+// GetCommentStats извлекает голоса и реакции комментариев ids.
+func (r *Repository) GetCommentStats(ids []int) (map[int]CommentStats, error) {
+	stats := make(map[int]CommentStats, len(ids))
+	if len(ids) == 0 {
+		return stats, nil
+	}
+
+	placeholders, args := inList(ids)
+	rows, err := r.db.Query(`SELECT id, upvotes, downvotes FROM comments WHERE id IN (`+placeholders+`)`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id int
+		var s CommentStats
+		if err := rows.Scan(&id, &s.Upvotes, &s.Downvotes); err != nil {
+			return nil, err
+		}
+		stats[id] = s
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	reactions, err := r.db.Query(`
+		SELECT comment_id, emoji, COUNT(*) FROM comment_reactions
+		WHERE comment_id IN (`+placeholders+`)
+		GROUP BY comment_id, emoji
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer reactions.Close()
+	for reactions.Next() {
+		var id, count int
+		var emoji string
+		if err := reactions.Scan(&id, &emoji, &count); err != nil {
+			return nil, err
+		}
+		s := stats[id]
+		if s.Reactions == nil {
+			s.Reactions = make(map[string]int)
+		}
+		s.Reactions[emoji] = count
+		stats[id] = s
+	}
+	return stats, reactions.Err()
+}
+
+// inList возвращает заполнители и аргументы для условия IN по ids.
+func inList(ids []int) (string, []interface{}) {
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","), args
+}
+
+// ReportActor исполнитель, от имени которого жалобы скрывают комментарии в журнале модерации.
+const ReportActor = "reports"
+
+// ReportComment сохраняет жалобу на одобренный комментарий. От пользователя принимается
+// одна жалоба на комментарий. Когда число жалоб достигает threshold, комментарий
+// уходит на проверку модератору и пропадает из выдачи; вернуть его может только
+// модератор, правка автора статус не меняет. Повторно одобренный модератором
+// комментарий последующие жалобы не скрывают. Нулевой threshold отключает скрытие.
+func (r *Repository) ReportComment(report Report, threshold int) (ReportResult, error) {
+	var result ReportResult
+	err := r.inTx(func(tx *sql.Tx) error {
+		var status string
+		err := tx.QueryRow(`
+			SELECT status FROM comments WHERE id = ? AND status = 'approved' FOR UPDATE
+		`, report.CommentID).Scan(&status)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrCommentNotFound
+		} else if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO comment_reports (comment_id, reporter, reason, details, created_at)
+			VALUES (?, ?, ?, ?, ?)
+		`, report.CommentID, report.Reporter, report.Reason, report.Details, report.CreatedAt)
+		var mysqlErr *mysql.MySQLError
+		if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+			return ErrAlreadyReported
+		} else if err != nil {
+			return err
+		}
+
+		err = tx.QueryRow(`SELECT COUNT(*) FROM comment_reports WHERE comment_id = ?`, report.CommentID).Scan(&result.Reports)
+		if err != nil {
+			return err
+		}
+		if threshold <= 0 || result.Reports != threshold {
+			return nil
+		}
+
+		result.Hidden = true
+		reason := fmt.Sprintf("hidden after %d reports", result.Reports)
+		return setStatus(tx, changeStatus, ErrCommentNotFound, report.CommentID, StatusPendingReview, ReportActor, reason)
+	})
+	if err == nil && result.Hidden {
+		r.publish(EventDeleted, report.CommentID)
+	}
+	return result, err
+}
+
+// GetReportedComments извлекает комментарии всех новостей по убыванию числа жалоб.
+func (r *Repository) GetReportedComments(limit, offset int) ([]ReportedComment, error) {
+	rows, err := r.db.Query(`
+		SELECT c.id, c.author, c.owner, c.text, c.news_id, c.parent_id, c.status, c.created_at,
+			COUNT(*) AS reports, MAX(rp.created_at) AS last_reported_at
+		FROM comment_reports rp
+		JOIN comments c ON c.id = rp.comment_id
+		WHERE c.status <> 'deleted'
+		GROUP BY c.id, c.author, c.owner, c.text, c.news_id, c.parent_id, c.status, c.created_at
+		ORDER BY reports DESC, last_reported_at DESC
+		LIMIT ? OFFSET ?
+	`, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var reported []ReportedComment
+	for rows.Next() {
+		var rc ReportedComment
+		c := &rc.Comment
+		if err := rows.Scan(&c.ID, &c.Author, &c.Owner, &c.Text, &c.NewsID, &c.ParentID, &c.Status, &c.CreatedAt, &rc.Reports, &rc.LastReportedAt); err != nil {
+			return nil, err
+		}
+		rc.Reasons = make(map[string]int)
+		reported = append(reported, rc)
+	}
+	if err := rows.Err(); err != nil || len(reported) == 0 {
+		return reported, err
+	}
+
+	ids := make([]int, len(reported))
+	index := make(map[int]int, len(reported))
+	for i, rc := range reported {
+		ids[i] = rc.ID
+		index[rc.ID] = i
+	}
+	placeholders, args := inList(ids)
+	reasons, err := r.db.Query(`
+		SELECT comment_id, reason, COUNT(*) FROM comment_reports
+		WHERE comment_id IN (`+placeholders+`)
+		GROUP BY comment_id, reason
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer reasons.Close()
+	for reasons.Next() {
+		var id, count int
+		var reason string
+		if err := reasons.Scan(&id, &reason, &count); err != nil {
+			return nil, err
+		}
+		reported[index[id]].Reasons[reason] = count
+	}
+	return reported, reasons.Err()
+}
+
+// inTx выполняет fn в транзакции.
+func (r *Repository) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// changeStatus меняет статус любого не удаленного комментария.
+const changeStatus = `UPDATE comments SET status = ? WHERE id = ? AND status <> 'deleted'`
+
+// setStatus меняет статус комментария запросом update и записывает изменение
+// в журнал модерации. Если запрос не затронул строк, возвращается notFound.
+func setStatus(tx *sql.Tx, update string, notFound error, id int, status, actor, reason string) error {
+	res, err := tx.Exec(update, status, id)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return notFound
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO comment_moderation_log (comment_id, moderator, status, reason, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, id, actor, status, reason, time.Now())
+	return err
+}
+
+// saveRevision сохраняет текущий текст комментария в истории правок.
+func saveRevision(tx *sql.Tx, id int, editor string) error {
+	var text string
+	err := tx.QueryRow(`
+		SELECT text FROM comments WHERE id = ? AND status <> 'deleted' FOR UPDATE
+	`, id).Scan(&text)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrCommentNotFound
+	} else if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO comment_revisions (comment_id, text, editor, created_at)
+		VALUES (?, ?, ?, ?)
+	`, id, text, editor, time.Now())
+	return err
+}
+
+// GetComment извлекает комментарий по идентификатору. Удаленные комментарии не возвращаются.
+func (r *Repository) GetComment(id int) (Comment, error) {
+	comments, err := r.queryComments(`
+		SELECT id, author, owner, text, news_id, parent_id, status, created_at 
+		FROM comments 
+		WHERE id = ? AND status <> 'deleted'
+	`, id)
+	if err != nil {
+		return Comment{}, err
+	}
+	if len(comments) == 0 {
+		return Comment{}, ErrCommentNotFound
+	}
+	return comments[0], nil
+}
+
+// GetModerationLog извлекает журнал модерации комментария.
+func (r *Repository) GetModerationLog(commentID int) ([]ModerationEntry, error) {
+	rows, err := r.db.Query(`
+		SELECT id, comment_id, moderator, status, reason, created_at
+		FROM comment_moderation_log
+		WHERE comment_id = ?
+		ORDER BY created_at, id
+	`, commentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []ModerationEntry
+	for rows.Next() {
+		var entry ModerationEntry
+		if err := rows.Scan(&entry.ID, &entry.CommentID, &entry.Moderator, &entry.Status, &entry.Reason, &entry.CreatedAt); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// CreateUser сохраняет нового пользователя и возвращает его идентификатор.
+func (r *Repository) CreateUser(u User) (int, error) {
+	res, err := r.db.Exec(`
+		INSERT INTO users (username, password_hash, role, created_at)
+		VALUES (?, ?, ?, ?)
+	`, u.Username, u.PasswordHash, u.Role, u.CreatedAt)
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) && mysqlErr.Number == mysqlDuplicateEntry {
+		return 0, ErrUserExists
+	} else if err != nil {
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// GetUserByUsername извлекает пользователя по имени.
+func (r *Repository) GetUserByUsername(username string) (User, error) {
+	var u User
+	err := r.db.QueryRow(`
+		SELECT id, username, password_hash, role, created_at
+		FROM users
+		WHERE username = ?
+	`, username).Scan(&u.ID, &u.Username, &u.PasswordHash, &u.Role, &u.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, ErrUserNotFound
+	}
+	return u, err
+}
+
+// SetUserRole назначает пользователю роль.
+func (r *Repository) SetUserRole(username, role string) error {
+	res, err := r.db.Exec(`UPDATE users SET role = ? WHERE username = ?`, role, username)
+	if err != nil {
+		return err
+	}
+	if affected, err := res.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
 // SetupDatabase создает таблицу комментариев, если она еще не существует.
 func (r *Repository) SetupDatabase() error {
 	_, err := r.db.Exec(`
 		CREATE TABLE IF NOT EXISTS comments (
 			id INT AUTO_INCREMENT PRIMARY KEY,
 			author VARCHAR(255),
+			owner VARCHAR(255) NOT NULL DEFAULT '',
 			text TEXT,
 			news_id INT,
 			parent_id INT,
+			status VARCHAR(32) NOT NULL DEFAULT 'approved',
+			created_at DATETIME
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	if err := r.addColumnIfMissing("comments", "status", "VARCHAR(32) NOT NULL DEFAULT 'approved'"); err != nil {
+		return err
+	}
+	// Владелец — субъект токена автора; у старых и анонимных комментариев он пуст.
+	if err := r.addColumnIfMissing("comments", "owner", "VARCHAR(255) NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := r.addColumnIfMissing("comments", "upvotes", "INT NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := r.addColumnIfMissing("comments", "downvotes", "INT NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS comment_votes (
+			comment_id INT NOT NULL,
+			voter VARCHAR(255) NOT NULL,
+			value TINYINT NOT NULL,
+			created_at DATETIME,
+			PRIMARY KEY (comment_id, voter)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS comment_reports (
+			comment_id INT NOT NULL,
+			reporter VARCHAR(255) NOT NULL,
+			reason VARCHAR(32) NOT NULL,
+			details TEXT,
+			created_at DATETIME,
+			PRIMARY KEY (comment_id, reporter)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS comment_reactions (
+			comment_id INT NOT NULL,
+			user VARCHAR(255) NOT NULL,
+			emoji VARCHAR(16) NOT NULL,
+			created_at DATETIME,
+			PRIMARY KEY (comment_id, user, emoji)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS comment_moderation_log (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			comment_id INT NOT NULL,
+			moderator VARCHAR(255) NOT NULL,
+			status VARCHAR(32) NOT NULL,
+			reason TEXT,
+			created_at DATETIME,
+			INDEX (comment_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS comment_revisions (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			comment_id INT NOT NULL,
+			text TEXT,
+			editor VARCHAR(255) NOT NULL,
+			created_at DATETIME,
+			INDEX (comment_id)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Exec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id INT AUTO_INCREMENT PRIMARY KEY,
+			username VARCHAR(32) NOT NULL UNIQUE,
+			password_hash VARCHAR(255) NOT NULL,
+			role VARCHAR(16) NOT NULL DEFAULT 'commenter',
 			created_at DATETIME
 		)
 	`)
+	if err != nil {
+		return err
+	}
+
+	return r.addColumnIfMissing("users", "role", "VARCHAR(16) NOT NULL DEFAULT 'commenter'")
+}
+
+// addColumnIfMissing добавляет колонку в существующую таблицу, созданную до её появления.
+func (r *Repository) addColumnIfMissing(table, column, definition string) error {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ? AND COLUMN_NAME = ?
+	`, table, column).Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+
+	_, err = r.db.Exec("ALTER TABLE " + table + " ADD COLUMN " + column + " " + definition)
 	return err
 }
@@ -2,7 +2,9 @@
 package repository
 
 import (
+	"errors"
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
 	"testing"
 	"time"
 )
@@ -31,6 +33,7 @@ func TestSave(t *testing.T) {
 	parentID := 2
 	comment := Comment{
 		Author:    "John",
+		Owner:     "1",
 		Text:      "Hello, world!",
 		NewsID:    1,
 		ParentID:  &parentID,
@@ -38,7 +41,7 @@ func TestSave(t *testing.T) {
 	}
 
 	mock.ExpectExec("INSERT INTO comments").
-		WithArgs(comment.Author, comment.Text, comment.NewsID, *comment.ParentID, comment.CreatedAt).
+		WithArgs(comment.Author, comment.Owner, comment.Text, comment.NewsID, *comment.ParentID, StatusApproved, comment.CreatedAt).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	if err := repo.Save(comment); err != nil {
@@ -55,10 +58,10 @@ func TestGetCommentsByNewsID(t *testing.T) {
 	repo := NewRepository(db)
 
 	newsID := 1
-	rows := sqlmock.NewRows([]string{"id", "author", "text", "news_id", "parent_id", "created_at"}).
-		AddRow(1, "John", "Hello, world!", newsID, 2, time.Now())
+	rows := sqlmock.NewRows([]string{"id", "author", "owner", "text", "news_id", "parent_id", "status", "created_at"}).
+		AddRow(1, "John", "1", "Hello, world!", newsID, 2, StatusApproved, time.Now())
 
-	mock.ExpectQuery("SELECT id, author, text, news_id, parent_id, created_at FROM comments WHERE news_id = ?").
+	mock.ExpectQuery("SELECT id, author, owner, text, news_id, parent_id, status, created_at FROM comments WHERE news_id = ?").
 		WithArgs(newsID).
 		WillReturnRows(rows)
 
@@ -79,3 +82,494 @@ func TestGetCommentsByNewsID(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestGetVisibleComments(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	repo := NewRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "author", "owner", "text", "news_id", "parent_id", "status", "created_at"}).
+		AddRow(1, "John", "1", "Hello", 1, nil, StatusPendingReview, time.Now())
+
+	mock.ExpectQuery("FROM comments WHERE news_id = \\? AND \\(status = 'approved' OR \\(status = 'pending_review' AND owner <> '' AND owner = \\?\\) OR").
+		WithArgs(1, "1").
+		WillReturnRows(rows)
+
+	comments, err := repo.GetVisibleComments(1, "1")
+	if err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if len(comments) != 1 || comments[0].Status != StatusPendingReview || comments[0].Owner != "1" {
+		t.Errorf("expected own pending comment, got %v", comments)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetApprovedComments(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	repo := NewRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "author", "owner", "text", "news_id", "parent_id", "status", "created_at"}).
+		AddRow(11, "John", "1", "Hello", 1, nil, StatusApproved, time.Now()).
+		AddRow(12, "Jane", "2", "Hi", 2, nil, StatusApproved, time.Now())
+
+	mock.ExpectQuery("FROM comments WHERE status = 'approved' AND id > \\? ORDER BY id LIMIT \\?").
+		WithArgs(10, 2).
+		WillReturnRows(rows)
+
+	comments, err := repo.GetApprovedComments(10, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(comments) != 2 || comments[1].ID != 12 {
+		t.Errorf("unexpected comments: %+v", comments)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSetCommentStatus(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE comments SET status").
+		WithArgs(StatusApproved, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO comment_moderation_log").
+		WithArgs(1, "mod", StatusApproved, "ok", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := repo.SetCommentStatus(1, StatusApproved, "mod", "ok"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE comments SET status").
+		WithArgs(StatusRejected, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if err := repo.SetCommentStatus(2, StatusRejected, "mod", "spam"); err != ErrNotPending {
+		t.Errorf("expected ErrNotPending, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestCreateUser(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	repo := NewUserRepository(db)
+	user := User{Username: "john", PasswordHash: "hash", Role: "commenter", CreatedAt: time.Now()}
+
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(user.Username, user.PasswordHash, user.Role, user.CreatedAt).
+		WillReturnResult(sqlmock.NewResult(3, 1))
+	if id, err := repo.CreateUser(user); err != nil || id != 3 {
+		t.Errorf("expected user 3, got %d, %v", id, err)
+	}
+
+	mock.ExpectExec("INSERT INTO users").
+		WithArgs(user.Username, user.PasswordHash, user.Role, user.CreatedAt).
+		WillReturnError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"})
+	if _, err := repo.CreateUser(user); err != ErrUserExists {
+		t.Errorf("expected ErrUserExists, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestGetUserByUsername(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	repo := NewUserRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "created_at"}).
+		AddRow(1, "john", "hash", "moderator", time.Now())
+	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("john").WillReturnRows(rows)
+	if user, err := repo.GetUserByUsername("john"); err != nil || user.ID != 1 || user.Role != "moderator" {
+		t.Errorf("expected user 1, got %+v, %v", user, err)
+	}
+
+	mock.ExpectQuery("SELECT (.+) FROM users").WithArgs("nobody").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "role", "created_at"}))
+	if _, err := repo.GetUserByUsername("nobody"); err != ErrUserNotFound {
+		t.Errorf("expected ErrUserNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestChangeCommentStatus(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE comments SET status (.+) status <> 'deleted'").
+		WithArgs(StatusHidden, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO comment_moderation_log").
+		WithArgs(1, "mod", StatusHidden, "offtopic", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := repo.ChangeCommentStatus(1, StatusHidden, "mod", "offtopic"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE comments SET status").
+		WithArgs(StatusDeleted, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	if err := repo.ChangeCommentStatus(2, StatusDeleted, "john", ""); err != ErrCommentNotFound {
+		t.Errorf("expected ErrCommentNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeletedCommentPlaceholder(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	repo := NewRepository(db)
+
+	rows := sqlmock.NewRows([]string{"id", "author", "owner", "text", "news_id", "parent_id", "status", "created_at"}).
+		AddRow(1, "John", "1", "Original", 1, nil, StatusDeleted, time.Now()).
+		AddRow(2, "Ann", "", "Reply", 1, 1, StatusApproved, time.Now())
+
+	mock.ExpectQuery("WHERE news_id = \\? AND \\(status = 'approved' OR \\(status = 'deleted' AND EXISTS").
+		WithArgs(1).
+		WillReturnRows(rows)
+
+	comments, err := repo.GetCommentsByNewsID(1)
+	if err != nil || len(comments) != 2 {
+		t.Fatalf("expected two comments, got %v, %v", comments, err)
+	}
+	if comments[0].Text != DeletedPlaceholder || comments[0].Author != "" {
+		t.Errorf("expected placeholder for deleted comment, got %+v", comments[0])
+	}
+	if comments[1].Text != "Reply" {
+		t.Errorf("expected reply to be intact, got %+v", comments[1])
+	}
+}
+
+func TestUpdateComment(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT text FROM comments WHERE id = \\? AND status <> 'deleted' FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"text"}).AddRow("Helo"))
+	mock.ExpectExec("INSERT INTO comment_revisions").
+		WithArgs(1, "Helo", "john", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE comments SET text").
+		WithArgs("Hello", StatusApproved, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	if err := repo.UpdateComment(1, "Hello", StatusApproved, "john"); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT text FROM comments").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows([]string{"text"}))
+	mock.ExpectRollback()
+
+	if err := repo.UpdateComment(2, "Hello", StatusApproved, "john"); err != ErrCommentNotFound {
+		t.Errorf("expected ErrCommentNotFound, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestDeleteComment(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	repo := NewRepository(db)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT text FROM comments").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"text"}).AddRow("Hello"))
+	mock.ExpectExec("INSERT INTO comment_revisions").
+		WithArgs(1, "Hello", "john", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("UPDATE comments SET status").
+		WithArgs(StatusDeleted, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO comment_moderation_log").
+		WithArgs(1, "john", StatusDeleted, "", sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := repo.DeleteComment(1, "john", ""); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestVote(t *testing.T) {
+	tt := []struct {
+		name             string
+		previous         int
+		value            int
+		wantUp, wantDown int
+	}{
+		{"first upvote", 0, 1, 1, 0},
+		{"change to downvote", 1, -1, 0, 1},
+		{"cancel downvote", -1, 0, 0, 0},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			repo := NewRepository(db)
+
+			up, down := voteDelta(0, tc.previous)
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT upvotes, downvotes FROM comments WHERE id = \\? AND status = 'approved' FOR UPDATE").
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"upvotes", "downvotes"}).AddRow(up, down))
+			previous := sqlmock.NewRows([]string{"value"})
+			if tc.previous != 0 {
+				previous.AddRow(tc.previous)
+			}
+			mock.ExpectQuery("SELECT value FROM comment_votes").WithArgs(1, "john").WillReturnRows(previous)
+			if tc.value == 0 {
+				mock.ExpectExec("DELETE FROM comment_votes").WithArgs(1, "john").
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			} else {
+				mock.ExpectExec("INSERT INTO comment_votes").WithArgs(1, "john", tc.value, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			mock.ExpectExec("UPDATE comments SET upvotes = upvotes \\+ \\?, downvotes = downvotes \\+ \\?").
+				WithArgs(tc.wantUp-up, tc.wantDown-down, 1).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			stats, err := repo.Vote(1, "john", tc.value)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if stats.Upvotes != tc.wantUp || stats.Downvotes != tc.wantDown {
+				t.Errorf("expected %d/%d, got %d/%d", tc.wantUp, tc.wantDown, stats.Upvotes, stats.Downvotes)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+
+	db, _, _ := sqlmock.New()
+	if _, err := NewRepository(db).Vote(1, "john", 2); err != ErrInvalidVote {
+		t.Errorf("expected ErrInvalidVote, got %v", err)
+	}
+}
+
+func TestGetCommentStats(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	repo := NewRepository(db)
+
+	mock.ExpectQuery("SELECT id, upvotes, downvotes FROM comments WHERE id IN \\(\\?,\\?\\)").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "upvotes", "downvotes"}).AddRow(1, 3, 1).AddRow(2, 0, 0))
+	mock.ExpectQuery("SELECT comment_id, emoji, COUNT\\(\\*\\) FROM comment_reactions").
+		WithArgs(1, 2).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id", "emoji", "count"}).AddRow(1, "👍", 2).AddRow(1, "😂", 1))
+
+	stats, err := repo.GetCommentStats([]int{1, 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if stats[1].Upvotes != 3 || stats[1].Downvotes != 1 || stats[1].Reactions["👍"] != 2 || stats[1].Reactions["😂"] != 1 {
+		t.Errorf("unexpected stats for comment 1: %+v", stats[1])
+	}
+	if stats[2].Reactions != nil {
+		t.Errorf("expected no reactions for comment 2, got %v", stats[2].Reactions)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestReportComment(t *testing.T) {
+	report := Report{CommentID: 1, Reporter: "ann", Reason: ReportSpam, CreatedAt: time.Now()}
+
+	tt := []struct {
+		name       string
+		count      int
+		threshold  int
+		wantHidden bool
+	}{
+		{"below threshold", 2, 3, false},
+		{"reaches threshold", 3, 3, true},
+		{"hiding disabled", 3, 0, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, _ := sqlmock.New()
+			repo := NewRepository(db)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery("SELECT status FROM comments WHERE id = \\? AND status = 'approved' FOR UPDATE").
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(StatusApproved))
+			mock.ExpectExec("INSERT INTO comment_reports").
+				WithArgs(1, "ann", ReportSpam, "", sqlmock.AnyArg()).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM comment_reports").
+				WithArgs(1).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(tc.count))
+			if tc.wantHidden {
+				mock.ExpectExec("UPDATE comments SET status").
+					WithArgs(StatusPendingReview, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+				mock.ExpectExec("INSERT INTO comment_moderation_log").
+					WithArgs(1, ReportActor, StatusPendingReview, sqlmock.AnyArg(), sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+			mock.ExpectCommit()
+
+			result, err := repo.ReportComment(report, tc.threshold)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if result.Reports != tc.count || result.Hidden != tc.wantHidden {
+				t.Errorf("unexpected result: %+v", result)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("there were unfulfilled expectations: %s", err)
+			}
+		})
+	}
+
+	t.Run("duplicate report", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		repo := NewRepository(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("SELECT status FROM comments").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(StatusApproved))
+		mock.ExpectExec("INSERT INTO comment_reports").
+			WillReturnError(&mysql.MySQLError{Number: mysqlDuplicateEntry})
+		mock.ExpectRollback()
+
+		if _, err := repo.ReportComment(report, 3); !errors.Is(err, ErrAlreadyReported) {
+			t.Errorf("expected ErrAlreadyReported, got %v", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
+
+func TestGetReportedComments(t *testing.T) {
+	db, mock, _ := sqlmock.New()
+	repo := NewRepository(db)
+	now := time.Now()
+
+	mock.ExpectQuery("SELECT c.id, c.author, c.owner, c.text, c.news_id, c.parent_id, c.status, c.created_at,\\s+COUNT\\(\\*\\) AS reports").
+		WithArgs(50, 0).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "author", "owner", "text", "news_id", "parent_id", "status", "created_at", "reports", "last_reported_at"}).
+			AddRow(2, "john", "1", "Buy now", 1, nil, StatusPendingReview, now, 3, now).
+			AddRow(5, "ann", "", "Hello", 2, nil, StatusApproved, now, 1, now))
+	mock.ExpectQuery("SELECT comment_id, reason, COUNT\\(\\*\\) FROM comment_reports").
+		WithArgs(2, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id", "reason", "count"}).
+			AddRow(2, ReportSpam, 2).AddRow(2, ReportAbuse, 1).AddRow(5, ReportOffTopic, 1))
+
+	reported, err := repo.GetReportedComments(50, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(reported) != 2 || reported[0].ID != 2 || reported[0].Reports != 3 || reported[0].Reasons[ReportSpam] != 2 {
+		t.Errorf("unexpected reported comments: %+v", reported)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+// recorder запоминает опубликованные события.
+type recorder struct {
+	events []CommentEvent
+}
+
+func (r *recorder) Publish(e CommentEvent) {
+	r.events = append(r.events, e)
+}
+
+func TestPublishingRepository(t *testing.T) {
+	t.Run("save publishes approved comment", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		events := &recorder{}
+		repo := NewPublishingRepository(db, events)
+
+		mock.ExpectExec("INSERT INTO comments").WillReturnResult(sqlmock.NewResult(7, 1))
+		mock.ExpectExec("INSERT INTO comments").WillReturnResult(sqlmock.NewResult(8, 1))
+
+		if err := repo.Save(Comment{Author: "john", Text: "Hello", NewsID: 1}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if err := repo.Save(Comment{Author: "john", Text: "Hmm", NewsID: 1, Status: StatusPendingReview}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if len(events.events) != 1 || events.events[0].Type != EventCreated || events.events[0].Comment.ID != 7 {
+			t.Errorf("expected only the approved comment to be published, got %+v", events.events)
+		}
+	})
+
+	t.Run("hidden comment is published as deleted", func(t *testing.T) {
+		db, mock, _ := sqlmock.New()
+		events := &recorder{}
+		repo := NewPublishingRepository(db, events)
+
+		mock.ExpectBegin()
+		mock.ExpectExec("UPDATE comments SET status").WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO comment_moderation_log").WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+		mock.ExpectQuery("SELECT id, author, owner, text, news_id, parent_id, status, created_at\\s+FROM comments\\s+WHERE id = \\?").
+			WithArgs(3).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "author", "owner", "text", "news_id", "parent_id", "status", "created_at"}).
+				AddRow(3, "john", "1", "Secret", 1, nil, StatusHidden, time.Now()))
+
+		if err := repo.ChangeCommentStatus(3, StatusHidden, "mod", ""); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if len(events.events) != 1 {
+			t.Fatalf("expected one event, got %+v", events.events)
+		}
+		e := events.events[0]
+		if e.Type != EventDeleted || e.Comment.ID != 3 || e.Comment.NewsID != 1 || e.Comment.Text != "" {
+			t.Errorf("expected deleted event without text, got %+v", e)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+// Package repository - sort.go
+package repository
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Способы сортировки комментариев.
+const (
+	SortNewest        = "newest"
+	SortOldest        = "oldest"
+	SortTop           = "top"
+	SortControversial = "controversial"
+	SortBest          = "best"
+)
+
+// wilsonZ квантиль нормального распределения для доверительного уровня 95%.
+const wilsonZ = 1.96
+
+// WilsonScore нижняя граница доверительного интервала Вильсона для доли голосов «за».
+// В отличие от простой разницы голосов не поднимает комментарий с одним голосом
+// выше комментария с сотней голосов и небольшим числом голосов «против».
+func WilsonScore(up, down int) float64 {
+	n := float64(up + down)
+	if n == 0 {
+		return 0
+	}
+	p := float64(up) / n
+	z2 := wilsonZ * wilsonZ
+	return (p + z2/(2*n) - wilsonZ*math.Sqrt((p*(1-p)+z2/(4*n))/n)) / (1 + z2/n)
+}
+
+// Controversy тем выше, чем больше голосов и чем ближе число голосов «за» к числу «против».
+func Controversy(up, down int) float64 {
+	if up <= 0 || down <= 0 {
+		return 0
+	}
+	magnitude := float64(up + down)
+	balance := float64(down) / float64(up)
+	if up < down {
+		balance = float64(up) / float64(down)
+	}
+	return math.Pow(magnitude, balance)
+}
+
+// SortComments упорядочивает комментарии способом mode. Пустой mode сохраняет порядок.
+// Равные комментарии упорядочиваются от новых к старым.
+func SortComments(comments []Comment, mode string) error {
+	var score func(c Comment) float64
+	switch mode {
+	case "":
+		return nil
+	case SortNewest:
+		score = func(c Comment) float64 { return 0 }
+	case SortOldest:
+		sort.SliceStable(comments, func(i, j int) bool {
+			if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+				return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+			}
+			return comments[i].ID < comments[j].ID
+		})
+		return nil
+	case SortTop:
+		score = func(c Comment) float64 { return float64(c.Upvotes - c.Downvotes) }
+	case SortControversial:
+		score = func(c Comment) float64 { return Controversy(c.Upvotes, c.Downvotes) }
+	case SortBest:
+		score = func(c Comment) float64 { return WilsonScore(c.Upvotes, c.Downvotes) }
+	default:
+		return fmt.Errorf("unknown sort %q", mode)
+	}
+
+	sort.SliceStable(comments, func(i, j int) bool {
+		si, sj := score(comments[i]), score(comments[j])
+		if si != sj {
+			return si > sj
+		}
+		if !comments[i].CreatedAt.Equal(comments[j].CreatedAt) {
+			return comments[i].CreatedAt.After(comments[j].CreatedAt)
+		}
+		return comments[i].ID > comments[j].ID
+	})
+	return nil
+}
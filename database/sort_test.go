@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWilsonScore(t *testing.T) {
+	if WilsonScore(0, 0) != 0 {
+		t.Errorf("expected zero score without votes")
+	}
+	// Один голос «за» не должен перевешивать сотню голосов при пяти «против».
+	if WilsonScore(1, 0) >= WilsonScore(100, 5) {
+		t.Errorf("expected 100/5 to rank above 1/0")
+	}
+	if WilsonScore(10, 0) <= WilsonScore(10, 10) {
+		t.Errorf("expected 10/0 to rank above 10/10")
+	}
+}
+
+func TestSortComments(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	comments := func() []Comment {
+		return []Comment{
+			{ID: 1, CreatedAt: base, Upvotes: 1, Downvotes: 0},
+			{ID: 2, CreatedAt: base.Add(time.Minute), Upvotes: 100, Downvotes: 5},
+			{ID: 3, CreatedAt: base.Add(2 * time.Minute), Upvotes: 50, Downvotes: 48},
+			{ID: 4, CreatedAt: base.Add(3 * time.Minute)},
+		}
+	}
+
+	tt := []struct {
+		mode    string
+		wantIDs []int
+	}{
+		{"", []int{1, 2, 3, 4}},
+		{SortNewest, []int{4, 3, 2, 1}},
+		{SortOldest, []int{1, 2, 3, 4}},
+		{SortTop, []int{2, 3, 1, 4}},
+		{SortControversial, []int{3, 2, 4, 1}},
+		{SortBest, []int{2, 3, 1, 4}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.mode, func(t *testing.T) {
+			list := comments()
+			if err := SortComments(list, tc.mode); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			for i, c := range list {
+				if c.ID != tc.wantIDs[i] {
+					t.Fatalf("expected order %v, got comment %d at %d", tc.wantIDs, c.ID, i)
+				}
+			}
+		})
+	}
+
+	if err := SortComments(comments(), "random"); err == nil {
+		t.Errorf("expected error for unknown sort")
+	}
+}
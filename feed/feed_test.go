@@ -0,0 +1,120 @@
+package feed
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFeed = Feed{
+	ID:      "urn:uuid:00000000-0000-0000-0000-000000000001",
+	Title:   "News",
+	Link:    "http://example.com/news",
+	SelfURL: "http://example.com/news/rss",
+	Author:  "APIGateway",
+	Updated: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	Items: []Item{
+		{ID: "urn:uuid:00000000-0000-0000-0000-000000000002", Title: "A & B", Author: "Ann", Content: "<b>text</b>",
+			Published: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{ID: "urn:uuid:00000000-0000-0000-0000-000000000003", Title: "Anonymous"},
+	},
+}
+
+func TestRSS(t *testing.T) {
+	body, err := RSS(testFeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var doc struct {
+		Version string `xml:"version,attr"`
+		Channel struct {
+			Links         []string `xml:"link"`
+			LastBuildDate string   `xml:"lastBuildDate"`
+			Items         []struct {
+				Title   string `xml:"title"`
+				Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+				GUID    struct {
+					IsPermaLink string `xml:"isPermaLink,attr"`
+					Value       string `xml:",chardata"`
+				} `xml:"guid"`
+				PubDate string `xml:"pubDate"`
+			} `xml:"item"`
+		} `xml:"channel"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("invalid RSS: %v\n%s", err, body)
+	}
+
+	if doc.Version != "2.0" || len(doc.Channel.Links) == 0 || doc.Channel.Links[0] != testFeed.Link {
+		t.Errorf("unexpected channel: %+v", doc)
+	}
+	if doc.Channel.LastBuildDate != "Wed, 01 May 2024 12:00:00 +0000" {
+		t.Errorf("unexpected lastBuildDate %q", doc.Channel.LastBuildDate)
+	}
+	if len(doc.Channel.Items) != 2 {
+		t.Fatalf("expected 2 items, got %d", len(doc.Channel.Items))
+	}
+	item := doc.Channel.Items[0]
+	if item.Title != "A & B" || item.Creator != "Ann" || item.PubDate != "Wed, 01 May 2024 12:00:00 +0000" {
+		t.Errorf("unexpected item: %+v", item)
+	}
+	if item.GUID.IsPermaLink != "false" || item.GUID.Value != testFeed.Items[0].ID {
+		t.Errorf("unexpected guid: %+v", item.GUID)
+	}
+	if doc.Channel.Items[1].PubDate != "" {
+		t.Errorf("expected no pubDate for undated item, got %q", doc.Channel.Items[1].PubDate)
+	}
+}
+
+func TestAtom(t *testing.T) {
+	body, err := Atom(testFeed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), `xmlns="http://www.w3.org/2005/Atom"`) {
+		t.Errorf("missing Atom namespace:\n%s", body)
+	}
+
+	var doc struct {
+		ID      string `xml:"id"`
+		Updated string `xml:"updated"`
+		Links   []struct {
+			Href string `xml:"href,attr"`
+			Rel  string `xml:"rel,attr"`
+		} `xml:"link"`
+		Entries []struct {
+			ID        string `xml:"id"`
+			Updated   string `xml:"updated"`
+			Published string `xml:"published"`
+			Author    *struct {
+				Name string `xml:"name"`
+			} `xml:"author"`
+			Content string `xml:"content"`
+		} `xml:"entry"`
+	}
+	if err := xml.Unmarshal(body, &doc); err != nil {
+		t.Fatalf("invalid Atom: %v\n%s", err, body)
+	}
+
+	if doc.ID != testFeed.ID || doc.Updated != "2024-05-01T12:00:00Z" {
+		t.Errorf("unexpected feed: %+v", doc)
+	}
+	if len(doc.Links) != 2 || doc.Links[1].Rel != "self" || doc.Links[1].Href != testFeed.SelfURL {
+		t.Errorf("unexpected links: %+v", doc.Links)
+	}
+	if len(doc.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(doc.Entries))
+	}
+	first := doc.Entries[0]
+	if first.Updated != "2024-05-01T12:00:00Z" || first.Published != first.Updated || first.Content != "<b>text</b>" {
+		t.Errorf("unexpected entry: %+v", first)
+	}
+	if first.Author == nil || first.Author.Name != "Ann" {
+		t.Errorf("expected entry author Ann, got %+v", first.Author)
+	}
+	if doc.Entries[1].Updated == "" || doc.Entries[1].Author != nil {
+		t.Errorf("expected undated entry to have updated and inherit feed author: %+v", doc.Entries[1])
+	}
+}
@@ -0,0 +1,214 @@
+// Package handlers - auth_handler.go
+package handlers
+
+import (
+	"APIGateway/auth"
+	"APIGateway/authz"
+	"APIGateway/database"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Credentials имя пользователя и пароль для регистрации и входа.
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// TokenResponse ответ на успешный вход.
+type TokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Register регистрирует нового пользователя.
+func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var creds Credentials
+	if err := decodeJSON(r.Body, &creds); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid registration request")
+		return
+	}
+	if err := auth.ValidateCredentials(creds.Username, creds.Password); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash, err := auth.HashPassword(creds.Password)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to register user")
+		return
+	}
+
+	user := repository.User{Username: creds.Username, PasswordHash: hash, Role: authz.Commenter, CreatedAt: time.Now()}
+	user.ID, err = h.Users.CreateUser(user)
+	if errors.Is(err, repository.ErrUserExists) {
+		respondWithError(w, r, http.StatusConflict, "Username is already taken")
+		return
+	} else if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to register user")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusCreated, user)
+}
+
+// Login проверяет пароль и выдает токен доступа.
+func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	var creds Credentials
+	if err := decodeJSON(r.Body, &creds); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid login request")
+		return
+	}
+
+	user, err := h.Users.GetUserByUsername(creds.Username)
+	if err != nil && !errors.Is(err, repository.ErrUserNotFound) {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to log in")
+		return
+	}
+	if err != nil || !auth.CheckPassword(user.PasswordHash, creds.Password) {
+		respondWithError(w, r, http.StatusUnauthorized, "Invalid username or password")
+		return
+	}
+
+	if user.Role == "" {
+		user.Role = authz.Commenter
+	}
+	token, err := h.Tokens.Issue(user.ID, user.Username, user.Role)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to issue token")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, TokenResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(h.Tokens.TTL.Seconds()),
+	})
+}
+
+// RoleRequest назначение роли пользователю.
+type RoleRequest struct {
+	Username string `json:"username"`
+	Role     string `json:"role"`
+}
+
+// SetUserRole назначает пользователю роль. Доступно администраторам.
+// Новая роль попадает в токен при следующем входе.
+func (h *Handler) SetUserRole(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if _, ok := h.authorize(w, r, authz.ManageUsers); !ok {
+		return
+	}
+
+	var req RoleRequest
+	if err := decodeJSON(r.Body, &req); err != nil || req.Username == "" {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid role request")
+		return
+	}
+	if !authz.ValidRole(req.Role) {
+		respondWithError(w, r, http.StatusBadRequest, "Unknown role")
+		return
+	}
+
+	err := h.Users.SetUserRole(req.Username, req.Role)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		respondWithError(w, r, http.StatusNotFound, "User not found")
+		return
+	} else if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to set role")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, req)
+}
+
+// authorize проверяет право perm пользователя из запроса. При отказе
+// отвечает 401 или 403 и возвращает false.
+func (h *Handler) authorize(w http.ResponseWriter, r *http.Request, perm authz.Permission) (*auth.Claims, bool) {
+	claims, err := h.authenticate(r)
+	if err == nil {
+		err = authz.Authorize(claims, perm)
+	}
+	if err != nil {
+		respondWithAuthError(w, r, err)
+		return nil, false
+	}
+	return claims, true
+}
+
+// respondWithAuthError отвечает 403 на нехватку прав и 401 на остальные ошибки входа.
+func respondWithAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, authz.ErrForbidden):
+		respondWithError(w, r, http.StatusForbidden, "Insufficient permissions")
+	case errors.Is(err, auth.ErrInvalidToken):
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		respondWithError(w, r, http.StatusUnauthorized, "Invalid or expired token")
+	default:
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		respondWithError(w, r, http.StatusUnauthorized, "Authentication required")
+	}
+}
+
+// authenticate возвращает утверждения, проверенные шлюзом, или проверяет токен
+// из заголовка Authorization. Без токена возвращает nil без ошибки.
+func (h *Handler) authenticate(r *http.Request) (*auth.Claims, error) {
+	if claims := auth.ClaimsFromContext(r.Context()); claims != nil {
+		return claims, nil
+	}
+	token, ok := auth.BearerToken(r.Header.Get("Authorization"))
+	if !ok {
+		return nil, nil
+	}
+	if h.Tokens == nil {
+		return nil, auth.ErrInvalidToken
+	}
+	return h.Tokens.Verify(token)
+}
+
+// resolveAuthor определяет автора и владельца комментария по токену: владельцем
+// становится субъект токена. Анонимная публикация с именем из тела запроса
+// разрешена только при AllowAnonymous, только под именем, не принадлежащим
+// зарегистрированному пользователю, и владельца не получает.
+func (h *Handler) resolveAuthor(r *http.Request, comment *repository.Comment) (int, string) {
+	claims, err := h.authenticate(r)
+	switch {
+	case err != nil:
+		return http.StatusUnauthorized, "Invalid or expired token"
+	case claims != nil:
+		if authz.Authorize(claims, authz.CreateComment) != nil {
+			return http.StatusForbidden, "Insufficient permissions"
+		}
+		comment.Author, comment.Owner = claims.Username, claims.Subject
+		return 0, ""
+	case !h.AllowAnonymous:
+		return http.StatusUnauthorized, "Authentication required"
+	}
+
+	comment.Owner = ""
+	if comment.Author == "" {
+		comment.Author = "anonymous"
+		return 0, ""
+	}
+	if h.Users != nil {
+		if _, err := h.Users.GetUserByUsername(comment.Author); err == nil {
+			return http.StatusForbidden, "Author name belongs to a registered user"
+		}
+	}
+	return 0, ""
+}
@@ -0,0 +1,177 @@
+// auth_handler_test.go
+package handlers
+
+import (
+	"APIGateway/auth"
+	"APIGateway/authz"
+	"APIGateway/database"
+	"APIGateway/response"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// memoryUsers хранилище пользователей в памяти для тестов.
+type memoryUsers map[string]repository.User
+
+func (m memoryUsers) CreateUser(u repository.User) (int, error) {
+	if _, ok := m[u.Username]; ok {
+		return 0, repository.ErrUserExists
+	}
+	u.ID = len(m) + 1
+	m[u.Username] = u
+	return u.ID, nil
+}
+
+func (m memoryUsers) GetUserByUsername(username string) (repository.User, error) {
+	u, ok := m[username]
+	if !ok {
+		return repository.User{}, repository.ErrUserNotFound
+	}
+	return u, nil
+}
+
+func (m memoryUsers) SetUserRole(username, role string) error {
+	u, ok := m[username]
+	if !ok {
+		return repository.ErrUserNotFound
+	}
+	u.Role = role
+	m[username] = u
+	return nil
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	h := &Handler{Users: memoryUsers{}, Tokens: auth.NewIssuer([]byte("secret"), time.Hour)}
+
+	post := func(handler http.HandlerFunc, body string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		handler(rr, httptest.NewRequest("POST", "/auth", bytes.NewBufferString(body)))
+		return rr
+	}
+
+	if rr := post(h.Register, `{"username":"john","password":"secret-pass"}`); rr.Code != http.StatusCreated {
+		t.Fatalf("expected status %v, got %v: %s", http.StatusCreated, rr.Code, rr.Body)
+	}
+	if rr := post(h.Register, `{"username":"john","password":"secret-pass"}`); rr.Code != http.StatusConflict {
+		t.Errorf("expected duplicate username to conflict, got %v", rr.Code)
+	}
+	if rr := post(h.Register, `{"username":"ann","password":"short"}`); rr.Code != http.StatusBadRequest {
+		t.Errorf("expected weak password to be rejected, got %v", rr.Code)
+	}
+
+	if rr := post(h.Login, `{"username":"john","password":"wrong-pass"}`); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected wrong password to be rejected, got %v", rr.Code)
+	}
+	if rr := post(h.Login, `{"username":"nobody","password":"secret-pass"}`); rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected unknown user to be rejected, got %v", rr.Code)
+	}
+
+	rr := post(h.Login, `{"username":"john","password":"secret-pass"}`)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status %v, got %v", http.StatusOK, rr.Code)
+	}
+	var resp TokenResponse
+	if err := response.Decode(rr.Body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	claims, err := h.Tokens.Verify(resp.AccessToken)
+	if err != nil || claims.Username != "john" || !claims.HasRole(authz.Commenter) {
+		t.Errorf("expected commenter token for john, got %+v, %v", claims, err)
+	}
+}
+
+func TestAddCommentAuthor(t *testing.T) {
+	newCensorshipStub(t, http.StatusOK, "")
+
+	tokens := auth.NewIssuer([]byte("secret"), time.Hour)
+	token, _ := tokens.Issue(1, "john", authz.Commenter)
+	readerToken, _ := tokens.Issue(2, "ann", authz.Reader)
+	users := memoryUsers{"john": {ID: 1, Username: "john"}}
+
+	tt := []struct {
+		name       string
+		anonymous  bool
+		header     string
+		author     string
+		wantStatus int
+		wantAuthor string
+		wantOwner  string
+	}{
+		{"author from token", false, "Bearer " + token, "mallory", http.StatusCreated, "john", "1"},
+		{"no token", false, "", "john", http.StatusUnauthorized, "", ""},
+		{"reader token", false, "Bearer " + readerToken, "ann", http.StatusForbidden, "", ""},
+		{"invalid token", true, "Bearer garbage", "ann", http.StatusUnauthorized, "", ""},
+		{"anonymous", true, "", "ann", http.StatusCreated, "ann", ""},
+		{"anonymous without name", true, "", "", http.StatusCreated, "anonymous", ""},
+		{"anonymous with blank name", true, "", " ", http.StatusUnprocessableEntity, "", ""},
+		{"anonymous impersonation", true, "", "john", http.StatusForbidden, "", ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var saved, owner string
+			h := &Handler{
+				Repo: &MockRepository{
+					SaveFunc: func(c repository.Comment) error {
+						saved, owner = c.Author, c.Owner
+						return nil
+					},
+				},
+				Users:          users,
+				Tokens:         tokens,
+				AllowAnonymous: tc.anonymous,
+			}
+
+			body := `{"text":"hello","news_id":1}`
+			if tc.author != "" {
+				body = `{"author":"` + tc.author + `","text":"hello","news_id":1}`
+			}
+			req := httptest.NewRequest("POST", "/comments/add", bytes.NewBufferString(body))
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rr := httptest.NewRecorder()
+			h.AddComment(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			if saved != tc.wantAuthor {
+				t.Errorf("expected author %q, got %q", tc.wantAuthor, saved)
+			}
+			if owner != tc.wantOwner {
+				t.Errorf("expected owner %q, got %q", tc.wantOwner, owner)
+			}
+		})
+	}
+}
+
+func TestSetUserRole(t *testing.T) {
+	users := memoryUsers{"john": {ID: 1, Username: "john", Role: authz.Commenter}}
+	h := &Handler{Users: users}
+
+	tt := []struct {
+		name       string
+		role       string
+		body       string
+		wantStatus int
+	}{
+		{"admin promotes", authz.Admin, `{"username":"john","role":"moderator"}`, http.StatusOK},
+		{"moderator promotes", authz.Moderator, `{"username":"john","role":"admin"}`, http.StatusForbidden},
+		{"unknown role", authz.Admin, `{"username":"john","role":"owner"}`, http.StatusBadRequest},
+		{"unknown user", authz.Admin, `{"username":"ann","role":"moderator"}`, http.StatusNotFound},
+	}
+	for _, tc := range tt {
+		rr := httptest.NewRecorder()
+		h.SetUserRole(rr, asUser(httptest.NewRequest("POST", "/admin/users/role", bytes.NewBufferString(tc.body)), "root", tc.role))
+		if rr.Code != tc.wantStatus {
+			t.Errorf("%s: expected status %v, got %v", tc.name, tc.wantStatus, rr.Code)
+		}
+	}
+	if users["john"].Role != authz.Moderator {
+		t.Errorf("expected john to become moderator, got %q", users["john"].Role)
+	}
+}
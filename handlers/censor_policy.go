@@ -0,0 +1,98 @@
+// Package handlers - censor_policy.go
+package handlers
+
+import (
+	"APIGateway/censor"
+	"APIGateway/database"
+	"APIGateway/spam"
+	"errors"
+	"expvar"
+	"os"
+)
+
+// ErrCensorshipUnavailable возвращается, когда сервис цензуры не смог проверить комментарий.
+var ErrCensorshipUnavailable = errors.New("censorship service unavailable")
+
+// CensorPolicy определяет поведение шлюза, когда сервис цензуры недоступен.
+type CensorPolicy string
+
+const (
+	// FailClosed отклоняет комментарии, пока сервис цензуры недоступен.
+	FailClosed CensorPolicy = "fail-closed"
+	// FailOpen принимает комментарии с пометкой pending_review.
+	FailOpen CensorPolicy = "fail-open"
+	// LocalFallback проверяет комментарии локально через repository.ModerateComment.
+	LocalFallback CensorPolicy = "local"
+)
+
+// Причины решений цензуры.
+const (
+	ReasonCensorshipPassed      = "censorship_passed"
+	ReasonForbiddenContent      = "forbidden_content"
+	ReasonForbiddenMasked       = "forbidden_content_masked"
+	ReasonNeedsReview           = "needs_review"
+	ReasonCensorshipUnavailable = "censorship_unavailable"
+	ReasonLocalPassed           = "local_moderation_passed"
+	ReasonLocalForbidden        = "local_moderation_forbidden"
+)
+
+// censorDecisions счетчики решений по политике и причине, доступны на /debug/vars.
+var censorDecisions = expvar.NewMap("censorship_decisions")
+
+// CensorDecision итог проверки комментария с учетом политики и оценки спама.
+type CensorDecision struct {
+	Allowed bool            `json:"allowed"`
+	Status  string          `json:"status"`
+	Reason  string          `json:"reason"`
+	Policy  CensorPolicy    `json:"policy"`
+	Masked  bool            `json:"masked,omitempty"`
+	Verdict *censor.Verdict `json:"verdict,omitempty"`
+	Spam    *spam.Result    `json:"spam,omitempty"`
+}
+
+// CensorPolicyFromEnv читает политику из переменной CENSOR_POLICY. По умолчанию fail-closed.
+func CensorPolicyFromEnv() CensorPolicy {
+	switch policy := CensorPolicy(os.Getenv("CENSOR_POLICY")); policy {
+	case FailOpen, LocalFallback:
+		return policy
+	}
+	return FailClosed
+}
+
+// decideCensorship проверяет комментарий и применяет политику при недоступности сервиса.
+func (h *Handler) decideCensorship(comment *repository.Comment) CensorDecision {
+	policy := h.CensorPolicy
+	if policy == "" {
+		policy = FailClosed
+	}
+
+	decision := CensorDecision{Policy: policy}
+	verdict, err := h.CensorComment(comment)
+	decision.Verdict = verdict
+	switch {
+	case err == nil && verdict.Decision == censor.Allowed:
+		decision.Allowed, decision.Status, decision.Reason = true, repository.StatusApproved, ReasonCensorshipPassed
+	case err == nil && verdict.Decision == censor.NeedsReview:
+		decision.Allowed, decision.Status, decision.Reason = true, repository.StatusPendingReview, ReasonNeedsReview
+	case err == nil && h.MaskBlocked && verdict.Masked != "":
+		decision.Allowed, decision.Status, decision.Reason = true, repository.StatusApproved, ReasonForbiddenMasked
+		decision.Masked = true
+	case err == nil:
+		decision.Status, decision.Reason = repository.StatusRejected, ReasonForbiddenContent
+	case policy == FailOpen:
+		decision.Allowed, decision.Status, decision.Reason = true, repository.StatusPendingReview, ReasonCensorshipUnavailable
+	case policy == LocalFallback:
+		moderated := *comment
+		repository.ModerateComment(&moderated)
+		if moderated.Text == comment.Text {
+			decision.Allowed, decision.Status, decision.Reason = true, repository.StatusApproved, ReasonLocalPassed
+		} else {
+			decision.Status, decision.Reason = repository.StatusRejected, ReasonLocalForbidden
+		}
+	default:
+		decision.Status, decision.Reason = repository.StatusRejected, ReasonCensorshipUnavailable
+	}
+
+	censorDecisions.Add(string(policy)+"/"+decision.Reason, 1)
+	return decision
+}
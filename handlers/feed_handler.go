@@ -0,0 +1,230 @@
+// Package handlers - feed_handler.go
+package handlers
+
+import (
+	"APIGateway/asyncrequests"
+	repository "APIGateway/database"
+	"APIGateway/feed"
+	"APIGateway/ingest"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/google/uuid"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// FeedPageSize число записей в одной странице ленты.
+var FeedPageSize = 50
+
+// feedAuthor автор лент шлюза, которым Atom подписывает записи без автора.
+const feedAuthor = "APIGateway"
+
+// feedNamespace пространство имен постоянных идентификаторов записей лент.
+var feedNamespace = uuid.NewSHA1(uuid.NameSpaceURL, []byte("urn:apigateway:feed"))
+
+// feedEncoder кодирует ленту в один из форматов.
+type feedEncoder struct {
+	encode      func(feed.Feed) ([]byte, error)
+	contentType string
+}
+
+var (
+	rssFeed  = feedEncoder{encode: feed.RSS, contentType: feed.RSSContentType}
+	atomFeed = feedEncoder{encode: feed.Atom, contentType: feed.AtomContentType}
+)
+
+// NewsRSS отдает новости в формате RSS 2.0 с теми же параметрами search, source
+// и page, что и /news.
+func (h *Handler) NewsRSS(w http.ResponseWriter, r *http.Request) {
+	h.newsFeed(w, r, rssFeed)
+}
+
+// NewsAtom отдает новости в формате Atom 1.0.
+func (h *Handler) NewsAtom(w http.ResponseWriter, r *http.Request) {
+	h.newsFeed(w, r, atomFeed)
+}
+
+// CommentsRSS отдает комментарии новости news_id в формате RSS 2.0.
+func (h *Handler) CommentsRSS(w http.ResponseWriter, r *http.Request) {
+	h.commentsFeed(w, r, rssFeed)
+}
+
+// CommentsAtom отдает комментарии новости news_id в формате Atom 1.0.
+func (h *Handler) CommentsAtom(w http.ResponseWriter, r *http.Request) {
+	h.commentsFeed(w, r, atomFeed)
+}
+
+func (h *Handler) newsFeed(w http.ResponseWriter, r *http.Request, enc feedEncoder) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q := ingest.Query{
+		Search:   r.URL.Query().Get("search"),
+		Source:   r.URL.Query().Get("source"),
+		Page:     1,
+		PageSize: FeedPageSize,
+	}
+	if p := r.URL.Query().Get("page"); p != "" {
+		page, err := strconv.Atoi(p)
+		if err != nil || page <= 0 {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid page number")
+			return
+		}
+		q.Page = page
+	}
+
+	articles, err := h.feedArticles(q)
+	if err != nil {
+		log.Error("Failed to get news for feed", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to get news from services")
+		return
+	}
+
+	base := baseURL(r)
+	title := "News"
+	if q.Search != "" {
+		title = fmt.Sprintf("News: %s", q.Search)
+	}
+	f := feed.Feed{
+		ID:          feedID(r.URL.Path + "?" + r.URL.Query().Encode()),
+		Title:       title,
+		Description: "Aggregated news",
+		Link:        base + "/news?" + url.Values{"search": {q.Search}, "page": {strconv.Itoa(q.Page)}}.Encode(),
+		SelfURL:     base + r.URL.RequestURI(),
+		Author:      feedAuthor,
+	}
+	for _, a := range articles {
+		f.Items = append(f.Items, feed.Item{
+			ID:        feedID("article:" + a.ID),
+			Title:     a.Title,
+			Author:    a.Author,
+			Content:   a.Content,
+			Published: a.PublishedAt,
+		})
+		if a.PublishedAt.After(f.Updated) {
+			f.Updated = a.PublishedAt
+		}
+	}
+
+	serveFeed(w, r, enc, f)
+}
+
+// feedArticles возвращает страницу статей из хранилища или, если оно не
+// настроено, у источников. Статьи источников приводятся к виду хранилища,
+// чтобы идентификаторы записей не зависели от способа получения.
+func (h *Handler) feedArticles(q ingest.Query) ([]ingest.Article, error) {
+	if h.Articles != nil {
+		articles, _, err := h.Articles.List(q)
+		return articles, err
+	}
+
+	serviceURLs := []string{BBCAPI, NYTAPI}
+	news, _, err := asyncrequests.ExecuteAsyncHTTPGets(GetNews, serviceURLs, q.Search, q.Page, q.PageSize)
+	if err != nil {
+		return nil, err
+	}
+	var articles []ingest.Article
+	for _, n := range news {
+		if q.Source != "" && n.Source != q.Source {
+			continue
+		}
+		// Нулевое время получения не дает дате статьи меняться от запроса к запросу.
+		if a, ok := ingest.Normalize(n.Source, n, time.Time{}); ok {
+			articles = append(articles, a)
+		}
+	}
+	sort.SliceStable(articles, func(i, j int) bool {
+		return articles[i].PublishedAt.After(articles[j].PublishedAt)
+	})
+	return articles, nil
+}
+
+func (h *Handler) commentsFeed(w http.ResponseWriter, r *http.Request, enc feedEncoder) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	newsID, err := strconv.Atoi(r.URL.Query().Get("news_id"))
+	if err != nil || newsID <= 0 {
+		respondWithError(w, r, http.StatusBadRequest, "'news_id' parameter is required")
+		return
+	}
+
+	comments, err := h.Repo.GetCommentsByNewsID(newsID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to fetch comments")
+		return
+	}
+	repository.SortComments(comments, repository.SortNewest)
+
+	base := baseURL(r)
+	f := feed.Feed{
+		ID:          feedID(fmt.Sprintf("comments:%d", newsID)),
+		Title:       fmt.Sprintf("Comments on news %d", newsID),
+		Description: fmt.Sprintf("Latest comments on news %d", newsID),
+		Link:        fmt.Sprintf("%s/comments/get?news_id=%d", base, newsID),
+		SelfURL:     base + r.URL.RequestURI(),
+		Author:      feedAuthor,
+	}
+	for _, c := range comments {
+		// Удаленные комментарии остаются в ветках заглушками, в ленту они не попадают.
+		if c.Status == repository.StatusDeleted {
+			continue
+		}
+		if len(f.Items) == FeedPageSize {
+			break
+		}
+		f.Items = append(f.Items, feed.Item{
+			ID:        feedID(fmt.Sprintf("comment:%d", c.ID)),
+			Title:     fmt.Sprintf("Comment by %s", c.Author),
+			Author:    c.Author,
+			Content:   c.Text,
+			Published: c.CreatedAt,
+		})
+		if c.CreatedAt.After(f.Updated) {
+			f.Updated = c.CreatedAt
+		}
+	}
+
+	serveFeed(w, r, enc, f)
+}
+
+// serveFeed кодирует ленту и отдает ее с ETag и Last-Modified. Условные запросы
+// If-None-Match и If-Modified-Since получают 304, если лента не изменилась.
+func serveFeed(w http.ResponseWriter, r *http.Request, enc feedEncoder, f feed.Feed) {
+	body, err := enc.encode(f)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to encode feed")
+		return
+	}
+
+	sum := sha256.Sum256(body)
+	w.Header().Set("Content-Type", enc.contentType)
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	w.Header().Set("Cache-Control", "no-cache")
+	http.ServeContent(w, r, "", f.Updated, bytes.NewReader(body))
+}
+
+// feedID постоянный идентификатор записи или ленты в виде urn:uuid.
+func feedID(name string) string {
+	return uuid.NewSHA1(feedNamespace, []byte(name)).URN()
+}
+
+// baseURL адрес шлюза, по которому пришел запрос, с учетом прокси.
+func baseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	return scheme + "://" + r.Host
+}
@@ -0,0 +1,140 @@
+// feed_handler_test.go
+package handlers
+
+import (
+	"APIGateway/database"
+	"APIGateway/feed"
+	"APIGateway/ingest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewsFeeds(t *testing.T) {
+	store := ingest.NewMemoryStore()
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i, n := range []repository.News{{Title: "Old"}, {Title: "New"}} {
+		n.Published = base.Add(time.Duration(i) * time.Hour).Format(time.RFC3339)
+		source := "bbc"
+		if i == 1 {
+			source = "nyt"
+		}
+		a, _ := ingest.Normalize(source, n, base)
+		store.Save([]ingest.Article{a})
+	}
+	h := &Handler{Articles: store}
+
+	tt := []struct {
+		name            string
+		handler         http.HandlerFunc
+		target          string
+		wantContentType string
+		wantTitles      []string
+	}{
+		{"rss", h.NewsRSS, "/news/rss", feed.RSSContentType, []string{"New", "Old"}},
+		{"atom", h.NewsAtom, "/news/atom", feed.AtomContentType, []string{"New", "Old"}},
+		{"rss by source", h.NewsRSS, "/news/rss?source=bbc", feed.RSSContentType, []string{"Old"}},
+		{"atom with search", h.NewsAtom, "/news/atom?search=new", feed.AtomContentType, []string{"New"}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tc.handler(rr, httptest.NewRequest("GET", tc.target, nil))
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %v: %s", rr.Code, rr.Body)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tc.wantContentType {
+				t.Errorf("expected content type %q, got %q", tc.wantContentType, got)
+			}
+			body := rr.Body.String()
+			last := -1
+			for _, title := range tc.wantTitles {
+				i := strings.Index(body, "<title>"+title+"</title>")
+				if i < last {
+					t.Errorf("expected %q in order %v:\n%s", title, tc.wantTitles, body)
+				}
+				last = i
+			}
+			if n := strings.Count(body, "<title>"); n != len(tc.wantTitles)+1 {
+				t.Errorf("expected %d items, got %d", len(tc.wantTitles), n-1)
+			}
+		})
+	}
+}
+
+func TestFeedConditionalRequests(t *testing.T) {
+	store := ingest.NewMemoryStore()
+	a, _ := ingest.Normalize("bbc", repository.News{Title: "News", Published: "2024-05-01T10:00:00Z"}, time.Now())
+	store.Save([]ingest.Article{a})
+	h := &Handler{Articles: store}
+
+	rr := httptest.NewRecorder()
+	h.NewsRSS(rr, httptest.NewRequest("GET", "/news/rss", nil))
+	etag := rr.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected ETag header")
+	}
+
+	tt := []struct {
+		name       string
+		header     string
+		value      string
+		wantStatus int
+	}{
+		{"matching etag", "If-None-Match", etag, http.StatusNotModified},
+		{"stale etag", "If-None-Match", `"stale"`, http.StatusOK},
+		{"not modified since", "If-Modified-Since", "Wed, 01 May 2024 10:00:00 GMT", http.StatusNotModified},
+		{"modified since", "If-Modified-Since", "Wed, 01 May 2024 09:00:00 GMT", http.StatusOK},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/news/rss", nil)
+			req.Header.Set(tc.header, tc.value)
+			rr := httptest.NewRecorder()
+			h.NewsRSS(rr, req)
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+		})
+	}
+}
+
+func TestCommentsFeed(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	h := &Handler{Repo: &MockRepository{
+		GetCommentsByNewsIDFunc: func(nid int) ([]repository.Comment, error) {
+			return []repository.Comment{
+				{ID: 1, Author: "alice", Text: "First", NewsID: nid, Status: repository.StatusApproved, CreatedAt: created},
+				{ID: 2, Text: repository.DeletedPlaceholder, NewsID: nid, Status: repository.StatusDeleted, CreatedAt: created.Add(time.Hour)},
+				{ID: 3, Author: "bob", Text: "Second", NewsID: nid, Status: repository.StatusApproved, CreatedAt: created.Add(2 * time.Hour)},
+			}, nil
+		},
+	}}
+
+	rr := httptest.NewRecorder()
+	h.CommentsAtom(rr, httptest.NewRequest("GET", "/comments/atom?news_id=7", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %v", rr.Code)
+	}
+	body := rr.Body.String()
+	if strings.Contains(body, repository.DeletedPlaceholder) {
+		t.Errorf("deleted comment must not be in the feed:\n%s", body)
+	}
+	if strings.Index(body, "Second") > strings.Index(body, "First") {
+		t.Errorf("expected newest comment first:\n%s", body)
+	}
+	if got := rr.Header().Get("Last-Modified"); got != "Wed, 01 May 2024 14:00:00 GMT" {
+		t.Errorf("unexpected Last-Modified %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	h.CommentsRSS(rr, httptest.NewRequest("GET", "/comments/rss", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 without news_id, got %v", rr.Code)
+	}
+}
@@ -0,0 +1,100 @@
+// Package handlers - filter_handler.go
+package handlers
+
+import (
+	"APIGateway/asyncrequests"
+	"APIGateway/ingest"
+	"APIGateway/search"
+	"math"
+	"net/http"
+	"time"
+)
+
+// NewsFilterHandler обрабатывает запросы и возвращает новости, отобранные
+// фильтром из параметра search, например "source:bbc after:2024-01-01 -sport".
+// Синтаксис фильтра описан в search.ParseFilter.
+func (h *Handler) NewsFilterHandler(w http.ResponseWriter, r *http.Request) {
+	page, ok := newsPage(w, r)
+	if !ok {
+		return
+	}
+	filter, err := search.ParseFilter(r.URL.Query().Get("search"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pageSize := 10 // define the appropriate page size
+
+	response, err := h.filteredNews(filter, page, pageSize)
+	if err != nil {
+		log.Error("Failed to get news from services", err)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to get news from services")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, response)
+}
+
+// FilterScanLimit наибольшее число статей хранилища, которые фильтр проверяет
+// за запрос. Источники, авторы и даты отбираются хранилищем, а язык и слова
+// проверяются среди не более чем FilterScanLimit самых свежих подходящих статей.
+var FilterScanLimit = 1000
+
+// filteredNews отбирает статьи фильтром. Статьи хранилища отбираются и делятся
+// на страницы после проверки фильтром. Без хранилища фильтруется страница page
+// новостей источников, и пагинация остается пагинацией источников.
+// Если фильтр проверил не все статьи, в ответе выставляется Partial.
+func (h *Handler) filteredNews(filter search.Filter, page, pageSize int) (NewsResponse, error) {
+	if h.Articles == nil {
+		serviceURLs := []string{BBCAPI, NYTAPI}
+		news, pagination, err := asyncrequests.ExecuteAsyncHTTPGets(GetNews, serviceURLs, "", page, pageSize)
+		if err != nil {
+			return NewsResponse{}, err
+		}
+		filtered := []News{}
+		for _, n := range news {
+			if a, ok := ingest.Normalize(n.Source, n, time.Time{}); ok && filter.Match(a) {
+				filtered = append(filtered, n)
+			}
+		}
+		// Страниц у источников больше одной: подходящие новости есть и на других.
+		partial := pagination != nil && pagination.TotalPages > 1
+		return NewsResponse{News: filtered, Pagination: pagination, Partial: partial}, nil
+	}
+
+	q := filter.Query()
+	q.Page, q.PageSize = 1, FilterScanLimit
+	articles, total, err := h.Articles.List(q)
+	if err != nil {
+		return NewsResponse{}, err
+	}
+
+	var matched []ingest.Article
+	for _, a := range articles {
+		if filter.Match(a) {
+			matched = append(matched, a)
+		}
+	}
+	start := (page - 1) * pageSize
+	if start > len(matched) {
+		start = len(matched)
+	}
+	end := start + pageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+
+	news := make([]News, 0, end-start)
+	for _, a := range matched[start:end] {
+		news = append(news, a.News())
+	}
+	return NewsResponse{
+		News: news,
+		Pagination: &Pagination{
+			CurrentPage: page,
+			TotalPages:  int(math.Ceil(float64(len(matched)) / float64(pageSize))),
+		},
+		Partial: total > len(articles),
+	}, nil
+}
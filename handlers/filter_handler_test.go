@@ -0,0 +1,158 @@
+// filter_handler_test.go
+package handlers
+
+import (
+	"APIGateway/database"
+	"APIGateway/ingest"
+	"APIGateway/response"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewsFilterHandler(t *testing.T) {
+	store := ingest.NewMemoryStore()
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, n := range []struct {
+		source string
+		news   repository.News
+	}{
+		{"bbc", repository.News{Title: "Sport: cup final", Author: "John"}},
+		{"bbc", repository.News{Title: "Budget approved", Author: "Jane"}},
+		{"nyt", repository.News{Title: "Markets rally", Author: "Jane"}},
+		{"bbc", repository.News{Title: "Бюджет принят", Author: "Анна"}},
+	} {
+		n.news.Published = base.AddDate(0, 0, i*10).Format(time.RFC3339)
+		a, _ := ingest.Normalize(n.source, n.news, base)
+		store.Save([]ingest.Article{a})
+	}
+	h := &Handler{Articles: store}
+
+	tt := []struct {
+		name       string
+		search     string
+		page       string
+		wantStatus int
+		wantTitles []string
+	}{
+		{"no filter", "", "1", http.StatusOK, []string{"Бюджет принят", "Markets rally", "Budget approved", "Sport: cup final"}},
+		{"source and exclusion", "source:bbc -sport", "1", http.StatusOK, []string{"Бюджет принят", "Budget approved"}},
+		{"author and date range", "author:jane after:2024-01-05 before:2024-01-22", "1", http.StatusOK, []string{"Markets rally", "Budget approved"}},
+		{"language", "lang:ru", "1", http.StatusOK, []string{"Бюджет принят"}},
+		{"page past the end", "lang:ru", "2", http.StatusOK, []string{}},
+		{"invalid filter", "after:tomorrow", "1", http.StatusBadRequest, nil},
+		{"invalid page", "", "0", http.StatusBadRequest, nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			target := "/news/filter?" + url.Values{"search": {tc.search}, "page": {tc.page}}.Encode()
+			rr := httptest.NewRecorder()
+			h.NewsFilterHandler(rr, httptest.NewRequest("GET", target, nil))
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("expected status %v, got %v: %s", tc.wantStatus, rr.Code, rr.Body)
+			}
+			if tc.wantTitles == nil {
+				return
+			}
+
+			var resp NewsResponse
+			if err := response.Decode(rr.Body, &resp); err != nil {
+				t.Fatal(err)
+			}
+			if len(resp.News) != len(tc.wantTitles) {
+				t.Fatalf("expected %v, got %+v", tc.wantTitles, resp.News)
+			}
+			for i, n := range resp.News {
+				if n.Title != tc.wantTitles[i] {
+					t.Errorf("expected %v, got %+v", tc.wantTitles, resp.News)
+				}
+			}
+		})
+	}
+}
+
+// queryStore запоминает запрос к хранилищу статей.
+type queryStore struct {
+	ingest.Store
+	query ingest.Query
+}
+
+func (s *queryStore) List(q ingest.Query) ([]ingest.Article, int, error) {
+	s.query = q
+	return s.Store.List(q)
+}
+
+func TestNewsFilterHandlerQueriesStore(t *testing.T) {
+	original := FilterScanLimit
+	FilterScanLimit = 2
+	t.Cleanup(func() { FilterScanLimit = original })
+
+	store := &queryStore{Store: ingest.NewMemoryStore()}
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		a, _ := ingest.Normalize("bbc", repository.News{Title: "Budget news", Author: "Jane", Published: base.AddDate(0, 0, i).Format(time.RFC3339)}, base)
+		store.Save([]ingest.Article{a})
+	}
+	h := &Handler{Articles: store}
+
+	target := "/news/filter?" + url.Values{"search": {"source:bbc author:jane after:2024-01-02 budget"}, "page": {"1"}}.Encode()
+	rr := httptest.NewRecorder()
+	h.NewsFilterHandler(rr, httptest.NewRequest("GET", target, nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %v", rr.Code)
+	}
+
+	q := store.query
+	if strings.Join(q.Sources, ",") != "bbc" || strings.Join(q.Authors, ",") != "jane" || !q.After.Equal(base.AddDate(0, 0, 1)) {
+		t.Errorf("expected source, author and date to be pushed to the store, got %+v", q)
+	}
+	var resp NewsResponse
+	if err := response.Decode(rr.Body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.News) != 2 || resp.Pagination.TotalPages != 1 || !resp.Partial {
+		t.Errorf("expected a partial result limited to %d articles, got %d news, %+v and partial %v", FilterScanLimit, len(resp.News), resp.Pagination, resp.Partial)
+	}
+
+	// Все подходящие статьи помещаются в лимит: результат полный.
+	FilterScanLimit = 10
+	rr = httptest.NewRecorder()
+	h.NewsFilterHandler(rr, httptest.NewRequest("GET", target, nil))
+	resp = NewsResponse{}
+	if err := response.Decode(rr.Body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.News) != 4 || resp.Partial {
+		t.Errorf("expected all 4 matches without partial, got %d news and partial %v", len(resp.News), resp.Partial)
+	}
+}
+
+func TestNewsFilterHandlerWithoutStore(t *testing.T) {
+	GetNews = func(serviceURL, title string, page, pageSize int) ([]News, *Pagination, error) {
+		return []News{
+			{Title: "Sport news " + serviceURL, Source: "bbc"},
+			{Title: "Politics " + serviceURL, Source: "bbc"},
+		}, &Pagination{CurrentPage: page, TotalPages: 3}, nil
+	}
+	defer func() { GetNews = GetNewsFromService }()
+
+	rr := httptest.NewRecorder()
+	(&Handler{}).NewsFilterHandler(rr, httptest.NewRequest("GET", "/news/filter?page=1&search=-sport", nil))
+
+	var resp NewsResponse
+	if err := response.Decode(rr.Body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.News) != 2 || resp.Pagination == nil || !resp.Partial {
+		t.Errorf("expected a partial page of filtered news, got %+v", resp)
+	}
+	for _, n := range resp.News {
+		if !strings.HasPrefix(n.Title, "Politics") {
+			t.Errorf("expected only politics, got %q", n.Title)
+		}
+	}
+}
@@ -2,8 +2,17 @@
 package handlers
 
 import (
+	"APIGateway/auth"
+	"APIGateway/authz"
+	"APIGateway/censor"
 	"APIGateway/database"
+	"APIGateway/httpclient"
+	"APIGateway/idempotency"
+	"APIGateway/response"
+	"APIGateway/spam"
+	"APIGateway/validate"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -19,6 +28,16 @@ var (
 	requestTimeout     = 2 * time.Second
 )
 
+// Upstream общий HTTP-клиент с повторами и выключателями для вызовов других сервисов.
+var Upstream = newUpstreamClient()
+
+// newUpstreamClient создает клиента апстримов с таймаутом шлюза.
+func newUpstreamClient() *httpclient.Client {
+	cfg := httpclient.ConfigFromEnv()
+	cfg.Timeout = requestTimeout
+	return httpclient.New(cfg)
+}
+
 var CensorshipServiceURL = "http://localhost:8080/censor"
 
 // decodeJSON декодирует JSON из тела запроса.
@@ -27,113 +46,479 @@ func decodeJSON(body io.ReadCloser, v interface{}) error {
 	return json.NewDecoder(body).Decode(v)
 }
 
-// createCommentInService создает комментарий в другом сервисе.
-func createCommentInService(input *repository.Comment) (*repository.Comment, error) {
-	client := &http.Client{}
-	reqBody, err := json.Marshal(input)
-	if err != nil {
-		return nil, err
+// decodeRequest строго декодирует и проверяет тело запроса размером не больше
+// maxBytes. При ошибке отвечает клиенту и возвращает false: 413 для слишком
+// большого тела, 422 с ошибками полей в errors и 400 для некорректного JSON.
+func decodeRequest(w http.ResponseWriter, r *http.Request, v interface{}, maxBytes int64, readOnly ...string) bool {
+	err := validate.DecodeJSON(w, r, v, maxBytes, readOnly...)
+	if err == nil {
+		return true
 	}
 
-	req, err := http.NewRequest("POST", CommentsServiceURL, strings.NewReader(string(reqBody)))
-	if err != nil {
-		return nil, err
+	var fields validate.Errors
+	switch {
+	case errors.As(err, &fields):
+		respondWithValidation(w, r, fields)
+	case errors.Is(err, validate.ErrBodyTooLarge):
+		respondWithError(w, r, http.StatusRequestEntityTooLarge, fmt.Sprintf("Request body exceeds %d bytes", maxBytes))
+	default:
+		respondWithError(w, r, http.StatusBadRequest, "Malformed JSON body")
 	}
-	req.Header.Set("Content-Type", "application/json")
+	return false
+}
 
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	} else if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to create comment, status code: %d", resp.StatusCode)
-	}
+// respondWithValidation отвечает 422 со списком ошибок полей в errors.
+func respondWithValidation(w http.ResponseWriter, r *http.Request, fields validate.Errors) {
+	response.WriteProblem(w, r, response.Problem{
+		Status:     http.StatusUnprocessableEntity,
+		Detail:     "Request validation failed",
+		Code:       "validation_failed",
+		Extensions: map[string]interface{}{"errors": fields},
+	})
+}
 
-	var comment repository.Comment
-	if err := decodeJSON(resp.Body, &comment); err != nil {
-		return nil, err
+// Ограничения комментария.
+var (
+	// MaxCommentBodyBytes наибольший размер тела запроса на публикацию комментария.
+	MaxCommentBodyBytes int64 = 16 << 10
+	// MaxCommentLength наибольшая длина текста комментария в символах.
+	MaxCommentLength = 2000
+	// MaxAuthorLength наибольшая длина имени автора в символах.
+	MaxAuthorLength = 64
+)
+
+// commentServerFields поля комментария, которые заполняет сервер.
+var commentServerFields = []string{"id", "status", "created_at", "upvotes", "downvotes", "reactions"}
+
+// CommentRequest тело запроса на публикацию комментария. Author необязателен:
+// для авторизованного пользователя автор берется из токена, анонимный без имени
+// публикуется как anonymous.
+type CommentRequest struct {
+	Author   *string `json:"author,omitempty"`
+	Text     string  `json:"text"`
+	NewsID   int     `json:"news_id"`
+	ParentID *int    `json:"parent_id,omitempty"`
+}
+
+// Validate проверяет поля запроса, не обращаясь к хранилищу.
+func (c *CommentRequest) Validate() error {
+	var v validate.Validator
+	if c.Author != nil {
+		v.Line("author", *c.Author, 1, MaxAuthorLength)
+	}
+	v.Text("text", c.Text, 1, MaxCommentLength)
+	v.Positive("news_id", c.NewsID)
+	if c.ParentID != nil {
+		v.Positive("parent_id", *c.ParentID)
 	}
+	return v.Err()
+}
 
-	return &comment, nil
+// Comment возвращает комментарий из запроса без полей, которые заполняет сервер.
+func (c *CommentRequest) Comment() repository.Comment {
+	comment := repository.Comment{Text: c.Text, NewsID: c.NewsID, ParentID: c.ParentID}
+	if c.Author != nil {
+		comment.Author = strings.TrimSpace(*c.Author)
+	}
+	return comment
 }
 
 // NewHandler создает и возвращает новую структуру Handler
 func NewHandler(repo repository.RepositoryInterface) *Handler {
-	return &Handler{Repo: repo}
+	return &Handler{
+		Repo:         repo,
+		CensorPolicy: CensorPolicyFromEnv(),
+		MaskBlocked:  os.Getenv("CENSOR_MASK_BLOCKED") == "true",
+		Spam:         spam.NewDetector(spam.DefaultConfig()),
+		Tokens:       auth.NewIssuerFromEnv(),
+		// Анонимная публикация включается явно.
+		AllowAnonymous:  os.Getenv("ALLOW_ANONYMOUS_COMMENTS") == "true",
+		ReportThreshold: reportThresholdFromEnv(),
+	}
+}
+
+// defaultReportThreshold число жалоб, скрывающее комментарий, если REPORT_HIDE_THRESHOLD не задан.
+const defaultReportThreshold = 5
+
+// reportThresholdFromEnv читает порог скрытия из REPORT_HIDE_THRESHOLD.
+func reportThresholdFromEnv() int {
+	if n, err := strconv.Atoi(os.Getenv("REPORT_HIDE_THRESHOLD")); err == nil && n >= 0 {
+		return n
+	}
+	return defaultReportThreshold
 }
 
 // AddComment обрабатывает HTTP POST запросы и добавлет комментарий.
+// С заголовком Idempotency-Key повтор запроса получает первый ответ.
 func (h *Handler) AddComment(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-
-	var comment repository.Comment
-	if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	var req CommentRequest
+	if !decodeRequest(w, r, &req, MaxCommentBodyBytes, commentServerFields...) {
 		return
 	}
+	comment := req.Comment()
 
-	comment.CreatedAt = time.Now()
+	if code, message := h.resolveAuthor(r, &comment); code != 0 {
+		if code == http.StatusUnauthorized {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+		}
+		respondWithError(w, r, code, message)
+		return
+	}
+	if !h.checkParent(w, r, comment) {
+		return
+	}
 
-	// Call the censorship service and check the comment
-	censorResult, err := h.CensorComment(&comment)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	key := r.Header.Get(idempotency.Header)
+	if key == "" || h.Idempotency == nil {
+		h.saveComment(w, r, comment)
 		return
 	}
+	h.idempotent(w, r, h.idempotencyScope(r), key, req, func(w http.ResponseWriter) {
+		h.saveComment(w, r, comment)
+	})
+}
+
+// saveComment проверяет комментарий на спам и цензуру и сохраняет его.
+func (h *Handler) saveComment(w http.ResponseWriter, r *http.Request, comment repository.Comment) {
+	comment.CreatedAt = time.Now()
 
-	if !censorResult {
-		http.Error(w, "Forbidden content in comment", http.StatusForbidden)
+	// Check the comment for spam and call the censorship service
+	decision := h.moderateComment(&comment, clientIP(r))
+	w.Header().Set("X-Censorship-Reason", decision.Reason)
+	if !decision.Allowed {
+		respondWithDecision(w, r, decision)
 		return
 	}
+	comment.Status = decision.Status
+	if decision.Masked {
+		comment.Text = decision.Verdict.Masked
+	}
 
 	if err := h.Repo.Save(comment); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to save comment")
 		return
 	}
 
-	w.WriteHeader(http.StatusCreated)
-	if err := json.NewEncoder(w).Encode(comment); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	respondWithJSON(w, r, http.StatusCreated, comment)
+}
+
+// idempotencyScope возвращает пространство ключей идемпотентности клиента.
+// Имя анонимного автора может взять любой, поэтому анонимные ключи отделяются
+// адресом клиента, а ключи вошедших пользователей и ключей API — субъектом токена.
+func (h *Handler) idempotencyScope(r *http.Request) string {
+	if claims, err := h.authenticate(r); err == nil && claims != nil {
+		return "user:" + claims.Subject
+	}
+	return "anonymous:" + clientIP(r)
+}
+
+// idempotent выполняет fn один раз для ключа из заголовка Idempotency-Key в
+// пределах scope. Повтор получает первый ответ, повтор во время выполнения — 409,
+// тот же ключ с другим запросом req — 422.
+func (h *Handler) idempotent(w http.ResponseWriter, r *http.Request, scope, key string, req interface{}, fn func(w http.ResponseWriter)) {
+	fingerprint, err := idempotency.Fingerprint(req)
+	if err == nil {
+		err = h.Idempotency.Do(w, scope, key, fingerprint, fn)
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, idempotency.ErrInvalidKey):
+		respondWithError(w, r, http.StatusBadRequest, "Invalid Idempotency-Key header")
+	case errors.Is(err, idempotency.ErrInProgress):
+		w.Header().Set("Retry-After", "1")
+		response.WriteProblem(w, r, response.Problem{
+			Status: http.StatusConflict,
+			Detail: "A request with this Idempotency-Key is still in progress",
+			Code:   "idempotency_in_progress",
+		})
+	case errors.Is(err, idempotency.ErrMismatch):
+		response.WriteProblem(w, r, response.Problem{
+			Status: http.StatusUnprocessableEntity,
+			Detail: "Idempotency-Key was already used with a different request",
+			Code:   "idempotency_key_reused",
+		})
+	default:
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to check Idempotency-Key")
+	}
+}
+
+// checkParent проверяет, что родительский комментарий существует и относится
+// к той же новости. При ошибке отвечает клиенту и возвращает false.
+func (h *Handler) checkParent(w http.ResponseWriter, r *http.Request, comment repository.Comment) bool {
+	if comment.ParentID == nil {
+		return true
+	}
+
+	parent, err := h.Repo.GetComment(*comment.ParentID)
+	switch {
+	case errors.Is(err, repository.ErrCommentNotFound):
+		respondWithValidation(w, r, validate.Errors{{Field: "parent_id", Code: validate.CodeNotFound, Message: "parent comment does not exist"}})
+		return false
+	case err != nil:
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to load parent comment")
+		return false
+	case parent.NewsID != comment.NewsID:
+		respondWithValidation(w, r, validate.Errors{{Field: "parent_id", Code: validate.CodeInvalid, Message: "parent comment belongs to another news item"}})
+		return false
 	}
+	return true
 }
 
-// CensorComment отправляет запрос для проверки содержимого комментария.
-func (h *Handler) CensorComment(comment *repository.Comment) (bool, error) {
-	client := &http.Client{}
+// CensorComment отправляет запрос для проверки содержимого комментария и возвращает вердикт.
+// Ошибка ErrCensorshipUnavailable означает, что сервис не вынес решения.
+func (h *Handler) CensorComment(comment *repository.Comment) (*censor.Verdict, error) {
 	reqBody, err := json.Marshal(comment)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	req, err := http.NewRequest("POST", CensorshipServiceURL, strings.NewReader(string(reqBody)))
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 	req.Header.Set("Content-Type", "application/json")
+	// Проверка комментария не меняет состояние, поэтому запрос можно повторять.
+	req = req.WithContext(httpclient.WithIdempotent(req.Context()))
+
+	resp, err := Upstream.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCensorshipUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusBadRequest {
+		return nil, fmt.Errorf("%w: status code %d", ErrCensorshipUnavailable, resp.StatusCode)
+	}
+
+	var verdict censor.Verdict
+	if err := decodeJSON(resp.Body, &verdict); err != nil || verdict.Decision == "" {
+		// Сервис старой версии отвечает только статусом без тела.
+		verdict = censor.Verdict{Decision: censor.Allowed}
+		if resp.StatusCode == http.StatusBadRequest {
+			verdict = censor.Verdict{Decision: censor.Blocked, Severity: 1}
+		}
+	}
+
+	return &verdict, nil
+}
+
+// respondWithDecision отвечает на комментарий, не прошедший проверку:
+// 503, если цензура недоступна, и 403 в остальных случаях. Решение проверки
+// передается в поле decision.
+func respondWithDecision(w http.ResponseWriter, r *http.Request, decision CensorDecision) {
+	problem := response.Problem{
+		Status:     http.StatusForbidden,
+		Detail:     "Forbidden content in comment",
+		Code:       "forbidden_content",
+		Extensions: map[string]interface{}{"decision": decision},
+	}
+	switch decision.Reason {
+	case ReasonCensorshipUnavailable:
+		problem.Status = http.StatusServiceUnavailable
+		problem.Detail = "Censorship service unavailable"
+		problem.Code = "censorship_unavailable"
+	case ReasonSpamDetected:
+		problem.Detail = "Comment looks like spam"
+		problem.Code = "spam_detected"
+	}
+	response.WriteProblem(w, r, problem)
+}
+
+// EditRequest новый текст комментария.
+type EditRequest struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+// Validate проверяет идентификатор и новый текст комментария.
+func (e *EditRequest) Validate() error {
+	var v validate.Validator
+	v.Positive("id", e.ID)
+	v.Text("text", e.Text, 1, MaxCommentLength)
+	return v.Err()
+}
 
-	resp, err := client.Do(req)
+// EditComment обрабатывает HTTP PUT запросы и меняет текст своего комментария.
+// Новый текст заново проходит проверку на спам и цензуру, прежний сохраняется
+// в истории правок. Комментарий на проверке после правки остается на проверке.
+func (h *Handler) EditComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPut {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, err := h.authenticate(r)
 	if err != nil {
-		return false, err
+		respondWithAuthError(w, r, err)
+		return
 	}
 
-	if resp.StatusCode == http.StatusOK {
-		return true, nil
+	var req EditRequest
+	if !decodeRequest(w, r, &req, MaxCommentBodyBytes) {
+		return
 	}
 
-	return false, nil
+	comment, ok := h.findComment(w, r, req.ID)
+	if !ok {
+		return
+	}
+	if err := authz.AuthorizeOwned(claims, "", authz.EditOwnComment, comment.Owner); err != nil {
+		respondWithAuthError(w, r, err)
+		return
+	}
+	// Скрытый или отклоненный комментарий правка не должна возвращать в выдачу.
+	if comment.Status != repository.StatusApproved && comment.Status != repository.StatusPendingReview {
+		respondWithError(w, r, http.StatusConflict, "Comment cannot be edited")
+		return
+	}
+
+	comment.Text = req.Text
+	decision := h.moderateComment(&comment, clientIP(r))
+	w.Header().Set("X-Censorship-Reason", decision.Reason)
+	if !decision.Allowed {
+		respondWithDecision(w, r, decision)
+		return
+	}
+	// Одобрить комментарий на проверке может только модератор, не правка автора.
+	if comment.Status != repository.StatusPendingReview {
+		comment.Status = decision.Status
+	}
+	if decision.Masked {
+		comment.Text = decision.Verdict.Masked
+	}
+
+	err = h.Repo.UpdateComment(comment.ID, comment.Text, comment.Status, claims.Username)
+	if errors.Is(err, repository.ErrCommentNotFound) {
+		respondWithError(w, r, http.StatusNotFound, "Comment not found")
+		return
+	} else if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to update comment")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, comment)
 }
 
-// GetComments обрабатывает HTTP GET запросы и возвращает комментарии.
+// CommentRevisions возвращает историю правок комментария. Доступно автору и модераторам.
+func (h *Handler) CommentRevisions(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		respondWithAuthError(w, r, err)
+		return
+	}
+	commentID, err := strconv.Atoi(r.URL.Query().Get("comment_id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "'comment_id' parameter is required")
+		return
+	}
+
+	comment, ok := h.findComment(w, r, commentID)
+	if !ok {
+		return
+	}
+	if err := authz.AuthorizeOwned(claims, authz.ReviewComments, authz.EditOwnComment, comment.Owner); err != nil {
+		respondWithAuthError(w, r, err)
+		return
+	}
+
+	revisions, err := h.Repo.GetRevisions(commentID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to fetch revisions")
+		return
+	}
+	if revisions == nil {
+		revisions = []repository.Revision{}
+	}
+
+	respondWithJSON(w, r, http.StatusOK, revisions)
+}
+
+// findComment извлекает комментарий. При ошибке отвечает 404 или 500 и возвращает false.
+func (h *Handler) findComment(w http.ResponseWriter, r *http.Request, id int) (repository.Comment, bool) {
+	comment, err := h.Repo.GetComment(id)
+	if errors.Is(err, repository.ErrCommentNotFound) {
+		respondWithError(w, r, http.StatusNotFound, "Comment not found")
+		return comment, false
+	} else if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to fetch comment")
+		return comment, false
+	}
+	return comment, true
+}
+
+// GetComments обрабатывает HTTP GET запросы и возвращает одобренные комментарии
+// вместе с комментариями зрителя, ожидающими проверки.
 func (h *Handler) GetComments(w http.ResponseWriter, r *http.Request) {
 	newsID, err := strconv.Atoi(r.URL.Query().Get("news_id"))
 	if err != nil {
-		http.Error(w, "'news_id' parameter is required", http.StatusBadRequest)
+		respondWithError(w, r, http.StatusBadRequest, "'news_id' parameter is required")
+		return
+	}
+
+	// Свои комментарии на проверке видит только владелец, вошедший по токену:
+	// имени из запроса доверять нельзя, под ним мог публиковать кто угодно.
+	viewer := ""
+	if claims, err := h.authenticate(r); err == nil && claims != nil {
+		viewer = claims.Subject
 	}
 
-	comments, err := h.Repo.GetCommentsByNewsID(newsID)
+	var comments []repository.Comment
+	if viewer != "" {
+		comments, err = h.Repo.GetVisibleComments(newsID, viewer)
+	} else {
+		comments, err = h.Repo.GetCommentsByNewsID(newsID)
+	}
 	if err != nil {
-		http.Error(w, "Failed to fetch comments", http.StatusInternalServerError)
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to fetch comments")
+		return
+	}
+	if err := h.attachStats(comments); err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to fetch comment votes")
 		return
 	}
+	if err := repository.SortComments(comments, r.URL.Query().Get("sort")); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if comments == nil {
+		comments = []repository.Comment{}
+	}
+
+	respondWithJSON(w, r, http.StatusOK, commentTable(comments))
+}
+
+// commentTable комментарии, выгружаемые в CSV.
+type commentTable []repository.Comment
+
+func (t commentTable) Table() ([]string, [][]string) {
+	header := []string{"id", "author", "text", "news_id", "parent_id", "status", "created_at", "upvotes", "downvotes"}
+	rows := make([][]string, len(t))
+	for i, c := range t {
+		parentID := ""
+		if c.ParentID != nil {
+			parentID = strconv.Itoa(*c.ParentID)
+		}
+		rows[i] = []string{
+			strconv.Itoa(c.ID), c.Author, c.Text, strconv.Itoa(c.NewsID), parentID, c.Status,
+			c.CreatedAt.UTC().Format(time.RFC3339), strconv.Itoa(c.Upvotes), strconv.Itoa(c.Downvotes),
+		}
+	}
+	return header, rows
+}
+
+// HealthHandler возвращает состояние шлюза и выключателей апстримов.
+func (h *Handler) HealthHandler(w http.ResponseWriter, r *http.Request) {
+	breakers := Upstream.Health()
+
+	status := "ok"
+	for _, state := range breakers {
+		if state != "closed" {
+			status = "degraded"
+		}
+	}
 
-	json.NewEncoder(w).Encode(comments)
+	respondWithJSON(w, r, http.StatusOK, map[string]interface{}{
+		"status":   status,
+		"breakers": breakers,
+	})
 }
@@ -2,10 +2,18 @@
 package handlers
 
 import (
+	"APIGateway/auth"
+	"APIGateway/authz"
 	"APIGateway/database"
+	"APIGateway/idempotency"
+	"APIGateway/response"
+	"APIGateway/spam"
+	"APIGateway/validate"
 	"bytes"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 )
@@ -13,6 +21,21 @@ import (
 type MockRepository struct {
 	SaveFunc                func(repository.Comment) error
 	GetCommentsByNewsIDFunc func(nid int) ([]repository.Comment, error)
+	GetVisibleCommentsFunc  func(nid int, viewer string) ([]repository.Comment, error)
+	GetPendingCommentsFunc  func(limit, offset int) ([]repository.Comment, error)
+	GetApprovedCommentsFunc func(afterID, limit int) ([]repository.Comment, error)
+	SetCommentStatusFunc    func(id int, status, moderator, reason string) error
+	GetCommentFunc          func(id int) (repository.Comment, error)
+	ChangeCommentStatusFunc func(id int, status, actor, reason string) error
+	UpdateCommentFunc       func(id int, text, status, editor string) error
+	DeleteCommentFunc       func(id int, actor, reason string) error
+	GetRevisionsFunc        func(commentID int) ([]repository.Revision, error)
+	GetModerationLogFunc    func(commentID int) ([]repository.ModerationEntry, error)
+	VoteFunc                func(commentID int, voter string, value int) (repository.CommentStats, error)
+	ReactFunc               func(commentID int, user, emoji string, add bool) error
+	GetCommentStatsFunc     func(ids []int) (map[int]repository.CommentStats, error)
+	ReportCommentFunc       func(report repository.Report, threshold int) (repository.ReportResult, error)
+	GetReportedCommentsFunc func(limit, offset int) ([]repository.ReportedComment, error)
 	SetupDatabaseFunc       func() error
 }
 
@@ -24,12 +47,91 @@ func (m *MockRepository) GetCommentsByNewsID(nid int) ([]repository.Comment, err
 	return m.GetCommentsByNewsIDFunc(nid)
 }
 
+func (m *MockRepository) GetVisibleComments(nid int, viewer string) ([]repository.Comment, error) {
+	return m.GetVisibleCommentsFunc(nid, viewer)
+}
+
+func (m *MockRepository) GetPendingComments(limit, offset int) ([]repository.Comment, error) {
+	return m.GetPendingCommentsFunc(limit, offset)
+}
+
+func (m *MockRepository) GetApprovedComments(afterID, limit int) ([]repository.Comment, error) {
+	return m.GetApprovedCommentsFunc(afterID, limit)
+}
+
+func (m *MockRepository) SetCommentStatus(id int, status, moderator, reason string) error {
+	return m.SetCommentStatusFunc(id, status, moderator, reason)
+}
+
+func (m *MockRepository) GetComment(id int) (repository.Comment, error) {
+	return m.GetCommentFunc(id)
+}
+
+func (m *MockRepository) ChangeCommentStatus(id int, status, actor, reason string) error {
+	return m.ChangeCommentStatusFunc(id, status, actor, reason)
+}
+
+func (m *MockRepository) UpdateComment(id int, text, status, editor string) error {
+	return m.UpdateCommentFunc(id, text, status, editor)
+}
+
+func (m *MockRepository) DeleteComment(id int, actor, reason string) error {
+	return m.DeleteCommentFunc(id, actor, reason)
+}
+
+func (m *MockRepository) GetRevisions(commentID int) ([]repository.Revision, error) {
+	return m.GetRevisionsFunc(commentID)
+}
+
+func (m *MockRepository) GetModerationLog(commentID int) ([]repository.ModerationEntry, error) {
+	return m.GetModerationLogFunc(commentID)
+}
+
+func (m *MockRepository) Vote(commentID int, voter string, value int) (repository.CommentStats, error) {
+	return m.VoteFunc(commentID, voter, value)
+}
+
+func (m *MockRepository) React(commentID int, user, emoji string, add bool) error {
+	return m.ReactFunc(commentID, user, emoji, add)
+}
+
+// GetCommentStats без заданной функции возвращает пустую статистику.
+func (m *MockRepository) GetCommentStats(ids []int) (map[int]repository.CommentStats, error) {
+	if m.GetCommentStatsFunc == nil {
+		return nil, nil
+	}
+	return m.GetCommentStatsFunc(ids)
+}
+
+func (m *MockRepository) ReportComment(report repository.Report, threshold int) (repository.ReportResult, error) {
+	return m.ReportCommentFunc(report, threshold)
+}
+
+func (m *MockRepository) GetReportedComments(limit, offset int) ([]repository.ReportedComment, error) {
+	return m.GetReportedCommentsFunc(limit, offset)
+}
+
 func (m *MockRepository) SetupDatabase() error {
 	return m.SetupDatabaseFunc()
 }
 
+// newCensorshipStub подменяет CensorshipServiceURL тестовым сервером, отвечающим status и body.
+func newCensorshipStub(t *testing.T, status int, body string) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	original := CensorshipServiceURL
+	CensorshipServiceURL = server.URL
+	t.Cleanup(func() {
+		CensorshipServiceURL = original
+		server.Close()
+	})
+}
+
 func TestAddComment(t *testing.T) {
-	reqBody := bytes.NewBuffer([]byte(`{"author":"John","text":"Test comment","news_id":1,"parent_id":null,"created_at":"` + time.Now().Format(time.RFC3339Nano) + `"}`))
+	newCensorshipStub(t, http.StatusOK, "")
+	reqBody := bytes.NewBuffer([]byte(`{"author":"John","text":"Test comment","news_id":1,"parent_id":null}`))
 	req, _ := http.NewRequest("POST", "/addComment", reqBody)
 	rr := httptest.NewRecorder()
 
@@ -42,6 +144,7 @@ func TestAddComment(t *testing.T) {
 				return nil
 			},
 		},
+		AllowAnonymous: true,
 	}
 	h.AddComment(rr, req)
 
@@ -50,6 +153,169 @@ func TestAddComment(t *testing.T) {
 	}
 }
 
+func TestAddCommentValidation(t *testing.T) {
+	newCensorshipStub(t, http.StatusOK, "")
+	parents := map[int]repository.Comment{3: {ID: 3, NewsID: 1}, 4: {ID: 4, NewsID: 2}}
+
+	tt := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantField  string
+	}{
+		{"valid reply", `{"text":"hi","news_id":1,"parent_id":3}`, http.StatusCreated, ""},
+		{"server-owned id", `{"id":9,"text":"hi","news_id":1}`, http.StatusUnprocessableEntity, "id"},
+		{"server-owned created_at", `{"text":"hi","news_id":1,"created_at":"2024-01-01T00:00:00Z"}`, http.StatusUnprocessableEntity, "created_at"},
+		{"unknown field", `{"text":"hi","news_id":1,"title":"x"}`, http.StatusUnprocessableEntity, "title"},
+		{"empty text", `{"text":"  ","news_id":1}`, http.StatusUnprocessableEntity, "text"},
+		{"text too long", `{"text":"` + strings.Repeat("a", MaxCommentLength+1) + `","news_id":1}`, http.StatusUnprocessableEntity, "text"},
+		{"control characters", `{"text":"a\u0007b","news_id":1}`, http.StatusUnprocessableEntity, "text"},
+		{"author on several lines", `{"author":"a\nb","text":"hi","news_id":1}`, http.StatusUnprocessableEntity, "author"},
+		{"missing news_id", `{"text":"hi"}`, http.StatusUnprocessableEntity, "news_id"},
+		{"missing parent", `{"text":"hi","news_id":1,"parent_id":5}`, http.StatusUnprocessableEntity, "parent_id"},
+		{"parent of another news", `{"text":"hi","news_id":1,"parent_id":4}`, http.StatusUnprocessableEntity, "parent_id"},
+		{"malformed json", `{"text":`, http.StatusBadRequest, ""},
+		{"body too large", `{"text":"` + strings.Repeat("a", int(MaxCommentBodyBytes)) + `","news_id":1}`, http.StatusRequestEntityTooLarge, ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			saved := false
+			h := &Handler{
+				Repo: &MockRepository{
+					SaveFunc: func(c repository.Comment) error {
+						saved = true
+						return nil
+					},
+					GetCommentFunc: func(id int) (repository.Comment, error) {
+						if c, ok := parents[id]; ok {
+							return c, nil
+						}
+						return repository.Comment{}, repository.ErrCommentNotFound
+					},
+				},
+				AllowAnonymous: true,
+			}
+			rr := httptest.NewRecorder()
+			h.AddComment(rr, httptest.NewRequest("POST", "/comments/add", strings.NewReader(tc.body)))
+
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("expected status %v, got %v: %s", tc.wantStatus, rr.Code, rr.Body.String())
+			}
+			if saved != (tc.wantStatus == http.StatusCreated) {
+				t.Errorf("unexpected save: %v", saved)
+			}
+			if tc.wantField == "" {
+				return
+			}
+			var problem struct {
+				Code   string                `json:"code"`
+				Errors []validate.FieldError `json:"errors"`
+			}
+			if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+				t.Fatal(err)
+			}
+			if problem.Code != "validation_failed" || len(problem.Errors) != 1 || problem.Errors[0].Field != tc.wantField {
+				t.Errorf("expected an error for %q, got %+v", tc.wantField, problem)
+			}
+		})
+	}
+}
+
+func TestAddCommentIdempotency(t *testing.T) {
+	newCensorshipStub(t, http.StatusOK, "")
+	saved := 0
+	h := &Handler{
+		Repo: &MockRepository{
+			SaveFunc: func(c repository.Comment) error {
+				saved++
+				return nil
+			},
+		},
+		AllowAnonymous: true,
+		Idempotency:    idempotency.NewService(idempotency.NewMemoryStore()),
+	}
+	post := func(key, body string, client func(*http.Request) *http.Request) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/comments/add", strings.NewReader(body))
+		req.RemoteAddr = "192.0.2.1:1234"
+		req.Header.Set(idempotency.Header, key)
+		if client != nil {
+			req = client(req)
+		}
+		rr := httptest.NewRecorder()
+		h.AddComment(rr, req)
+		return rr
+	}
+
+	first := post("k1", `{"author":"ann","text":"hello","news_id":1}`, nil)
+	retry := post("k1", `{"author":"ann","text":"hello","news_id":1}`, nil)
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated || saved != 1 {
+		t.Fatalf("expected one saved comment, got %v, %v, %d saves", first.Code, retry.Code, saved)
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get(idempotency.ReplayedHeader) != "true" {
+		t.Errorf("expected the first response replayed, got %s", retry.Body.String())
+	}
+
+	otherClient := func(r *http.Request) *http.Request {
+		r.RemoteAddr = "198.51.100.7:4321"
+		return r
+	}
+	signedIn := func(r *http.Request) *http.Request {
+		return asUser(r, "ann", authz.Commenter)
+	}
+	// Ключ API с тем же названием, что и имя пользователя, не делит с ним ключи.
+	apiKey := func(r *http.Request) *http.Request {
+		return r.WithContext(auth.WithClaims(r.Context(), &auth.Claims{Subject: "apikey:7", Username: "ann", Roles: []string{authz.Commenter}}))
+	}
+
+	tt := []struct {
+		name       string
+		key        string
+		body       string
+		client     func(*http.Request) *http.Request
+		wantStatus int
+		wantSaves  int
+	}{
+		{"different payload", "k1", `{"author":"ann","text":"changed","news_id":1}`, nil, http.StatusUnprocessableEntity, 1},
+		{"same client under another name", "k1", `{"author":"bob","text":"hello","news_id":1}`, nil, http.StatusUnprocessableEntity, 1},
+		{"another anonymous client", "k1", `{"author":"ann","text":"hello","news_id":1}`, otherClient, http.StatusCreated, 2},
+		{"signed-in user with the same name", "k1", `{"author":"ann","text":"hello","news_id":1}`, signedIn, http.StatusCreated, 3},
+		{"API key named like the user", "k1", `{"author":"ann","text":"hello","news_id":1}`, apiKey, http.StatusCreated, 4},
+		{"invalid key", "bad\tkey", `{"author":"ann","text":"hello","news_id":1}`, nil, http.StatusBadRequest, 4},
+		{"no key", "", `{"author":"ann","text":"hello","news_id":1}`, nil, http.StatusCreated, 5},
+	}
+	for _, tc := range tt {
+		rr := post(tc.key, tc.body, tc.client)
+		if rr.Code != tc.wantStatus || saved != tc.wantSaves {
+			t.Errorf("%s: expected %v with %d saves, got %v with %d", tc.name, tc.wantStatus, tc.wantSaves, rr.Code, saved)
+		}
+	}
+}
+
+func TestAddCommentIdempotencyInProgress(t *testing.T) {
+	newCensorshipStub(t, http.StatusOK, "")
+	var inner *httptest.ResponseRecorder
+	h := &Handler{AllowAnonymous: true, Idempotency: idempotency.NewService(idempotency.NewMemoryStore())}
+	request := func() *http.Request {
+		req := httptest.NewRequest("POST", "/comments/add", strings.NewReader(`{"text":"hello","news_id":1}`))
+		req.Header.Set(idempotency.Header, "k1")
+		return req
+	}
+	h.Repo = &MockRepository{
+		SaveFunc: func(c repository.Comment) error {
+			// Повтор приходит, пока первый запрос еще сохраняет комментарий.
+			inner = httptest.NewRecorder()
+			h.AddComment(inner, request())
+			return nil
+		},
+	}
+	h.AddComment(httptest.NewRecorder(), request())
+
+	if inner == nil || inner.Code != http.StatusConflict || inner.Header().Get("Retry-After") == "" {
+		t.Errorf("expected 409 for a request in progress, got %+v", inner)
+	}
+}
+
 func TestGetComments(t *testing.T) {
 	req, _ := http.NewRequest("GET", "/getComment?news_id=1", nil)
 	rr := httptest.NewRecorder()
@@ -72,3 +338,296 @@ func TestGetComments(t *testing.T) {
 		t.Errorf("Expected Status %v, but got %v", http.StatusOK, rr.Result().StatusCode)
 	}
 }
+
+func TestGetCommentsFormats(t *testing.T) {
+	h := &Handler{
+		Repo: &MockRepository{
+			GetCommentsByNewsIDFunc: func(nid int) ([]repository.Comment, error) {
+				return []repository.Comment{
+					{ID: 7, Author: "John", Text: "Hello, world", NewsID: 1, Status: repository.StatusApproved, CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+				}, nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	h.GetComments(rr, httptest.NewRequest("GET", "/comments/get?news_id=1&format=csv", nil))
+	if rr.Code != http.StatusOK || !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+		t.Fatalf("expected CSV export, got %v %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	want := "id,author,text,news_id,parent_id,status,created_at,upvotes,downvotes\n" +
+		"7,John,\"Hello, world\",1,,approved,2024-05-01T12:00:00Z,0,0\n"
+	if rr.Body.String() != want {
+		t.Errorf("expected body %q, got %q", want, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	h.GetComments(rr, httptest.NewRequest("GET", "/comments/get?news_id=abc", nil))
+	if rr.Code != http.StatusBadRequest || rr.Header().Get("Content-Type") != response.ProblemJSON {
+		t.Errorf("expected problem+json 400, got %v %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+}
+
+func TestAddCommentCensorPolicy(t *testing.T) {
+	tt := []struct {
+		name       string
+		policy     CensorPolicy
+		censor     int
+		text       string
+		wantStatus int
+		wantReason string
+		wantSaved  string
+	}{
+		{"service allows", FailClosed, http.StatusOK, "hello", http.StatusCreated, ReasonCensorshipPassed, repository.StatusApproved},
+		{"service forbids", FailOpen, http.StatusBadRequest, "hello", http.StatusForbidden, ReasonForbiddenContent, ""},
+		{"fail-closed on 500", FailClosed, http.StatusInternalServerError, "hello", http.StatusServiceUnavailable, ReasonCensorshipUnavailable, ""},
+		{"default is fail-closed", "", http.StatusInternalServerError, "hello", http.StatusServiceUnavailable, ReasonCensorshipUnavailable, ""},
+		{"fail-open on 500", FailOpen, http.StatusInternalServerError, "hello", http.StatusCreated, ReasonCensorshipUnavailable, repository.StatusPendingReview},
+		{"local fallback allows", LocalFallback, http.StatusInternalServerError, "hello", http.StatusCreated, ReasonLocalPassed, repository.StatusApproved},
+		{"local fallback forbids", LocalFallback, http.StatusInternalServerError, "qwerty", http.StatusForbidden, ReasonLocalForbidden, ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			newCensorshipStub(t, tc.censor, "")
+
+			var saved string
+			h := &Handler{
+				Repo: &MockRepository{
+					SaveFunc: func(c repository.Comment) error {
+						saved = c.Status
+						return nil
+					},
+				},
+				CensorPolicy:   tc.policy,
+				AllowAnonymous: true,
+			}
+
+			reqBody := bytes.NewBufferString(`{"author":"John","text":"` + tc.text + `","news_id":1}`)
+			req, _ := http.NewRequest("POST", "/comments/add", reqBody)
+			rr := httptest.NewRecorder()
+			h.AddComment(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			if reason := rr.Header().Get("X-Censorship-Reason"); reason != tc.wantReason {
+				t.Errorf("expected reason %q, got %q", tc.wantReason, reason)
+			}
+			if saved != tc.wantSaved {
+				t.Errorf("expected saved status %q, got %q", tc.wantSaved, saved)
+			}
+		})
+	}
+}
+
+func TestAddCommentVerdict(t *testing.T) {
+	blocked := `{"decision":"blocked","matches":[{"rule":"qwerty","start":0,"end":6}],"severity":1,"masked":"q****y"}`
+
+	tt := []struct {
+		name       string
+		status     int
+		body       string
+		mask       bool
+		wantStatus int
+		wantSaved  repository.Comment
+	}{
+		{"needs review", http.StatusOK, `{"decision":"needs_review","severity":0.5}`, false, http.StatusCreated, repository.Comment{Text: "qwerty", Status: repository.StatusPendingReview}},
+		{"blocked", http.StatusBadRequest, blocked, false, http.StatusForbidden, repository.Comment{}},
+		{"blocked and masked", http.StatusBadRequest, blocked, true, http.StatusCreated, repository.Comment{Text: "q****y", Status: repository.StatusApproved}},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			newCensorshipStub(t, tc.status, tc.body)
+
+			var saved repository.Comment
+			h := &Handler{
+				Repo: &MockRepository{
+					SaveFunc: func(c repository.Comment) error {
+						saved = c
+						return nil
+					},
+				},
+				MaskBlocked:    tc.mask,
+				AllowAnonymous: true,
+			}
+
+			req, _ := http.NewRequest("POST", "/comments/add", bytes.NewBufferString(`{"author":"John","text":"qwerty","news_id":1}`))
+			rr := httptest.NewRecorder()
+			h.AddComment(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			if saved.Text != tc.wantSaved.Text || saved.Status != tc.wantSaved.Status {
+				t.Errorf("expected saved %q/%q, got %q/%q", tc.wantSaved.Text, tc.wantSaved.Status, saved.Text, saved.Status)
+			}
+			if tc.wantStatus == http.StatusForbidden && !strings.Contains(rr.Body.String(), `"rule":"qwerty"`) {
+				t.Errorf("expected matched rules in response, got %s", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestAddCommentSpam(t *testing.T) {
+	newCensorshipStub(t, http.StatusOK, "")
+
+	var saved []repository.Comment
+	h := &Handler{
+		Repo: &MockRepository{
+			SaveFunc: func(c repository.Comment) error {
+				saved = append(saved, c)
+				return nil
+			},
+		},
+		Spam:           spam.NewDetector(spam.DefaultConfig()),
+		AllowAnonymous: true,
+	}
+
+	post := func(text string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/comments/add", bytes.NewBufferString(`{"author":"John","text":"`+text+`","news_id":1}`))
+		rr := httptest.NewRecorder()
+		h.AddComment(rr, req)
+		return rr
+	}
+
+	if rr := post("hello"); rr.Code != http.StatusCreated {
+		t.Fatalf("expected first comment to be created, got %v", rr.Code)
+	}
+
+	// Дубль набирает 0.6 балла: комментарий сохраняется, но уходит на проверку.
+	rr := post("hello")
+	if rr.Code != http.StatusCreated || saved[1].Status != repository.StatusPendingReview {
+		t.Errorf("expected duplicate to be held for review, got %v and status %q", rr.Code, saved[1].Status)
+	}
+	if reason := rr.Header().Get("X-Censorship-Reason"); reason != ReasonSpamSuspected {
+		t.Errorf("expected reason %q, got %q", ReasonSpamSuspected, reason)
+	}
+
+	// Дубль со ссылками превышает порог блокировки.
+	links := "http://a.example http://b.example http://c.example"
+	post(links)
+	rr = post(links)
+	if rr.Code != http.StatusForbidden || !strings.Contains(rr.Body.String(), spam.ReasonDuplicate) {
+		t.Errorf("expected spam to be rejected with reasons, got %v: %s", rr.Code, rr.Body.String())
+	}
+}
+
+func TestEditComment(t *testing.T) {
+	tt := []struct {
+		name       string
+		user, role string
+		status     string
+		censor     int
+		wantStatus int
+		wantSaved  string
+	}{
+		{"author edits", "john", authz.Commenter, repository.StatusApproved, http.StatusOK, http.StatusOK, repository.StatusApproved},
+		{"author edits pending", "john", authz.Commenter, repository.StatusPendingReview, http.StatusOK, http.StatusOK, repository.StatusPendingReview},
+		{"forbidden text", "john", authz.Commenter, repository.StatusApproved, http.StatusBadRequest, http.StatusForbidden, ""},
+		{"other user", "ann", authz.Commenter, repository.StatusApproved, http.StatusOK, http.StatusForbidden, ""},
+		{"moderator edits other", "mod", authz.Moderator, repository.StatusApproved, http.StatusOK, http.StatusForbidden, ""},
+		{"hidden comment", "john", authz.Commenter, repository.StatusHidden, http.StatusOK, http.StatusConflict, ""},
+		{"anonymous", "", "", repository.StatusApproved, http.StatusOK, http.StatusUnauthorized, ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			newCensorshipStub(t, tc.censor, "")
+
+			var saved, editor string
+			h := &Handler{
+				Repo: &MockRepository{
+					GetCommentFunc: func(id int) (repository.Comment, error) {
+						return repository.Comment{ID: id, Author: "john", Owner: "john", Text: "Helo", Status: tc.status}, nil
+					},
+					UpdateCommentFunc: func(id int, text, status, e string) error {
+						saved, editor = status, e
+						return nil
+					},
+				},
+			}
+
+			req := asUser(httptest.NewRequest("PUT", "/comments/edit", bytes.NewBufferString(`{"id":1,"text":"Hello"}`)), tc.user, tc.role)
+			rr := httptest.NewRecorder()
+			h.EditComment(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			if saved != tc.wantSaved {
+				t.Errorf("expected status %q to be saved, got %q", tc.wantSaved, saved)
+			}
+			if saved != "" && editor != tc.user {
+				t.Errorf("expected editor %q, got %q", tc.user, editor)
+			}
+		})
+	}
+}
+
+func TestEditCommentSpam(t *testing.T) {
+	newCensorshipStub(t, http.StatusOK, "")
+
+	var saved string
+	h := &Handler{
+		Repo: &MockRepository{
+			GetCommentFunc: func(id int) (repository.Comment, error) {
+				return repository.Comment{ID: id, Author: "john", Owner: "john", Text: "Helo", NewsID: 1, Status: repository.StatusApproved}, nil
+			},
+			UpdateCommentFunc: func(id int, text, status, e string) error {
+				saved = status
+				return nil
+			},
+		},
+		Spam: spam.NewDetector(spam.DefaultConfig()),
+	}
+	edit := func(text string) *httptest.ResponseRecorder {
+		req := asUser(httptest.NewRequest("PUT", "/comments/edit", bytes.NewBufferString(`{"id":1,"text":"`+text+`"}`)), "john", authz.Commenter)
+		req.RemoteAddr = "192.0.2.1:1234"
+		rr := httptest.NewRecorder()
+		h.EditComment(rr, req)
+		return rr
+	}
+
+	// Правка в текст, уже опубликованный этим автором, уходит на проверку.
+	h.Spam.Score(spam.Submission{Author: "john", IP: "192.0.2.1", Text: "Hello", NewsID: 1})
+	if rr := edit("Hello"); rr.Code != http.StatusOK || saved != repository.StatusPendingReview {
+		t.Errorf("expected suspected edit to be held for review, got %v and status %q", rr.Code, saved)
+	}
+
+	links := "http://a.example http://b.example http://c.example"
+	h.Spam.Score(spam.Submission{Author: "john", IP: "192.0.2.1", Text: links, NewsID: 1})
+	saved = ""
+	if rr := edit(links); rr.Code != http.StatusForbidden || saved != "" {
+		t.Errorf("expected spam edit to be rejected, got %v and status %q", rr.Code, saved)
+	}
+}
+
+func TestCommentRevisions(t *testing.T) {
+	h := &Handler{
+		Repo: &MockRepository{
+			GetCommentFunc: func(id int) (repository.Comment, error) {
+				return repository.Comment{ID: id, Author: "john", Owner: "john"}, nil
+			},
+			GetRevisionsFunc: func(commentID int) ([]repository.Revision, error) {
+				return []repository.Revision{{CommentID: commentID, Text: "Helo", Editor: "john"}}, nil
+			},
+		},
+	}
+
+	for _, tc := range []struct {
+		user, role string
+		wantStatus int
+	}{
+		{"john", authz.Commenter, http.StatusOK},
+		{"mod", authz.Moderator, http.StatusOK},
+		{"ann", authz.Commenter, http.StatusForbidden},
+	} {
+		rr := httptest.NewRecorder()
+		h.CommentRevisions(rr, asUser(httptest.NewRequest("GET", "/comments/revisions?comment_id=1", nil), tc.user, tc.role))
+		if rr.Code != tc.wantStatus {
+			t.Errorf("%s: expected status %v, got %v", tc.user, tc.wantStatus, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Package handlers - ingest_handler.go
+package handlers
+
+import (
+	"APIGateway/authz"
+	"APIGateway/ingest"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// storedNews возвращает страницу статей из хранилища фонового опроса.
+func (h *Handler) storedNews(q ingest.Query) ([]News, *Pagination, error) {
+	articles, total, err := h.Articles.List(q)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	news := make([]News, len(articles))
+	for i, a := range articles {
+		news[i] = a.News()
+	}
+	pagination := &Pagination{
+		CurrentPage: q.Page,
+		TotalPages:  int(math.Ceil(float64(total) / float64(q.PageSize))),
+	}
+	return news, pagination, nil
+}
+
+// IngestStatus возвращает состояние опроса источников новостей.
+func (h *Handler) IngestStatus(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorize(w, r, authz.ManageIngestion); !ok {
+		return
+	}
+	if h.Ingest == nil {
+		respondWithError(w, r, http.StatusServiceUnavailable, "News ingestion is disabled")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, h.Ingest.Status())
+}
+
+// TriggerIngest запускает внеочередной опрос источника из параметра source
+// или всех источников, если параметр не задан.
+func (h *Handler) TriggerIngest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if _, ok := h.authorize(w, r, authz.ManageIngestion); !ok {
+		return
+	}
+	if h.Ingest == nil {
+		respondWithError(w, r, http.StatusServiceUnavailable, "News ingestion is disabled")
+		return
+	}
+
+	triggered, err := h.Ingest.Trigger(r.URL.Query().Get("source"))
+	var limited *ingest.RateLimitError
+	switch {
+	case errors.Is(err, ingest.ErrUnknownSource):
+		respondWithError(w, r, http.StatusNotFound, "Unknown news source")
+		return
+	case errors.As(err, &limited):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		respondWithError(w, r, http.StatusTooManyRequests, err.Error())
+		return
+	case err != nil:
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to trigger ingestion")
+		return
+	}
+	if triggered == nil {
+		triggered = []string{}
+	}
+
+	respondWithJSON(w, r, http.StatusAccepted, map[string]interface{}{"triggered": triggered})
+}
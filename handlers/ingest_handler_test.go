@@ -0,0 +1,84 @@
+// ingest_handler_test.go
+package handlers
+
+import (
+	"APIGateway/authz"
+	"APIGateway/database"
+	"APIGateway/ingest"
+	"APIGateway/response"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewsHandlerReadsStore(t *testing.T) {
+	store := ingest.NewMemoryStore()
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for i, title := range []string{"Old", "Middle", "New"} {
+		a, _ := ingest.Normalize("bbc", repository.News{Title: title, Published: base.Add(time.Duration(i) * time.Hour).Format(time.RFC3339)}, base)
+		store.Save([]ingest.Article{a})
+	}
+	h := &Handler{Articles: store}
+
+	// Источники не должны опрашиваться, когда статьи берутся из хранилища.
+	GetNews = func(string, string, int, int) ([]News, *Pagination, error) {
+		t.Fatal("unexpected upstream request")
+		return nil, nil, nil
+	}
+	defer func() { GetNews = GetNewsFromService }()
+
+	req := httptest.NewRequest("GET", "/news?page=1", nil)
+	req = req.WithContext(response.WithRequestID(req.Context(), "req-1"))
+	rr := httptest.NewRecorder()
+	h.NewsHandler(rr, req)
+
+	var resp NewsResponse
+	if err := response.Decode(rr.Body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.News) != 3 || resp.News[0].Title != "New" || resp.News[0].Source != "bbc" {
+		t.Errorf("unexpected news: %+v", resp.News)
+	}
+	if resp.Pagination == nil || resp.Pagination.TotalPages != 1 {
+		t.Errorf("unexpected pagination: %+v", resp.Pagination)
+	}
+}
+
+func TestIngestEndpoints(t *testing.T) {
+	fetcher := &stubFetcher{}
+	scheduler := ingest.NewScheduler(ingest.NewMemoryStore(), fetcher, ingest.Source{Name: "bbc", Interval: time.Hour})
+	h := &Handler{Ingest: scheduler}
+
+	tt := []struct {
+		name       string
+		handler    http.HandlerFunc
+		method     string
+		target     string
+		role       string
+		wantStatus int
+	}{
+		{"status for admin", h.IngestStatus, "GET", "/news/ingest/status", authz.Admin, http.StatusOK},
+		{"status for moderator", h.IngestStatus, "GET", "/news/ingest/status", authz.Moderator, http.StatusForbidden},
+		{"trigger source", h.TriggerIngest, "POST", "/news/ingest/trigger?source=bbc", authz.Admin, http.StatusAccepted},
+		{"trigger unknown source", h.TriggerIngest, "POST", "/news/ingest/trigger?source=cnn", authz.Admin, http.StatusNotFound},
+		{"trigger with GET", h.TriggerIngest, "GET", "/news/ingest/trigger", authz.Admin, http.StatusMethodNotAllowed},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tc.handler(rr, asUser(httptest.NewRequest(tc.method, tc.target, nil), "root", tc.role))
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+		})
+	}
+}
+
+type stubFetcher struct{}
+
+func (stubFetcher) Fetch(ctx context.Context, source ingest.Source) ([]repository.News, error) {
+	return nil, nil
+}
@@ -0,0 +1,347 @@
+// Package handlers - live_handler.go
+package handlers
+
+import (
+	"APIGateway/response"
+	"APIGateway/stream"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Темы живого канала.
+const (
+	// TopicLatestNews новые статьи агрегатора.
+	TopicLatestNews = "news:latest"
+	// topicCommentsPrefix префикс темы комментариев новости: comments:<news_id>.
+	topicCommentsPrefix = "comments:"
+)
+
+// Типы сообщений живого канала.
+const (
+	liveSubscribe    = "subscribe"
+	liveUnsubscribe  = "unsubscribe"
+	liveSubscribed   = "subscribed"
+	liveUnsubscribed = "unsubscribed"
+	liveComment      = "comment"
+	liveEvent        = "event"
+	liveError        = "error"
+)
+
+var (
+	// LiveMaxSubscriptions наибольшее число тем на одно соединение.
+	LiveMaxSubscriptions = 20
+	// LivePingPeriod интервал пингов. Клиент, не ответивший за два интервала, отключается.
+	LivePingPeriod = 30 * time.Second
+	// liveSendBuffer число исходящих сообщений, которое соединение держит для медленного клиента.
+	liveSendBuffer = 64
+	// liveWriteWait время на отправку одного сообщения.
+	liveWriteWait = 10 * time.Second
+	// liveMaxMessage наибольший размер входящего сообщения в байтах.
+	liveMaxMessage int64 = 64 << 10
+)
+
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+
+var (
+	errTooManySubscriptions = errors.New("too many subscriptions")
+	errUnknownTopic         = errors.New("unknown topic")
+	errTopicUnavailable     = errors.New("topic is not available")
+	errConnectionClosed     = errors.New("connection closed")
+)
+
+// LiveMessage сообщение живого канала. Клиент отправляет subscribe и unsubscribe
+// с темой и comment с комментарием в data; сервер отвечает subscribed, unsubscribed,
+// comment с HTTP-статусом публикации, error и присылает event с событием темы.
+// Ref клиента возвращается в ответе на его сообщение.
+type LiveMessage struct {
+	Type   string          `json:"type"`
+	Topic  string          `json:"topic,omitempty"`
+	Ref    string          `json:"ref,omitempty"`
+	Event  string          `json:"event,omitempty"`
+	Status int             `json:"status,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// Live открывает WebSocket-соединение живого канала. Клиент подписывается на темы
+// news:latest и comments:<news_id> и может публиковать комментарии с теми же
+// проверками, что и POST /comments/add.
+func (h *Handler) Live(w http.ResponseWriter, r *http.Request) {
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &liveConn{
+		h:    h,
+		r:    r,
+		ws:   ws,
+		send: make(chan LiveMessage, liveSendBuffer),
+		done: make(chan struct{}),
+		subs: make(map[string]interface{ Close() }),
+	}
+	go c.writeLoop()
+	c.readLoop()
+}
+
+// liveConn соединение живого канала.
+type liveConn struct {
+	h    *Handler
+	r    *http.Request
+	ws   *websocket.Conn
+	send chan LiveMessage
+	done chan struct{}
+	once sync.Once
+	// closeCode код закрытия, отправляемый клиенту.
+	closeCode int
+
+	mu   sync.Mutex
+	subs map[string]interface{ Close() }
+}
+
+// readLoop разбирает сообщения клиента, пока соединение открыто.
+// Сообщения обрабатываются по одному, поэтому клиент не может обогнать сервер.
+func (c *liveConn) readLoop() {
+	defer c.close(websocket.CloseNormalClosure)
+
+	c.ws.SetReadLimit(liveMaxMessage)
+	c.ws.SetReadDeadline(time.Now().Add(2 * LivePingPeriod))
+	c.ws.SetPongHandler(func(string) error {
+		return c.ws.SetReadDeadline(time.Now().Add(2 * LivePingPeriod))
+	})
+
+	for {
+		var m LiveMessage
+		if err := c.ws.ReadJSON(&m); err != nil {
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				c.enqueue(LiveMessage{Type: liveError, Error: "invalid message"})
+				continue
+			}
+			return
+		}
+
+		switch m.Type {
+		case liveSubscribe:
+			if err := c.subscribe(m.Topic); err != nil {
+				c.enqueue(LiveMessage{Type: liveError, Ref: m.Ref, Topic: m.Topic, Error: err.Error()})
+				continue
+			}
+			c.enqueue(LiveMessage{Type: liveSubscribed, Ref: m.Ref, Topic: m.Topic})
+		case liveUnsubscribe:
+			c.unsubscribe(m.Topic)
+			c.enqueue(LiveMessage{Type: liveUnsubscribed, Ref: m.Ref, Topic: m.Topic})
+		case liveComment:
+			c.postComment(m)
+		default:
+			c.enqueue(LiveMessage{Type: liveError, Ref: m.Ref, Error: "unknown message type"})
+		}
+	}
+}
+
+// writeLoop отправляет сообщения и пинги клиенту.
+func (c *liveConn) writeLoop() {
+	ping := time.NewTicker(LivePingPeriod)
+	defer func() {
+		ping.Stop()
+		c.ws.Close()
+	}()
+
+	for {
+		select {
+		case m := <-c.send:
+			c.ws.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if err := c.ws.WriteJSON(m); err != nil {
+				c.close(websocket.CloseAbnormalClosure)
+				return
+			}
+		case <-ping.C:
+			if err := c.ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteWait)); err != nil {
+				c.close(websocket.CloseAbnormalClosure)
+				return
+			}
+		case <-c.done:
+			message := websocket.FormatCloseMessage(c.closeCode, "")
+			c.ws.WriteControl(websocket.CloseMessage, message, time.Now().Add(liveWriteWait))
+			return
+		}
+	}
+}
+
+// enqueue ставит сообщение в очередь отправки. Клиент, переполнивший очередь,
+// отключается: сервер не копит для него сообщения.
+func (c *liveConn) enqueue(m LiveMessage) {
+	select {
+	case c.send <- m:
+	case <-c.done:
+	default:
+		c.close(websocket.CloseTryAgainLater)
+	}
+}
+
+// close закрывает соединение с кодом code и отменяет подписки. Повторный вызов безопасен.
+func (c *liveConn) close(code int) {
+	c.once.Do(func() {
+		c.closeCode = code
+		close(c.done)
+
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for topic, sub := range c.subs {
+			delete(c.subs, topic)
+			sub.Close()
+		}
+	})
+}
+
+// subscribe подписывает соединение на тему.
+func (c *liveConn) subscribe(topic string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Соединение могло закрыться, пока сообщение разбиралось.
+	select {
+	case <-c.done:
+		return errConnectionClosed
+	default:
+	}
+	if _, ok := c.subs[topic]; ok {
+		return nil
+	}
+	if len(c.subs) >= LiveMaxSubscriptions {
+		return errTooManySubscriptions
+	}
+
+	switch {
+	case topic == TopicLatestNews:
+		if c.h.News == nil {
+			return errTopicUnavailable
+		}
+		sub := c.h.News.Subscribe()
+		c.subs[topic] = sub
+		go c.forwardNews(topic, sub)
+	case strings.HasPrefix(topic, topicCommentsPrefix):
+		newsID, err := strconv.Atoi(strings.TrimPrefix(topic, topicCommentsPrefix))
+		if err != nil || newsID <= 0 {
+			return errUnknownTopic
+		}
+		if c.h.Events == nil {
+			return errTopicUnavailable
+		}
+		sub, _, _ := c.h.Events.Subscribe(newsID, 0)
+		c.subs[topic] = sub
+		go c.forwardComments(topic, sub)
+	default:
+		return errUnknownTopic
+	}
+	return nil
+}
+
+// unsubscribe отменяет подписку на тему.
+func (c *liveConn) unsubscribe(topic string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if sub, ok := c.subs[topic]; ok {
+		delete(c.subs, topic)
+		sub.Close()
+	}
+}
+
+// forwardComments пересылает клиенту события комментариев.
+func (c *liveConn) forwardComments(topic string, sub *stream.Subscription) {
+	for event := range sub.C {
+		data, err := json.Marshal(event.Comment)
+		if err != nil {
+			continue
+		}
+		c.enqueue(LiveMessage{Type: liveEvent, Topic: topic, Event: event.Type, Data: data})
+	}
+	c.ended(topic, sub)
+}
+
+// forwardNews пересылает клиенту новые статьи.
+func (c *liveConn) forwardNews(topic string, sub *stream.NewsSubscription) {
+	for news := range sub.C {
+		data, err := json.Marshal(news)
+		if err != nil {
+			continue
+		}
+		c.enqueue(LiveMessage{Type: liveEvent, Topic: topic, Event: "news", Data: data})
+	}
+	c.ended(topic, sub)
+}
+
+// ended вызывается, когда канал подписки закрыт. Если подписку не отменял сам
+// клиент, ее отключил хаб из-за отставания, и соединение закрывается.
+func (c *liveConn) ended(topic string, sub interface{ Close() }) {
+	c.mu.Lock()
+	current, ok := c.subs[topic]
+	c.mu.Unlock()
+	if ok && current == sub {
+		c.close(websocket.CloseTryAgainLater)
+	}
+}
+
+// postComment публикует комментарий через AddComment от имени пользователя соединения.
+func (c *liveConn) postComment(m LiveMessage) {
+	req, err := http.NewRequestWithContext(c.r.Context(), http.MethodPost, "/comments/add", bytes.NewReader(m.Data))
+	if err != nil {
+		c.enqueue(LiveMessage{Type: liveError, Ref: m.Ref, Error: err.Error()})
+		return
+	}
+	req.RemoteAddr = c.r.RemoteAddr
+	req.Header.Set("Content-Type", "application/json")
+	if authorization := c.r.Header.Get("Authorization"); authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	res := newResponseBuffer()
+	c.h.AddComment(res, req)
+
+	// Опубликованный комментарий передается без обертки ответа, ошибка — описанием
+	// в error и целиком в data.
+	reply := LiveMessage{Type: liveComment, Ref: m.Ref, Status: res.code}
+	body := bytes.TrimSpace(res.body.Bytes())
+	if res.code < http.StatusBadRequest {
+		var envelope struct {
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(body, &envelope); err == nil {
+			reply.Data = envelope.Data
+		}
+	} else {
+		var problem response.Problem
+		if err := json.Unmarshal(body, &problem); err == nil {
+			reply.Error, reply.Data = problem.Detail, body
+		} else {
+			reply.Error = string(body)
+		}
+	}
+	c.enqueue(reply)
+}
+
+// responseBuffer ResponseWriter, сохраняющий ответ в памяти.
+type responseBuffer struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: make(http.Header), code: http.StatusOK}
+}
+
+func (b *responseBuffer) Header() http.Header { return b.header }
+
+func (b *responseBuffer) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+func (b *responseBuffer) WriteHeader(code int) { b.code = code }
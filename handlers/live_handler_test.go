@@ -0,0 +1,114 @@
+// live_handler_test.go
+package handlers
+
+import (
+	"APIGateway/database"
+	"APIGateway/stream"
+	"github.com/gorilla/websocket"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dialLive открывает соединение живого канала к тестовому серверу.
+func dialLive(t *testing.T, h *Handler) *websocket.Conn {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(h.Live))
+	t.Cleanup(server.Close)
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	ws.SetReadDeadline(time.Now().Add(2 * time.Second))
+	return ws
+}
+
+// exchange отправляет сообщение и читает ответ.
+func exchange(t *testing.T, ws *websocket.Conn, m LiveMessage) LiveMessage {
+	t.Helper()
+	if err := ws.WriteJSON(m); err != nil {
+		t.Fatal(err)
+	}
+	var reply LiveMessage
+	if err := ws.ReadJSON(&reply); err != nil {
+		t.Fatal(err)
+	}
+	return reply
+}
+
+func TestLiveSubscriptions(t *testing.T) {
+	hub := stream.NewHub(stream.DefaultBuffer, 0)
+	feed := stream.NewNewsFeed(stream.DefaultBuffer, 0)
+	ws := dialLive(t, &Handler{Events: hub, News: feed})
+
+	if reply := exchange(t, ws, LiveMessage{Type: "subscribe", Topic: "comments:1", Ref: "1"}); reply.Type != "subscribed" || reply.Ref != "1" {
+		t.Fatalf("unexpected reply: %+v", reply)
+	}
+	if reply := exchange(t, ws, LiveMessage{Type: "subscribe", Topic: TopicLatestNews}); reply.Type != "subscribed" {
+		t.Fatalf("unexpected reply: %+v", reply)
+	}
+	if reply := exchange(t, ws, LiveMessage{Type: "subscribe", Topic: "weather"}); reply.Type != "error" {
+		t.Errorf("expected error for unknown topic, got %+v", reply)
+	}
+
+	hub.Publish(repository.CommentEvent{Type: repository.EventCreated, Comment: repository.Comment{ID: 5, NewsID: 1, Text: "Hi"}})
+	var event LiveMessage
+	if err := ws.ReadJSON(&event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Type != "event" || event.Topic != "comments:1" || event.Event != "created" || !strings.Contains(string(event.Data), `"Hi"`) {
+		t.Errorf("unexpected comment event: %+v", event)
+	}
+
+	feed.Publish(repository.News{Title: "Breaking"})
+	if err := ws.ReadJSON(&event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Topic != TopicLatestNews || !strings.Contains(string(event.Data), "Breaking") {
+		t.Errorf("unexpected news event: %+v", event)
+	}
+}
+
+func TestLiveMaxSubscriptions(t *testing.T) {
+	LiveMaxSubscriptions = 2
+	defer func() { LiveMaxSubscriptions = 20 }()
+	ws := dialLive(t, &Handler{Events: stream.NewHub(0, 0)})
+
+	for i, topic := range []string{"comments:1", "comments:2", "comments:3"} {
+		reply := exchange(t, ws, LiveMessage{Type: "subscribe", Topic: topic})
+		if want := i < 2; (reply.Type == "subscribed") != want {
+			t.Errorf("%s: unexpected reply %+v", topic, reply)
+		}
+	}
+}
+
+func TestLivePostComment(t *testing.T) {
+	newCensorshipStub(t, http.StatusOK, `{"allowed":true}`)
+	var saved repository.Comment
+	ws := dialLive(t, &Handler{
+		AllowAnonymous: true,
+		Repo: &MockRepository{
+			SaveFunc: func(c repository.Comment) error {
+				saved = c
+				return nil
+			},
+		},
+	})
+
+	reply := exchange(t, ws, LiveMessage{Type: "comment", Ref: "c1", Data: []byte(`{"author":"john","text":"Hello","news_id":1}`)})
+	if reply.Type != "comment" || reply.Ref != "c1" || reply.Status != http.StatusCreated {
+		t.Errorf("unexpected reply: %+v", reply)
+	}
+	if saved.Author != "john" || saved.Text != "Hello" {
+		t.Errorf("unexpected saved comment: %+v", saved)
+	}
+
+	reply = exchange(t, ws, LiveMessage{Type: "comment", Ref: "c2", Data: []byte(`{"text":5}`)})
+	if reply.Status != http.StatusUnprocessableEntity || reply.Error == "" {
+		t.Errorf("expected a validation error, got %+v", reply)
+	}
+}
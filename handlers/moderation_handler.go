@@ -0,0 +1,202 @@
+// Package handlers - moderation_handler.go
+package handlers
+
+import (
+	"APIGateway/authz"
+	"APIGateway/database"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// ModerationRequest действие над комментарием. Исполнитель определяется по токену.
+type ModerationRequest struct {
+	ID     int    `json:"id"`
+	Reason string `json:"reason"`
+}
+
+// PendingComments возвращает комментарии, ожидающие проверки модератором.
+func (h *Handler) PendingComments(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorize(w, r, authz.ReviewComments); !ok {
+		return
+	}
+
+	limit, offset, err := parseLimitOffset(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	comments, err := h.Repo.GetPendingComments(limit, offset)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to fetch pending comments")
+		return
+	}
+	if comments == nil {
+		comments = []repository.Comment{}
+	}
+
+	respondWithJSON(w, r, http.StatusOK, comments)
+}
+
+// ApproveComment одобряет комментарий, ожидающий проверки.
+func (h *Handler) ApproveComment(w http.ResponseWriter, r *http.Request) {
+	h.reviewComment(w, r, repository.StatusApproved)
+}
+
+// RejectComment отклоняет комментарий, ожидающий проверки. Причина обязательна.
+func (h *Handler) RejectComment(w http.ResponseWriter, r *http.Request) {
+	h.reviewComment(w, r, repository.StatusRejected)
+}
+
+// reviewComment применяет решение модератора и записывает его в журнал.
+func (h *Handler) reviewComment(w http.ResponseWriter, r *http.Request, status string) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, ok := h.authorize(w, r, authz.ReviewComments)
+	if !ok {
+		return
+	}
+
+	req, ok := decodeModerationRequest(w, r)
+	if !ok {
+		return
+	}
+	if status == repository.StatusRejected && req.Reason == "" {
+		respondWithError(w, r, http.StatusBadRequest, "'reason' is required to reject a comment")
+		return
+	}
+
+	err := h.Repo.SetCommentStatus(req.ID, status, claims.Username, req.Reason)
+	if errors.Is(err, repository.ErrNotPending) {
+		respondWithError(w, r, http.StatusConflict, "Comment is not pending review")
+		return
+	} else if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to update comment status")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, map[string]interface{}{"id": req.ID, "status": status})
+}
+
+// HideComment скрывает любой комментарий. Доступно модераторам.
+func (h *Handler) HideComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, ok := h.authorize(w, r, authz.HideAnyComment)
+	if !ok {
+		return
+	}
+
+	req, ok := decodeModerationRequest(w, r)
+	if !ok {
+		return
+	}
+	respondWithStatusChange(w, r, req.ID, repository.StatusHidden,
+		h.Repo.ChangeCommentStatus(req.ID, repository.StatusHidden, claims.Username, req.Reason))
+}
+
+// DeleteComment мягко удаляет комментарий. Автор может удалить свой комментарий,
+// модератор — любой. Удаленный комментарий с ответами остается в ветке заглушкой.
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, err := h.authenticate(r)
+	if err != nil {
+		respondWithAuthError(w, r, err)
+		return
+	}
+
+	req, ok := decodeModerationRequest(w, r)
+	if !ok {
+		return
+	}
+	comment, ok := h.findComment(w, r, req.ID)
+	if !ok {
+		return
+	}
+	if err := authz.AuthorizeOwned(claims, authz.DeleteAnyComment, authz.DeleteOwnComment, comment.Owner); err != nil {
+		respondWithAuthError(w, r, err)
+		return
+	}
+
+	respondWithStatusChange(w, r, req.ID, repository.StatusDeleted,
+		h.Repo.DeleteComment(req.ID, claims.Username, req.Reason))
+}
+
+// respondWithStatusChange отвечает на смену статуса комментария с ошибкой err.
+func respondWithStatusChange(w http.ResponseWriter, r *http.Request, id int, status string, err error) {
+	if errors.Is(err, repository.ErrCommentNotFound) {
+		respondWithError(w, r, http.StatusNotFound, "Comment not found")
+		return
+	} else if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to update comment status")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, map[string]interface{}{"id": id, "status": status})
+}
+
+// decodeModerationRequest читает действие над комментарием. При ошибке отвечает 400.
+func decodeModerationRequest(w http.ResponseWriter, r *http.Request) (ModerationRequest, bool) {
+	var req ModerationRequest
+	if err := decodeJSON(r.Body, &req); err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid moderation request")
+		return req, false
+	}
+	if req.ID <= 0 {
+		respondWithError(w, r, http.StatusBadRequest, "'id' is required")
+		return req, false
+	}
+	return req, true
+}
+
+// ModerationLog возвращает журнал модерации комментария.
+func (h *Handler) ModerationLog(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorize(w, r, authz.ReviewComments); !ok {
+		return
+	}
+
+	commentID, err := strconv.Atoi(r.URL.Query().Get("comment_id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "'comment_id' parameter is required")
+		return
+	}
+
+	entries, err := h.Repo.GetModerationLog(commentID)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to fetch moderation log")
+		return
+	}
+	if entries == nil {
+		entries = []repository.ModerationEntry{}
+	}
+
+	respondWithJSON(w, r, http.StatusOK, entries)
+}
+
+// parseLimitOffset читает параметры limit (по умолчанию 50, не больше 200) и offset.
+func parseLimitOffset(r *http.Request) (int, int, error) {
+	limit, offset := 50, 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 200 {
+			return 0, 0, errors.New("'limit' must be between 1 and 200")
+		}
+		limit = n
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return 0, 0, errors.New("'offset' must be a non-negative number")
+		}
+		offset = n
+	}
+	return limit, offset, nil
+}
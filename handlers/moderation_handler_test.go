@@ -0,0 +1,187 @@
+// moderation_handler_test.go
+package handlers
+
+import (
+	"APIGateway/auth"
+	"APIGateway/authz"
+	"APIGateway/database"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// asUser добавляет в запрос утверждения пользователя name с ролью role, как это делает шлюз.
+// Пустая роль оставляет запрос анонимным.
+func asUser(r *http.Request, name, role string) *http.Request {
+	if role == "" {
+		return r
+	}
+	return r.WithContext(auth.WithClaims(r.Context(), &auth.Claims{Subject: name, Username: name, Roles: []string{role}}))
+}
+
+func TestPendingComments(t *testing.T) {
+	var gotLimit, gotOffset int
+	h := &Handler{
+		Repo: &MockRepository{
+			GetPendingCommentsFunc: func(limit, offset int) ([]repository.Comment, error) {
+				gotLimit, gotOffset = limit, offset
+				return []repository.Comment{{ID: 1, Status: repository.StatusPendingReview}}, nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	h.PendingComments(rr, asUser(httptest.NewRequest("GET", "/moderation/pending?limit=10&offset=20", nil), "mod", authz.Moderator))
+	if rr.Code != http.StatusOK {
+		t.Errorf("expected status %v, got %v", http.StatusOK, rr.Code)
+	}
+	if gotLimit != 10 || gotOffset != 20 {
+		t.Errorf("expected limit 10 and offset 20, got %d and %d", gotLimit, gotOffset)
+	}
+
+	rr = httptest.NewRecorder()
+	h.PendingComments(rr, asUser(httptest.NewRequest("GET", "/moderation/pending?limit=1000", nil), "mod", authz.Moderator))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status %v for oversized limit, got %v", http.StatusBadRequest, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	h.PendingComments(rr, asUser(httptest.NewRequest("GET", "/moderation/pending", nil), "john", authz.Commenter))
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("expected status %v for commenter, got %v", http.StatusForbidden, rr.Code)
+	}
+}
+
+func TestReviewComment(t *testing.T) {
+	tt := []struct {
+		name       string
+		handler    func(h *Handler) http.HandlerFunc
+		role       string
+		body       string
+		repoErr    error
+		wantStatus int
+		wantSaved  string
+	}{
+		{"approve", func(h *Handler) http.HandlerFunc { return h.ApproveComment }, authz.Moderator, `{"id":1}`, nil, http.StatusOK, repository.StatusApproved},
+		{"reject", func(h *Handler) http.HandlerFunc { return h.RejectComment }, authz.Moderator, `{"id":1,"reason":"spam"}`, nil, http.StatusOK, repository.StatusRejected},
+		{"reject without reason", func(h *Handler) http.HandlerFunc { return h.RejectComment }, authz.Moderator, `{"id":1}`, nil, http.StatusBadRequest, ""},
+		{"missing id", func(h *Handler) http.HandlerFunc { return h.ApproveComment }, authz.Moderator, `{}`, nil, http.StatusBadRequest, ""},
+		{"not pending", func(h *Handler) http.HandlerFunc { return h.ApproveComment }, authz.Moderator, `{"id":1}`, repository.ErrNotPending, http.StatusConflict, repository.StatusApproved},
+		{"anonymous", func(h *Handler) http.HandlerFunc { return h.ApproveComment }, "", `{"id":1}`, nil, http.StatusUnauthorized, ""},
+		{"commenter", func(h *Handler) http.HandlerFunc { return h.ApproveComment }, authz.Commenter, `{"id":1}`, nil, http.StatusForbidden, ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var saved, moderator string
+			h := &Handler{
+				Repo: &MockRepository{
+					SetCommentStatusFunc: func(id int, status, m, reason string) error {
+						saved, moderator = status, m
+						return tc.repoErr
+					},
+				},
+			}
+
+			rr := httptest.NewRecorder()
+			req := asUser(httptest.NewRequest("POST", "/moderation", bytes.NewBufferString(tc.body)), "mod", tc.role)
+			tc.handler(h)(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			if saved != tc.wantSaved {
+				t.Errorf("expected status %q to be saved, got %q", tc.wantSaved, saved)
+			}
+			if saved != "" && moderator != "mod" {
+				t.Errorf("expected moderator from token, got %q", moderator)
+			}
+		})
+	}
+}
+
+func TestGetCommentsShowsOwnPending(t *testing.T) {
+	var viewer string
+	var public bool
+	h := &Handler{
+		Repo: &MockRepository{
+			GetVisibleCommentsFunc: func(nid int, v string) ([]repository.Comment, error) {
+				viewer = v
+				return nil, nil
+			},
+			GetCommentsByNewsIDFunc: func(nid int) ([]repository.Comment, error) {
+				public = true
+				return nil, nil
+			},
+		},
+		AllowAnonymous: true,
+	}
+
+	rr := httptest.NewRecorder()
+	h.GetComments(rr, asUser(httptest.NewRequest("GET", "/comments/get?news_id=1", nil), "John", authz.Commenter))
+	if rr.Code != http.StatusOK || viewer != "John" {
+		t.Errorf("expected visible comments for John, got status %v and viewer %q", rr.Code, viewer)
+	}
+
+	// Имя в параметре author не открывает чужие комментарии на проверке.
+	viewer = ""
+	rr = httptest.NewRecorder()
+	h.GetComments(rr, httptest.NewRequest("GET", "/comments/get?news_id=1&author=John", nil))
+	if rr.Code != http.StatusOK || viewer != "" || !public {
+		t.Errorf("expected only public comments for an anonymous caller, got status %v and viewer %q", rr.Code, viewer)
+	}
+}
+
+func TestHideAndDeleteComment(t *testing.T) {
+	tt := []struct {
+		name       string
+		handler    func(h *Handler) http.HandlerFunc
+		user, role string
+		wantStatus int
+		wantSaved  string
+	}{
+		{"moderator hides", func(h *Handler) http.HandlerFunc { return h.HideComment }, "mod", authz.Moderator, http.StatusOK, repository.StatusHidden},
+		{"author cannot hide", func(h *Handler) http.HandlerFunc { return h.HideComment }, "john", authz.Commenter, http.StatusForbidden, ""},
+		{"author deletes own", func(h *Handler) http.HandlerFunc { return h.DeleteComment }, "john", authz.Commenter, http.StatusOK, repository.StatusDeleted},
+		{"commenter deletes other", func(h *Handler) http.HandlerFunc { return h.DeleteComment }, "ann", authz.Commenter, http.StatusForbidden, ""},
+		{"reader deletes own", func(h *Handler) http.HandlerFunc { return h.DeleteComment }, "john", authz.Reader, http.StatusForbidden, ""},
+		{"moderator deletes any", func(h *Handler) http.HandlerFunc { return h.DeleteComment }, "mod", authz.Moderator, http.StatusOK, repository.StatusDeleted},
+		{"anonymous deletes", func(h *Handler) http.HandlerFunc { return h.DeleteComment }, "", "", http.StatusUnauthorized, ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var saved, actor string
+			h := &Handler{
+				Repo: &MockRepository{
+					GetCommentFunc: func(id int) (repository.Comment, error) {
+						return repository.Comment{ID: id, Author: "john", Owner: "john"}, nil
+					},
+					ChangeCommentStatusFunc: func(id int, status, a, reason string) error {
+						saved, actor = status, a
+						return nil
+					},
+					DeleteCommentFunc: func(id int, a, reason string) error {
+						saved, actor = repository.StatusDeleted, a
+						return nil
+					},
+				},
+			}
+
+			rr := httptest.NewRecorder()
+			req := asUser(httptest.NewRequest("POST", "/comments", bytes.NewBufferString(`{"id":1}`)), tc.user, tc.role)
+			tc.handler(h)(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			if saved != tc.wantSaved {
+				t.Errorf("expected status %q to be saved, got %q", tc.wantSaved, saved)
+			}
+			if saved != "" && actor != tc.user {
+				t.Errorf("expected actor %q, got %q", tc.user, actor)
+			}
+		})
+	}
+}
@@ -3,7 +3,16 @@ package handlers
 
 import (
 	"APIGateway/asyncrequests"
+	"APIGateway/auth"
+	"APIGateway/authz"
 	repository "APIGateway/database"
+	"APIGateway/idempotency"
+	"APIGateway/ingest"
+	"APIGateway/quota"
+	"APIGateway/response"
+	"APIGateway/search"
+	"APIGateway/spam"
+	"APIGateway/stream"
 	"encoding/json"
 	"fmt"
 	"github.com/sirupsen/logrus"
@@ -14,31 +23,55 @@ import (
 )
 
 const (
-	BBCAPI       = "https://www.bbc.co.uk/news/"
-	NYTAPI       = "https://developer.nytimes.com/apis"
-	requestIDKey = "requestID"
+	BBCAPI = "https://www.bbc.co.uk/news/"
+	NYTAPI = "https://developer.nytimes.com/apis"
 )
 
 type Handler struct {
-	Repo repository.RepositoryInterface
+	Repo         repository.RepositoryInterface
+	CensorPolicy CensorPolicy
+	// MaskBlocked сохраняет замаскированный текст вместо отказа в публикации.
+	MaskBlocked bool
+	// Spam оценивает комментарии на спам перед проверкой цензурой. nil отключает проверку.
+	Spam *spam.Detector
+	// Users хранилище пользователей для регистрации и входа.
+	Users repository.UserRepositoryInterface
+	// Tokens выпускает и проверяет токены доступа.
+	Tokens *auth.Issuer
+	// AllowAnonymous разрешает публиковать комментарии без входа.
+	AllowAnonymous bool
+	// ReportThreshold число жалоб, после которого комментарий скрывается до проверки.
+	// Ноль отключает скрытие.
+	ReportThreshold int
+	// Events рассылает изменения комментариев в потоки. nil отключает потоки.
+	Events *stream.Hub
+	// News рассылает новые статьи в живой канал. nil отключает тему news:latest.
+	News *stream.NewsFeed
+	// Articles хранилище статей, собранных фоновым опросом. nil означает
+	// запрос новостей у источников при каждом обращении к /news.
+	Articles ingest.Store
+	// Ingest планировщик опроса источников.
+	Ingest *ingest.Scheduler
+	// NewsIndex и CommentIndex полнотекстовые индексы статей и одобренных
+	// комментариев. nil отключает поиск по соответствующему типу.
+	NewsIndex    *search.Index
+	CommentIndex *search.Index
+	// Idempotency повторяет ответы на публикацию комментария с заголовком
+	// Idempotency-Key. nil отключает поддержку заголовка.
+	Idempotency *idempotency.Service
 }
 
-type News struct {
-	Title     string `json:"title"`
-	Author    string `json:"author"`
-	Published string `json:"published"`
-	Content   string `json:"content"`
-}
+type News = repository.News
 
-type Pagination struct {
-	CurrentPage int `json:"current_page"`
-	TotalPages  int `json:"total_pages"`
-}
+type Pagination = repository.Pagination
 
+// NewsResponse страница новостей. Идентификатор запроса передается в обертке ответа.
 type NewsResponse struct {
-	RequestID  string      `json:"requestId"`
 	News       []News      `json:"news"`
 	Pagination *Pagination `json:"pagination,omitempty"`
+	// Partial означает, что фильтр проверил не все новости, и пагинация
+	// описывает только проверенную часть.
+	Partial bool `json:"partial,omitempty"`
 }
 
 // makeGETRequest делает GET-запрос и возвращает тело ответа.
@@ -57,23 +90,19 @@ func makeGETRequest(url string) ([]byte, error) {
 	return body, nil
 }
 
-// respondWithError отправляет HTTP-ответ с кодом ошибки и сообщением.
-func respondWithError(w http.ResponseWriter, code int, message string) {
-	respondWithJSON(w, code, map[string]string{"error": message})
+// respondWithError отправляет ошибку в формате application/problem+json.
+func respondWithError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	response.Error(w, r, code, message)
 }
 
-func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
-	response, _ := json.Marshal(payload)
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	w.Write(response)
+// respondWithJSON отправляет данные в формате, согласованном с клиентом.
+func respondWithJSON(w http.ResponseWriter, r *http.Request, code int, payload interface{}) {
+	response.Write(w, r, code, payload)
 }
 
 var GetNews = GetNewsFromService
 
 func GetNewsFromService(serviceURL string, title string, page int, pageSize int) ([]News, *Pagination, error) {
-	client := &http.Client{}
-
 	URL, err := url.Parse(serviceURL)
 	if err != nil {
 		return nil, nil, err
@@ -91,7 +120,7 @@ func GetNewsFromService(serviceURL string, title string, page int, pageSize int)
 		return nil, nil, err
 	}
 
-	resp, err := client.Do(req)
+	resp, err := Upstream.Do(req)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -127,46 +156,44 @@ var log = logrus.New()
 
 // NewsHandler обрабатывает запросы и возвращает новости.
 func (h *Handler) NewsHandler(w http.ResponseWriter, r *http.Request) {
-	requestID, ok := r.Context().Value(requestIDKey).(string)
-	if !ok {
-		log.Error("requestID missing from context")
-		respondWithError(w, http.StatusBadRequest, "requestID missing from context")
-		return
-	}
-
 	searchQuery := r.URL.Query().Get("search")
-	page, err := strconv.Atoi(r.URL.Query().Get("page"))
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid or missing page number")
+	page, ok := newsPage(w, r)
+	if !ok {
 		return
 	}
 
 	pageSize := 10 // define the appropriate page size
 
-	serviceURLs := []string{BBCAPI, NYTAPI}
-
-	news, pagination, err := asyncrequests.ExecuteAsyncHTTPGets(serviceURLs, searchQuery, page, pageSize)
+	var news []News
+	var pagination *Pagination
+	var err error
+	if searchQuery != "" && h.NewsIndex != nil {
+		news, pagination = h.searchNews(searchQuery, page, pageSize)
+	} else if h.Articles != nil {
+		news, pagination, err = h.storedNews(ingest.Query{Search: searchQuery, Page: page, PageSize: pageSize})
+	} else {
+		serviceURLs := []string{BBCAPI, NYTAPI}
+		news, pagination, err = asyncrequests.ExecuteAsyncHTTPGets(GetNews, serviceURLs, searchQuery, page, pageSize)
+	}
 	if err != nil {
 		log.Error("Failed to get news from services", err)
-		respondWithError(w, http.StatusInternalServerError, "Failed to get news from services")
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to get news from services")
 		return
 	}
 
 	response := NewsResponse{
-		RequestID:  requestID,
 		News:       news,
 		Pagination: pagination,
 	}
 
-	h.sendJSONResponse(w, &response)
+	respondWithJSON(w, r, http.StatusOK, response)
 }
 
 // NewsDetailHandler обрабатывает запросы и возвращает детали новости.
 func (h *Handler) NewsDetailHandler(w http.ResponseWriter, r *http.Request) {
 	searchQuery := r.URL.Query().Get("search")
-	page, err := strconv.Atoi(r.URL.Query().Get("page"))
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid or missing page number")
+	page, ok := newsPage(w, r)
+	if !ok {
 		return
 	}
 
@@ -174,97 +201,63 @@ func (h *Handler) NewsDetailHandler(w http.ResponseWriter, r *http.Request) {
 
 	details, pagination, err := GetNewsFromService(BBCAPI, searchQuery, page, pageSize)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get news details"))
+		respondWithError(w, r, http.StatusInternalServerError, fmt.Sprintf("Failed to get news details"))
 		return
 	}
 
 	response := NewsResponse{
-		RequestID:  h.getContextRequestID(r),
 		News:       details,
 		Pagination: pagination,
 	}
 
-	h.sendJSONResponse(w, &response)
+	respondWithJSON(w, r, http.StatusOK, response)
 }
 
-func (h *Handler) NewsFilterHandler(w http.ResponseWriter, r *http.Request) {
-	searchQuery := r.URL.Query().Get("search")
+// newsPage разбирает номер страницы запросов новостей. При ошибке отвечает 400
+// и возвращает false.
+func newsPage(w http.ResponseWriter, r *http.Request) (int, bool) {
 	page, err := strconv.Atoi(r.URL.Query().Get("page"))
-	if err != nil {
-		respondWithError(w, http.StatusBadRequest, "Invalid or missing page number")
-		return
-	}
-
-	pageSize := 10 // define the appropriate page size
-
-	filterServiceURL := "https://news-filter-service.com"
-	filtered, pagination, err := GetNewsFromService(filterServiceURL, searchQuery, page, pageSize)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, fmt.Sprintf("Failed to get filtered news"))
-		return
-	}
-
-	response := NewsResponse{
-		RequestID:  h.getContextRequestID(r),
-		News:       filtered,
-		Pagination: pagination,
-	}
-
-	h.sendJSONResponse(w, &response)
-}
-
-// getContextRequestID извлекает и возвращает идентификатор запроса из контекста.
-func (h *Handler) getContextRequestID(r *http.Request) string {
-	requestID, ok := r.Context().Value(requestIDKey).(string)
-	if !ok {
-		return ""
+	if err != nil || page < 1 {
+		respondWithError(w, r, http.StatusBadRequest, "Invalid or missing page number")
+		return 0, false
 	}
-	return requestID
+	return page, true
 }
 
-// sendJSONResponse отправляет ответ в формате JSON.
-func (h *Handler) sendJSONResponse(w http.ResponseWriter, response *NewsResponse) {
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(response); err != nil {
-		http.Error(w, "failed to encode response", http.StatusInternalServerError)
-		return
-	}
-}
+// NewsAPI клиент NewsAPI с учетом квот, общий для всех обработчиков шлюза.
+// nil отключает /forward-news, если ключи NewsAPI не заданы.
+var NewsAPI *quota.Client
 
 func ForwardNewsRequest(w http.ResponseWriter, r *http.Request) {
-	resp, err := http.Get("https://newsapi.org/v2/top-headlines?country=us&apiKey=818fab7db99641809f117b29d2ffcfe8") // замените на URL вашего сервиса новостей.
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if NewsAPI == nil {
+		respondWithError(w, r, http.StatusServiceUnavailable, "NewsAPI is not configured")
 		return
 	}
-
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	body, cached, err := NewsAPI.Get("/top-headlines", url.Values{"country": {"us"}})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondWithError(w, r, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	if cached {
+		w.Header().Set("X-Cache", "HIT")
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(body)
 }
 
 func (h *Handler) ForwardNewsRequest(w http.ResponseWriter, r *http.Request) {
-	resp, err := http.Get("https://newsapi.org/v2/top-headlines?country=us&apiKey=818fab7db99641809f117b29d2ffcfe8") // Замените на URL вашего API новостей.
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	ForwardNewsRequest(w, r)
+}
+
+// QuotaStatusHandler возвращает оставшуюся квоту NewsAPI. Доступно администраторам.
+func (h *Handler) QuotaStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorize(w, r, authz.ViewQuota); !ok {
 		return
 	}
-
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if NewsAPI == nil {
+		respondWithError(w, r, http.StatusServiceUnavailable, "NewsAPI is not configured")
 		return
 	}
-
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(body)
+	NewsAPI.StatusHandler(w, r)
 }
@@ -0,0 +1,117 @@
+// Package handlers - reaction_handler.go
+package handlers
+
+import (
+	"APIGateway/authz"
+	"APIGateway/database"
+	"errors"
+	"net/http"
+)
+
+// VoteRequest голос за комментарий: 1 — за, -1 — против, 0 отменяет голос.
+type VoteRequest struct {
+	ID    int `json:"id"`
+	Value int `json:"value"`
+}
+
+// VoteResponse счетчики голосов комментария после голосования.
+type VoteResponse struct {
+	ID        int `json:"id"`
+	Upvotes   int `json:"upvotes"`
+	Downvotes int `json:"downvotes"`
+}
+
+// ReactionRequest реакция на комментарий.
+type ReactionRequest struct {
+	ID    int    `json:"id"`
+	Emoji string `json:"emoji"`
+}
+
+// VoteComment обрабатывает HTTP POST запросы и записывает голос пользователя.
+// У пользователя один голос на комментарий, повторный голос заменяет прежний.
+func (h *Handler) VoteComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, ok := h.authorize(w, r, authz.ReactToComments)
+	if !ok {
+		return
+	}
+
+	var req VoteRequest
+	if err := decodeJSON(r.Body, &req); err != nil || req.ID <= 0 {
+		respondWithError(w, r, http.StatusBadRequest, "'id' is required")
+		return
+	}
+
+	stats, err := h.Repo.Vote(req.ID, claims.Subject, req.Value)
+	if errors.Is(err, repository.ErrInvalidVote) {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	} else if errors.Is(err, repository.ErrCommentNotFound) {
+		respondWithError(w, r, http.StatusNotFound, "Comment not found")
+		return
+	} else if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to save vote")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, VoteResponse{ID: req.ID, Upvotes: stats.Upvotes, Downvotes: stats.Downvotes})
+}
+
+// ReactToComment добавляет реакцию на комментарий (POST) или снимает ее (DELETE).
+func (h *Handler) ReactToComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost && r.Method != http.MethodDelete {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, ok := h.authorize(w, r, authz.ReactToComments)
+	if !ok {
+		return
+	}
+
+	var req ReactionRequest
+	if err := decodeJSON(r.Body, &req); err != nil || req.ID <= 0 {
+		respondWithError(w, r, http.StatusBadRequest, "'id' and 'emoji' are required")
+		return
+	}
+	if !repository.IsAllowedReaction(req.Emoji) {
+		respondWithError(w, r, http.StatusBadRequest, "Unsupported reaction")
+		return
+	}
+
+	add := r.Method == http.MethodPost
+	err := h.Repo.React(req.ID, claims.Subject, req.Emoji, add)
+	if errors.Is(err, repository.ErrCommentNotFound) {
+		respondWithError(w, r, http.StatusNotFound, "Comment not found")
+		return
+	} else if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to save reaction")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusOK, map[string]interface{}{"id": req.ID, "emoji": req.Emoji, "active": add})
+}
+
+// attachStats добавляет к комментариям голоса и реакции.
+func (h *Handler) attachStats(comments []repository.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	ids := make([]int, len(comments))
+	for i, c := range comments {
+		ids[i] = c.ID
+	}
+
+	stats, err := h.Repo.GetCommentStats(ids)
+	if err != nil {
+		return err
+	}
+	for i := range comments {
+		s := stats[comments[i].ID]
+		comments[i].Upvotes, comments[i].Downvotes = s.Upvotes, s.Downvotes
+		comments[i].Reactions = s.Reactions
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+// reaction_handler_test.go
+package handlers
+
+import (
+	"APIGateway/authz"
+	"APIGateway/database"
+	"APIGateway/response"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestVoteComment(t *testing.T) {
+	tt := []struct {
+		name       string
+		role       string
+		body       string
+		wantStatus int
+	}{
+		{"commenter upvotes", authz.Commenter, `{"id":1,"value":1}`, http.StatusOK},
+		{"invalid value", authz.Commenter, `{"id":1,"value":5}`, http.StatusBadRequest},
+		{"missing comment", authz.Commenter, `{"id":2,"value":-1}`, http.StatusNotFound},
+		{"reader cannot vote", authz.Reader, `{"id":1,"value":1}`, http.StatusForbidden},
+		{"anonymous", "", `{"id":1,"value":1}`, http.StatusUnauthorized},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var voter string
+			h := &Handler{
+				Repo: &MockRepository{
+					VoteFunc: func(id int, v string, value int) (repository.CommentStats, error) {
+						if value < -1 || value > 1 {
+							return repository.CommentStats{}, repository.ErrInvalidVote
+						}
+						if id != 1 {
+							return repository.CommentStats{}, repository.ErrCommentNotFound
+						}
+						voter = v
+						return repository.CommentStats{Upvotes: 1}, nil
+					},
+				},
+			}
+
+			rr := httptest.NewRecorder()
+			req := asUser(httptest.NewRequest("POST", "/comments/vote", bytes.NewBufferString(tc.body)), "john", tc.role)
+			h.VoteComment(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			if tc.wantStatus == http.StatusOK && voter != "john" {
+				t.Errorf("expected vote from john, got %q", voter)
+			}
+		})
+	}
+}
+
+func TestReactToComment(t *testing.T) {
+	tt := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+		wantAdd    bool
+	}{
+		{"add reaction", "POST", `{"id":1,"emoji":"👍"}`, http.StatusOK, true},
+		{"remove reaction", "DELETE", `{"id":1,"emoji":"👍"}`, http.StatusOK, false},
+		{"unsupported emoji", "POST", `{"id":1,"emoji":"🍕"}`, http.StatusBadRequest, false},
+		{"wrong method", "GET", `{"id":1,"emoji":"👍"}`, http.StatusMethodNotAllowed, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var added bool
+			h := &Handler{
+				Repo: &MockRepository{
+					ReactFunc: func(id int, user, emoji string, add bool) error {
+						added = add
+						return nil
+					},
+				},
+			}
+
+			rr := httptest.NewRecorder()
+			req := asUser(httptest.NewRequest(tc.method, "/comments/react", bytes.NewBufferString(tc.body)), "john", authz.Commenter)
+			h.ReactToComment(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			if added != tc.wantAdd {
+				t.Errorf("expected add=%v, got %v", tc.wantAdd, added)
+			}
+		})
+	}
+}
+
+func TestGetCommentsSorted(t *testing.T) {
+	h := &Handler{
+		Repo: &MockRepository{
+			GetCommentsByNewsIDFunc: func(nid int) ([]repository.Comment, error) {
+				return []repository.Comment{{ID: 1}, {ID: 2}}, nil
+			},
+			GetCommentStatsFunc: func(ids []int) (map[int]repository.CommentStats, error) {
+				return map[int]repository.CommentStats{
+					1: {Upvotes: 1},
+					2: {Upvotes: 10, Downvotes: 1, Reactions: map[string]int{"👍": 3}},
+				}, nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	h.GetComments(rr, httptest.NewRequest("GET", "/comments/get?news_id=1&sort=best", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected status 200, got %v", rr.Code)
+	}
+	var comments []repository.Comment
+	if err := response.Decode(rr.Body, &comments); err != nil {
+		t.Fatal(err)
+	}
+	if len(comments) != 2 || comments[0].ID != 2 || comments[0].Reactions["👍"] != 3 {
+		t.Errorf("unexpected comments: %+v", comments)
+	}
+
+	rr = httptest.NewRecorder()
+	h.GetComments(rr, httptest.NewRequest("GET", "/comments/get?news_id=1&sort=random", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("expected status 400 for unknown sort, got %v", rr.Code)
+	}
+}
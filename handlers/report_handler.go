@@ -0,0 +1,103 @@
+// Package handlers - report_handler.go
+package handlers
+
+import (
+	"APIGateway/authz"
+	"APIGateway/database"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxReportDetails наибольшая длина пояснения к жалобе в символах.
+const maxReportDetails = 1000
+
+// ReportRequest жалоба на комментарий. Для причины other пояснение обязательно.
+type ReportRequest struct {
+	ID      int    `json:"id"`
+	Reason  string `json:"reason"`
+	Details string `json:"details"`
+}
+
+// ReportComment обрабатывает HTTP POST запросы и принимает жалобу на комментарий.
+// От пользователя принимается одна жалоба на комментарий.
+func (h *Handler) ReportComment(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	claims, ok := h.authorize(w, r, authz.ReportComments)
+	if !ok {
+		return
+	}
+	// Жаловаться может и читатель, но только вошедший: одна жалоба на пользователя.
+	if claims == nil {
+		respondWithAuthError(w, r, authz.ErrUnauthenticated)
+		return
+	}
+
+	var req ReportRequest
+	if err := decodeJSON(r.Body, &req); err != nil || req.ID <= 0 {
+		respondWithError(w, r, http.StatusBadRequest, "'id' and 'reason' are required")
+		return
+	}
+	req.Details = strings.TrimSpace(req.Details)
+	if !repository.IsReportReason(req.Reason) {
+		respondWithError(w, r, http.StatusBadRequest, "'reason' must be one of "+strings.Join(repository.ReportReasons, ", "))
+		return
+	}
+	if req.Reason == repository.ReportOther && req.Details == "" {
+		respondWithError(w, r, http.StatusBadRequest, "'details' are required for reason 'other'")
+		return
+	}
+	if utf8.RuneCountInString(req.Details) > maxReportDetails {
+		respondWithError(w, r, http.StatusBadRequest, "'details' are too long")
+		return
+	}
+
+	result, err := h.Repo.ReportComment(repository.Report{
+		CommentID: req.ID,
+		Reporter:  claims.Subject,
+		Reason:    req.Reason,
+		Details:   req.Details,
+		CreatedAt: time.Now(),
+	}, h.ReportThreshold)
+	if errors.Is(err, repository.ErrAlreadyReported) {
+		respondWithError(w, r, http.StatusConflict, "Comment already reported")
+		return
+	} else if errors.Is(err, repository.ErrCommentNotFound) {
+		respondWithError(w, r, http.StatusNotFound, "Comment not found")
+		return
+	} else if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to save report")
+		return
+	}
+
+	respondWithJSON(w, r, http.StatusCreated, result)
+}
+
+// ReportedComments возвращает комментарии всех новостей по убыванию числа жалоб.
+func (h *Handler) ReportedComments(w http.ResponseWriter, r *http.Request) {
+	if _, ok := h.authorize(w, r, authz.ReviewComments); !ok {
+		return
+	}
+
+	limit, offset, err := parseLimitOffset(r)
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	reported, err := h.Repo.GetReportedComments(limit, offset)
+	if err != nil {
+		respondWithError(w, r, http.StatusInternalServerError, "Failed to fetch reported comments")
+		return
+	}
+	if reported == nil {
+		reported = []repository.ReportedComment{}
+	}
+
+	respondWithJSON(w, r, http.StatusOK, reported)
+}
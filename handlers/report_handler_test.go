@@ -0,0 +1,118 @@
+// report_handler_test.go
+package handlers
+
+import (
+	"APIGateway/authz"
+	"APIGateway/database"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReportComment(t *testing.T) {
+	tt := []struct {
+		name       string
+		role       string
+		body       string
+		wantStatus int
+	}{
+		{"reader reports", authz.Reader, `{"id":1,"reason":"spam"}`, http.StatusCreated},
+		{"unknown reason", authz.Reader, `{"id":1,"reason":"boring"}`, http.StatusBadRequest},
+		{"other without details", authz.Reader, `{"id":1,"reason":"other"}`, http.StatusBadRequest},
+		{"other with details", authz.Reader, `{"id":1,"reason":"other","details":"doxxing"}`, http.StatusCreated},
+		{"duplicate", authz.Reader, `{"id":2,"reason":"abuse"}`, http.StatusConflict},
+		{"anonymous", "", `{"id":1,"reason":"spam"}`, http.StatusUnauthorized},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var saved repository.Report
+			var threshold int
+			h := &Handler{
+				ReportThreshold: 3,
+				Repo: &MockRepository{
+					ReportCommentFunc: func(report repository.Report, th int) (repository.ReportResult, error) {
+						if report.CommentID == 2 {
+							return repository.ReportResult{}, repository.ErrAlreadyReported
+						}
+						saved, threshold = report, th
+						return repository.ReportResult{Reports: 1}, nil
+					},
+				},
+			}
+
+			rr := httptest.NewRecorder()
+			req := asUser(httptest.NewRequest("POST", "/comments/report", bytes.NewBufferString(tc.body)), "ann", tc.role)
+			h.ReportComment(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			if tc.wantStatus == http.StatusCreated && (saved.Reporter != "ann" || threshold != 3) {
+				t.Errorf("unexpected report %+v with threshold %d", saved, threshold)
+			}
+		})
+	}
+}
+
+func TestReportHiddenCommentStaysHiddenAfterEdit(t *testing.T) {
+	newCensorshipStub(t, http.StatusOK, "")
+
+	// Репозиторий хранит один комментарий и скрывает его на пороге жалоб, как это делает база.
+	comment := repository.Comment{ID: 1, Author: "john", Owner: "john", Text: "Helo", NewsID: 1, Status: repository.StatusApproved}
+	reports := 0
+	h := &Handler{
+		ReportThreshold: 1,
+		Repo: &MockRepository{
+			ReportCommentFunc: func(report repository.Report, threshold int) (repository.ReportResult, error) {
+				reports++
+				hidden := reports == threshold
+				if hidden {
+					comment.Status = repository.StatusPendingReview
+				}
+				return repository.ReportResult{Reports: reports, Hidden: hidden}, nil
+			},
+			GetCommentFunc: func(id int) (repository.Comment, error) {
+				return comment, nil
+			},
+			UpdateCommentFunc: func(id int, text, status, editor string) error {
+				comment.Text, comment.Status = text, status
+				return nil
+			},
+		},
+	}
+
+	rr := httptest.NewRecorder()
+	h.ReportComment(rr, asUser(httptest.NewRequest("POST", "/comments/report", bytes.NewBufferString(`{"id":1,"reason":"abuse"}`)), "ann", authz.Reader))
+	if rr.Code != http.StatusCreated || comment.Status != repository.StatusPendingReview {
+		t.Fatalf("expected report to hide the comment, got %v and status %q", rr.Code, comment.Status)
+	}
+
+	rr = httptest.NewRecorder()
+	h.EditComment(rr, asUser(httptest.NewRequest("PUT", "/comments/edit", bytes.NewBufferString(`{"id":1,"text":"Hello"}`)), "john", authz.Commenter))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected edit to succeed, got %v", rr.Code)
+	}
+	if comment.Text != "Hello" || comment.Status != repository.StatusPendingReview {
+		t.Errorf("expected edited comment to stay hidden for review, got %q with status %q", comment.Text, comment.Status)
+	}
+}
+
+func TestReportedComments(t *testing.T) {
+	h := &Handler{
+		Repo: &MockRepository{
+			GetReportedCommentsFunc: func(limit, offset int) ([]repository.ReportedComment, error) {
+				return []repository.ReportedComment{{Comment: repository.Comment{ID: 1}, Reports: 3}}, nil
+			},
+		},
+	}
+
+	for role, want := range map[string]int{authz.Moderator: http.StatusOK, authz.Commenter: http.StatusForbidden} {
+		rr := httptest.NewRecorder()
+		h.ReportedComments(rr, asUser(httptest.NewRequest("GET", "/moderation/reported", nil), "mod", role))
+		if rr.Code != want {
+			t.Errorf("%s: expected status %v, got %v", role, want, rr.Code)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package handlers - search_handler.go
+package handlers
+
+import (
+	"APIGateway/search"
+	"math"
+	"net/http"
+	"strconv"
+)
+
+// SearchResponse результаты полнотекстового поиска.
+type SearchResponse struct {
+	Query      string       `json:"query"`
+	Type       string       `json:"type"`
+	Total      int          `json:"total"`
+	Results    []search.Hit `json:"results"`
+	Pagination *Pagination  `json:"pagination"`
+}
+
+// Search обрабатывает HTTP GET запросы полнотекстового поиска по статьям
+// (type=news, по умолчанию) или комментариям (type=comments). Слова в кавычках
+// ищутся как фраза.
+func (h *Handler) Search(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		respondWithError(w, r, http.StatusBadRequest, "'q' parameter is required")
+		return
+	}
+	page := 1
+	if p := r.URL.Query().Get("page"); p != "" {
+		var err error
+		if page, err = strconv.Atoi(p); err != nil || page <= 0 {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid page number")
+			return
+		}
+	}
+
+	typ := r.URL.Query().Get("type")
+	var index *search.Index
+	switch typ {
+	case "", search.TypeNews:
+		typ, index = search.TypeNews, h.NewsIndex
+	case search.TypeComments:
+		index = h.CommentIndex
+	default:
+		respondWithError(w, r, http.StatusBadRequest, "'type' must be news or comments")
+		return
+	}
+	if index == nil {
+		respondWithError(w, r, http.StatusServiceUnavailable, "Search is disabled")
+		return
+	}
+
+	pageSize := 10 // define the appropriate page size
+
+	hits, total := index.Search(q, pageSize, (page-1)*pageSize)
+	if hits == nil {
+		hits = []search.Hit{}
+	}
+	respondWithJSON(w, r, http.StatusOK, SearchResponse{
+		Query:      q,
+		Type:       typ,
+		Total:      total,
+		Results:    hits,
+		Pagination: searchPagination(page, pageSize, total),
+	})
+}
+
+// searchNews возвращает страницу статей из индекса в порядке релевантности.
+func (h *Handler) searchNews(q string, page, pageSize int) ([]News, *Pagination) {
+	hits, total := h.NewsIndex.Search(q, pageSize, (page-1)*pageSize)
+	news := make([]News, 0, len(hits))
+	for _, hit := range hits {
+		if n, ok := hit.Item.(News); ok {
+			news = append(news, n)
+		}
+	}
+	return news, searchPagination(page, pageSize, total)
+}
+
+func searchPagination(page, pageSize, total int) *Pagination {
+	return &Pagination{
+		CurrentPage: page,
+		TotalPages:  int(math.Ceil(float64(total) / float64(pageSize))),
+	}
+}
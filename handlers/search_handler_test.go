@@ -0,0 +1,95 @@
+// search_handler_test.go
+package handlers
+
+import (
+	"APIGateway/database"
+	"APIGateway/ingest"
+	"APIGateway/response"
+	"APIGateway/search"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSearch(t *testing.T) {
+	news := search.NewIndex()
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	for _, n := range []repository.News{
+		{Title: "Elections results", Content: "Parliament elected a new speaker."},
+		{Title: "Weather", Content: "Sunny, elections will not be affected."},
+	} {
+		a, _ := ingest.Normalize("bbc", n, base)
+		news.AddArticles([]ingest.Article{a})
+	}
+	comments := search.NewIndex()
+	search.CommentIndexer{Index: comments}.Publish(repository.CommentEvent{
+		Type:    repository.EventCreated,
+		Comment: repository.Comment{ID: 5, NewsID: 1, Author: "alice", Text: "Хорошие новости", Status: repository.StatusApproved},
+	})
+	h := &Handler{NewsIndex: news, CommentIndex: comments}
+
+	tt := []struct {
+		name       string
+		handler    *Handler
+		target     string
+		wantStatus int
+		wantTotal  int
+	}{
+		{"news by default", h, "/search?q=election", http.StatusOK, 2},
+		{"news phrase", h, "/search?q=%22new+speaker%22&type=news", http.StatusOK, 1},
+		{"comments", h, "/search?q=новость&type=comments", http.StatusOK, 1},
+		{"nothing found", h, "/search?q=football", http.StatusOK, 0},
+		{"missing query", h, "/search", http.StatusBadRequest, 0},
+		{"unknown type", h, "/search?q=x&type=users", http.StatusBadRequest, 0},
+		{"invalid page", h, "/search?q=x&page=0", http.StatusBadRequest, 0},
+		{"disabled index", &Handler{}, "/search?q=x", http.StatusServiceUnavailable, 0},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			tc.handler.Search(rr, httptest.NewRequest("GET", tc.target, nil))
+			if rr.Code != tc.wantStatus {
+				t.Fatalf("expected status %v, got %v: %s", tc.wantStatus, rr.Code, rr.Body)
+			}
+			if rr.Code != http.StatusOK {
+				return
+			}
+
+			var resp SearchResponse
+			if err := response.Decode(rr.Body, &resp); err != nil {
+				t.Fatal(err)
+			}
+			if resp.Total != tc.wantTotal || len(resp.Results) != tc.wantTotal {
+				t.Errorf("expected %d results, got %+v", tc.wantTotal, resp)
+			}
+		})
+	}
+}
+
+func TestNewsHandlerUsesSearchIndex(t *testing.T) {
+	index := search.NewIndex()
+	a, _ := ingest.Normalize("bbc", repository.News{Title: "Running marathon", Published: "2024-05-01"}, time.Now())
+	index.AddArticles([]ingest.Article{a})
+	h := &Handler{NewsIndex: index}
+
+	GetNews = func(string, string, int, int) ([]News, *Pagination, error) {
+		t.Fatal("unexpected upstream request")
+		return nil, nil, nil
+	}
+	defer func() { GetNews = GetNewsFromService }()
+
+	req := httptest.NewRequest("GET", "/news?page=1&search=runs", nil)
+	req = req.WithContext(response.WithRequestID(req.Context(), "req-1"))
+	rr := httptest.NewRecorder()
+	h.NewsHandler(rr, req)
+
+	var resp NewsResponse
+	if err := response.Decode(rr.Body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.News) != 1 || resp.News[0].Title != "Running marathon" {
+		t.Errorf("unexpected news: %+v", resp.News)
+	}
+}
@@ -0,0 +1,59 @@
+// Package handlers - spam_check.go
+package handlers
+
+import (
+	"APIGateway/database"
+	"APIGateway/spam"
+	"net"
+	"net/http"
+)
+
+// Пороги оценки спама: с SpamBlockScore комментарий отклоняется,
+// с SpamReviewScore отправляется на проверку модератору.
+const (
+	SpamBlockScore  = 0.8
+	SpamReviewScore = 0.5
+)
+
+// Причины решений по спаму.
+const (
+	ReasonSpamDetected  = "spam_detected"
+	ReasonSpamSuspected = "spam_suspected"
+)
+
+// moderateComment прогоняет комментарий через конвейер проверок: оценку спама
+// и цензуру. Явный спам отклоняется без обращения к сервису цензуры.
+func (h *Handler) moderateComment(comment *repository.Comment, ip string) CensorDecision {
+	var result *spam.Result
+	if h.Spam != nil {
+		res := h.Spam.Score(spam.Submission{
+			Author: comment.Author,
+			IP:     ip,
+			Text:   comment.Text,
+			NewsID: comment.NewsID,
+		})
+		result = &res
+
+		if res.Score >= SpamBlockScore {
+			censorDecisions.Add("spam/"+ReasonSpamDetected, 1)
+			return CensorDecision{Status: repository.StatusRejected, Reason: ReasonSpamDetected, Spam: result}
+		}
+	}
+
+	decision := h.decideCensorship(comment)
+	decision.Spam = result
+	if decision.Allowed && decision.Status == repository.StatusApproved && result != nil && result.Score >= SpamReviewScore {
+		decision.Status, decision.Reason = repository.StatusPendingReview, ReasonSpamSuspected
+		censorDecisions.Add("spam/"+ReasonSpamSuspected, 1)
+	}
+	return decision
+}
+
+// clientIP возвращает IP-адрес клиента без порта.
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
@@ -0,0 +1,106 @@
+// Package handlers - stream_handler.go
+package handlers
+
+import (
+	"APIGateway/stream"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// StreamHeartbeat интервал комментариев-пульсов, не дающих прокси закрыть простаивающий поток.
+var StreamHeartbeat = 15 * time.Second
+
+// streamRetry задержка переподключения клиента в миллисекундах.
+const streamRetry = 3000
+
+// StreamComments отдает события комментариев новости по протоколу Server-Sent Events:
+// created и updated с комментарием, deleted с его идентификаторами. Клиент
+// продолжает поток с заголовка Last-Event-ID или параметра last_event_id; если
+// пропущенные события уже недоступны, первым приходит событие reset.
+func (h *Handler) StreamComments(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		respondWithError(w, r, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	}
+	if h.Events == nil {
+		respondWithError(w, r, http.StatusServiceUnavailable, "Comment streaming is disabled")
+		return
+	}
+	newsID, err := strconv.Atoi(r.URL.Query().Get("news_id"))
+	if err != nil {
+		respondWithError(w, r, http.StatusBadRequest, "'news_id' parameter is required")
+		return
+	}
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("last_event_id")
+	}
+	var lastID uint64
+	if lastEventID != "" {
+		if lastID, err = strconv.ParseUint(lastEventID, 10, 64); err != nil {
+			respondWithError(w, r, http.StatusBadRequest, "Invalid Last-Event-ID")
+			return
+		}
+	}
+
+	sub, missed, complete := h.Events.Subscribe(newsID, lastID)
+	defer sub.Close()
+
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", streamRetry)
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, event := range missed {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(StreamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			// Закрытый канал означает, что клиент отстал: он переподключится с Last-Event-ID.
+			if !ok {
+				return
+			}
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent записывает событие в формате Server-Sent Events.
+func writeEvent(w http.ResponseWriter, event stream.Event) error {
+	data, err := json.Marshal(event.Comment)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", event.ID, event.Type, data)
+	return err
+}
@@ -0,0 +1,106 @@
+// stream_handler_test.go
+package handlers
+
+import (
+	"APIGateway/database"
+	"APIGateway/stream"
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readEvent читает из потока строки одного события до пустой строки.
+func readEvent(t *testing.T, r *bufio.Reader) []string {
+	t.Helper()
+	var lines []string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("failed to read stream: %s", err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			return lines
+		}
+		lines = append(lines, line)
+	}
+}
+
+func TestStreamComments(t *testing.T) {
+	hub := stream.NewHub(stream.DefaultBuffer, stream.DefaultHistory)
+	hub.Publish(repository.CommentEvent{Type: repository.EventCreated, Comment: repository.Comment{ID: 1, NewsID: 1, Text: "missed"}})
+
+	h := &Handler{Events: hub}
+	server := httptest.NewServer(http.HandlerFunc(h.StreamComments))
+	defer server.Close()
+
+	StreamHeartbeat = 50 * time.Millisecond
+	defer func() { StreamHeartbeat = 15 * time.Second }()
+
+	req, _ := http.NewRequest("GET", server.URL+"?news_id=1", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+
+	body := bufio.NewReader(resp.Body)
+	if lines := readEvent(t, body); len(lines) != 1 || !strings.HasPrefix(lines[0], "retry:") {
+		t.Fatalf("expected retry field, got %v", lines)
+	}
+
+	hub.Publish(repository.CommentEvent{Type: repository.EventUpdated, Comment: repository.Comment{ID: 1, NewsID: 1, Text: "edited"}})
+	lines := readEvent(t, body)
+	if len(lines) != 3 || lines[0] != "id: 2" || lines[1] != "event: updated" || !strings.Contains(lines[2], `"edited"`) {
+		t.Errorf("unexpected event: %v", lines)
+	}
+
+	if lines := readEvent(t, body); len(lines) != 1 || lines[0] != ": heartbeat" {
+		t.Errorf("expected heartbeat, got %v", lines)
+	}
+}
+
+func TestStreamCommentsResume(t *testing.T) {
+	hub := stream.NewHub(stream.DefaultBuffer, 1)
+	for i := 1; i <= 3; i++ {
+		hub.Publish(repository.CommentEvent{Type: repository.EventCreated, Comment: repository.Comment{ID: i, NewsID: 1}})
+	}
+
+	h := &Handler{Events: hub}
+	server := httptest.NewServer(http.HandlerFunc(h.StreamComments))
+	defer server.Close()
+
+	// Из истории вытеснены события 1 и 2: клиент, получивший лишь первое, должен перезагрузить ленту.
+	resp, err := http.Get(server.URL + "?news_id=1&last_event_id=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	body := bufio.NewReader(resp.Body)
+	readEvent(t, body)
+	if lines := readEvent(t, body); len(lines) == 0 || lines[0] != "event: reset" {
+		t.Errorf("expected reset event, got %v", lines)
+	}
+	if lines := readEvent(t, body); len(lines) == 0 || lines[0] != "id: 3" {
+		t.Errorf("expected event 3 from history, got %v", lines)
+	}
+}
+
+func TestStreamCommentsBadRequest(t *testing.T) {
+	h := &Handler{Events: stream.NewHub(0, 0)}
+	for _, target := range []string{"/comments/stream", "/comments/stream?news_id=1&last_event_id=x"} {
+		rr := httptest.NewRecorder()
+		h.StreamComments(rr, httptest.NewRequest("GET", target, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected status 400, got %v", target, rr.Code)
+		}
+	}
+}
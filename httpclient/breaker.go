@@ -0,0 +1,150 @@
+// Package httpclient - breaker.go
+package httpclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen возвращается, когда автомат для апстрима разомкнут.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// State состояние автоматического выключателя.
+type State int
+
+const (
+	StateClosed State = iota
+	StateOpen
+	StateHalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// Breaker автоматический выключатель для одного апстрима.
+type Breaker struct {
+	name string
+	cfg  Config
+	now  func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	successes int
+	inFlight  int
+	// generation меняется при каждой смене состояния, чтобы отличать
+	// результаты запросов, пропущенных в прежнем состоянии.
+	generation uint64
+	openedAt   time.Time
+	onChange   func(name string, from, to State)
+}
+
+// NewBreaker создает выключатель в замкнутом состоянии.
+func NewBreaker(name string, cfg Config, onChange func(name string, from, to State)) *Breaker {
+	return &Breaker{name: name, cfg: cfg, now: time.Now, onChange: onChange}
+}
+
+// Ticket разрешение на запрос, выданное Allow. Его передают в Record.
+type Ticket struct {
+	generation uint64
+	probe      bool
+}
+
+// Allow проверяет, можно ли отправить запрос через выключатель.
+func (b *Breaker) Allow() (Ticket, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == StateOpen {
+		if b.now().Sub(b.openedAt) < b.cfg.OpenTimeout {
+			return Ticket{}, ErrCircuitOpen
+		}
+		b.setState(StateHalfOpen)
+	}
+
+	t := Ticket{generation: b.generation}
+	if b.state == StateHalfOpen {
+		if b.inFlight >= b.cfg.HalfOpenMaxRequests {
+			return Ticket{}, ErrCircuitOpen
+		}
+		b.inFlight++
+		t.probe = true
+	}
+	return t, nil
+}
+
+// Record учитывает результат запроса, пропущенного через Allow с разрешением t.
+// Результаты запросов, пропущенных до смены состояния, не учитываются.
+func (b *Breaker) Record(t Ticket, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if t.generation != b.generation {
+		return
+	}
+
+	switch b.state {
+	case StateClosed:
+		if success {
+			b.failures = 0
+			return
+		}
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			b.setState(StateOpen)
+		}
+	case StateHalfOpen:
+		if t.probe {
+			b.inFlight--
+		}
+		if !success {
+			b.setState(StateOpen)
+			return
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenSuccesses {
+			b.setState(StateClosed)
+		}
+	}
+}
+
+// State возвращает текущее состояние выключателя.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.cfg.OpenTimeout {
+		return StateHalfOpen
+	}
+	return b.state
+}
+
+// setState переводит выключатель в новое состояние. Вызывается под b.mu.
+func (b *Breaker) setState(to State) {
+	from := b.state
+	if from == to {
+		return
+	}
+
+	b.state = to
+	b.generation++
+	b.failures = 0
+	b.successes = 0
+	b.inFlight = 0
+	if to == StateOpen {
+		b.openedAt = b.now()
+	}
+
+	if b.onChange != nil {
+		b.onChange(b.name, from, to)
+	}
+}
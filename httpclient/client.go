@@ -0,0 +1,207 @@
+// Package httpclient - client.go
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Config параметры повторов и выключателей.
+type Config struct {
+	Timeout             time.Duration
+	MaxRetries          int
+	BaseDelay           time.Duration
+	MaxDelay            time.Duration
+	FailureThreshold    int
+	OpenTimeout         time.Duration
+	HalfOpenMaxRequests int
+	HalfOpenSuccesses   int
+}
+
+// DefaultConfig возвращает настройки по умолчанию.
+func DefaultConfig() Config {
+	return Config{
+		Timeout:             2 * time.Second,
+		MaxRetries:          3,
+		BaseDelay:           100 * time.Millisecond,
+		MaxDelay:            2 * time.Second,
+		FailureThreshold:    5,
+		OpenTimeout:         30 * time.Second,
+		HalfOpenMaxRequests: 1,
+		HalfOpenSuccesses:   1,
+	}
+}
+
+// ConfigFromEnv возвращает DefaultConfig, переопределенный переменными окружения
+// UPSTREAM_MAX_RETRIES, UPSTREAM_FAILURE_THRESHOLD и UPSTREAM_OPEN_TIMEOUT.
+func ConfigFromEnv() Config {
+	cfg := DefaultConfig()
+	if n, err := strconv.Atoi(os.Getenv("UPSTREAM_MAX_RETRIES")); err == nil && n >= 0 {
+		cfg.MaxRetries = n
+	}
+	if n, err := strconv.Atoi(os.Getenv("UPSTREAM_FAILURE_THRESHOLD")); err == nil && n > 0 {
+		cfg.FailureThreshold = n
+	}
+	if d, err := time.ParseDuration(os.Getenv("UPSTREAM_OPEN_TIMEOUT")); err == nil && d > 0 {
+		cfg.OpenTimeout = d
+	}
+	return cfg
+}
+
+// Client HTTP-клиент с повторами идемпотентных запросов и выключателем на каждый хост.
+type Client struct {
+	cfg  Config
+	http *http.Client
+
+	mu       sync.Mutex
+	breakers map[string]*Breaker
+}
+
+// New создает клиента с указанными настройками.
+func New(cfg Config) *Client {
+	return &Client{
+		cfg:      cfg,
+		http:     &http.Client{Timeout: cfg.Timeout},
+		breakers: make(map[string]*Breaker),
+	}
+}
+
+type idempotentKey struct{}
+
+// WithIdempotent помечает запрос как безопасный для повтора независимо от метода.
+func WithIdempotent(ctx context.Context) context.Context {
+	return context.WithValue(ctx, idempotentKey{}, true)
+}
+
+type noRateLimitRetryKey struct{}
+
+// WithoutRateLimitRetry отключает повтор запроса после ответа 429: ответ сразу
+// возвращается вызывающему, который сам соблюдает Retry-After апстрима.
+func WithoutRateLimitRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRateLimitRetryKey{}, true)
+}
+
+// retriesRateLimit сообщает, повторяется ли запрос после ответа 429.
+func retriesRateLimit(req *http.Request) bool {
+	disabled, _ := req.Context().Value(noRateLimitRetryKey{}).(bool)
+	return !disabled
+}
+
+// isIdempotent определяет, можно ли повторять запрос.
+func isIdempotent(req *http.Request) bool {
+	if marked, _ := req.Context().Value(idempotentKey{}).(bool); marked {
+		return true
+	}
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// breaker возвращает выключатель для хоста, создавая его при необходимости.
+func (c *Client) breaker(host string) *Breaker {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.breakers[host]
+	if !ok {
+		b = NewBreaker(host, c.cfg, logStateChange)
+		c.breakers[host] = b
+	}
+	return b
+}
+
+func logStateChange(host string, from, to State) {
+	log.Printf("Circuit breaker for %s changed state: %s -> %s", host, from, to)
+}
+
+// Do отправляет запрос. Идемпотентные запросы повторяются при сетевых ошибках,
+// ответах 5xx и 429 с экспоненциальной задержкой и джиттером. Повтор после 429
+// отключается WithoutRateLimitRetry.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	b := c.breaker(req.URL.Host)
+
+	attempts := 1
+	if isIdempotent(req) {
+		attempts += c.cfg.MaxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := c.sleep(req.Context(), attempt); err != nil {
+				return nil, err
+			}
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req.Body = body
+			}
+		}
+
+		ticket, err := b.Allow()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", req.URL.Host, err)
+		}
+
+		resp, err := c.http.Do(req)
+		failed := err != nil || resp.StatusCode >= http.StatusInternalServerError
+		b.Record(ticket, !failed)
+
+		retryable := failed || (resp.StatusCode == http.StatusTooManyRequests && retriesRateLimit(req))
+		if !retryable || attempt == attempts-1 {
+			return resp, err
+		}
+
+		if err != nil {
+			lastErr = err
+		} else {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+	}
+	return nil, lastErr
+}
+
+// sleep ждет перед повтором номер attempt с учетом отмены контекста.
+func (c *Client) sleep(ctx context.Context, attempt int) error {
+	delay := c.cfg.BaseDelay << uint(attempt-1)
+	if delay > c.cfg.MaxDelay || delay <= 0 {
+		delay = c.cfg.MaxDelay
+	}
+	// Джиттер: случайная задержка в интервале [delay/2, delay].
+	delay = delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Health возвращает состояние выключателей по хостам.
+func (c *Client) Health() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	states := make(map[string]string, len(c.breakers))
+	for host, b := range c.breakers {
+		states[host] = b.State().String()
+	}
+	return states
+}
@@ -0,0 +1,141 @@
+// client_test.go
+package httpclient
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func testConfig() Config {
+	cfg := DefaultConfig()
+	cfg.BaseDelay = time.Millisecond
+	cfg.MaxDelay = 5 * time.Millisecond
+	cfg.FailureThreshold = 3
+	cfg.OpenTimeout = time.Hour
+	return cfg
+}
+
+func TestDoRetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := New(testConfig())
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected status 200, got %d", resp.StatusCode)
+	}
+	if calls != 3 {
+		t.Errorf("expected 3 calls, got %d", calls)
+	}
+}
+
+func TestDoDoesNotRetryPost(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	cfg := testConfig()
+	cfg.FailureThreshold = 10
+	client := New(cfg)
+	req, _ := http.NewRequest("POST", server.URL, strings.NewReader("{}"))
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if resp.StatusCode != http.StatusInternalServerError || calls != 1 {
+		t.Errorf("expected a single failed call, got status %d after %d calls", resp.StatusCode, calls)
+	}
+
+	calls = 0
+	req, _ = http.NewRequest("POST", server.URL, strings.NewReader("{}"))
+	req = req.WithContext(WithIdempotent(req.Context()))
+	client.Do(req)
+	if want := int32(1 + cfg.MaxRetries); calls != want {
+		t.Errorf("expected idempotent POST to be retried, got %d calls, want %d", calls, want)
+	}
+}
+
+func TestDoOpensBreaker(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	client := New(testConfig())
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	if _, err := client.Do(req); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected breaker to stop after 3 calls, got %d", calls)
+	}
+
+	for host, state := range client.Health() {
+		if state != "open" {
+			t.Errorf("expected %s to be open, got %s", host, state)
+		}
+	}
+}
+
+func TestBreakerStates(t *testing.T) {
+	now := time.Now()
+	cfg := testConfig()
+	cfg.FailureThreshold = 2
+	cfg.OpenTimeout = time.Minute
+
+	var transitions []string
+	b := NewBreaker("upstream", cfg, func(name string, from, to State) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+	b.now = func() time.Time { return now }
+
+	// Запрос, пропущенный в замкнутом состоянии, завершится уже после размыкания.
+	late, _ := b.Allow()
+	for i := 0; i < 2; i++ {
+		ticket, err := b.Allow()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		b.Record(ticket, false)
+	}
+	if _, err := b.Allow(); err != ErrCircuitOpen {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+
+	now = now.Add(time.Minute)
+	probe, err := b.Allow()
+	if err != nil {
+		t.Fatalf("expected half-open probe to pass, got %v", err)
+	}
+	b.Record(late, true)
+	if _, err := b.Allow(); err != ErrCircuitOpen {
+		t.Errorf("expected only one half-open probe, got %v", err)
+	}
+	b.Record(probe, true)
+
+	want := []string{"closed->open", "open->half-open", "half-open->closed"}
+	if strings.Join(transitions, ",") != strings.Join(want, ",") {
+		t.Errorf("got transitions %v, want %v", transitions, want)
+	}
+}
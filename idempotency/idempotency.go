@@ -0,0 +1,189 @@
+// Package idempotency - idempotency.go
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	// Header заголовок запроса с ключом идемпотентности.
+	Header = "Idempotency-Key"
+	// ReplayedHeader заголовок, которым помечается повторно отданный ответ.
+	ReplayedHeader = "Idempotent-Replayed"
+	// MaxKeyLength наибольшая длина ключа.
+	MaxKeyLength = 255
+	// DefaultTTL время хранения ответа, если IDEMPOTENCY_TTL не задан.
+	DefaultTTL = 24 * time.Hour
+)
+
+var (
+	// ErrInvalidKey возвращается для пустого, слишком длинного ключа или ключа
+	// с символами вне печатного ASCII.
+	ErrInvalidKey = errors.New("invalid idempotency key")
+	// ErrInProgress возвращается, если запрос с тем же ключом еще выполняется.
+	ErrInProgress = errors.New("request with this idempotency key is in progress")
+	// ErrMismatch возвращается, если ключ уже использован с другим телом запроса.
+	ErrMismatch = errors.New("idempotency key was used with a different request")
+	// ErrNotFound возвращается, если записи для ключа нет.
+	ErrNotFound = errors.New("idempotency key not found")
+)
+
+// Service повторяет сохраненные ответы на запросы с тем же ключом.
+type Service struct {
+	store Store
+	// TTL время, в течение которого ответ отдается повторно.
+	TTL time.Duration
+	now func() time.Time
+}
+
+// NewService создает сервис поверх хранилища store.
+func NewService(store Store) *Service {
+	return &Service{store: store, TTL: DefaultTTL, now: time.Now}
+}
+
+// NewServiceFromEnv создает сервис и читает время хранения из IDEMPOTENCY_TTL,
+// например "12h".
+func NewServiceFromEnv(store Store) *Service {
+	s := NewService(store)
+	if ttl, err := time.ParseDuration(os.Getenv("IDEMPOTENCY_TTL")); err == nil && ttl > 0 {
+		s.TTL = ttl
+	}
+	return s
+}
+
+// ValidKey сообщает, подходит ли значение заголовка в качестве ключа.
+func ValidKey(key string) bool {
+	if key == "" || len(key) > MaxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x20 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Fingerprint возвращает хэш запроса v в JSON для сравнения повторов.
+func Fingerprint(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Do выполняет fn один раз для ключа key в пределах scope. Повтор с тем же
+// fingerprint получает сохраненный ответ с заголовком ReplayedHeader. Ответы
+// 5xx не сохраняются, и запрос можно повторить с тем же ключом.
+//
+// Ошибки ErrInvalidKey, ErrInProgress и ErrMismatch возвращаются до вызова fn,
+// ответ в этом случае не записан.
+func (s *Service) Do(w http.ResponseWriter, scope, key, fingerprint string, fn func(w http.ResponseWriter)) error {
+	if !ValidKey(key) {
+		return ErrInvalidKey
+	}
+
+	now := s.now()
+	rec, created, err := s.store.Reserve(Record{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(s.TTL),
+	}, now)
+	if err != nil {
+		return err
+	}
+	if !created {
+		switch {
+		case rec.Fingerprint != fingerprint:
+			return ErrMismatch
+		case rec.Response == nil:
+			return ErrInProgress
+		}
+		replay(w, *rec.Response)
+		return nil
+	}
+
+	rw := &recorder{ResponseWriter: w}
+	completed := false
+	defer func() {
+		// Запись не должна остаться в обработке, если fn запаниковала.
+		if !completed {
+			s.store.Release(scope, key)
+		}
+	}()
+	fn(rw)
+
+	if rw.status >= http.StatusInternalServerError {
+		return nil
+	}
+	resp := Response{Status: rw.status, ContentType: w.Header().Get("Content-Type"), Body: rw.body.Bytes()}
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	if err := s.store.Complete(scope, key, resp); err != nil {
+		// Ответ уже отправлен, поэтому ключ освобождается для повтора.
+		log.Printf("Failed to store idempotent response: %v", err)
+		return nil
+	}
+	completed = true
+	return nil
+}
+
+// replay отдает сохраненный ответ.
+func replay(w http.ResponseWriter, resp Response) {
+	if resp.ContentType != "" {
+		w.Header().Set("Content-Type", resp.ContentType)
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+// Run удаляет истекшие ключи каждые interval, пока не закрыт stop.
+func (s *Service) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if _, err := s.store.Purge(s.now()); err != nil {
+				log.Printf("Failed to purge idempotency keys: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// recorder передает ответ клиенту и запоминает его для сохранения.
+type recorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
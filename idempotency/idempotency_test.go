@@ -0,0 +1,133 @@
+package idempotency
+
+import (
+	"github.com/DATA-DOG/go-sqlmock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDo(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	calls := 0
+	create := func(w http.ResponseWriter) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id":1}`))
+	}
+
+	rr := httptest.NewRecorder()
+	if err := s.Do(rr, "john", "k1", "fp", create); err != nil || rr.Code != http.StatusCreated {
+		t.Fatalf("first request: %v, %v", err, rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	if err := s.Do(rr, "john", "k1", "fp", create); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || rr.Code != http.StatusCreated || rr.Body.String() != `{"id":1}` ||
+		rr.Header().Get("Content-Type") != "application/json" || rr.Header().Get(ReplayedHeader) != "true" {
+		t.Errorf("expected the first response replayed, got %d calls, %v %q %v", calls, rr.Code, rr.Body.String(), rr.Header())
+	}
+
+	if err := s.Do(httptest.NewRecorder(), "john", "k1", "other", create); err != ErrMismatch {
+		t.Errorf("expected ErrMismatch, got %v", err)
+	}
+	if err := s.Do(httptest.NewRecorder(), "ann", "k1", "other", create); err != nil || calls != 2 {
+		t.Errorf("expected keys scoped by author, got %v, %d calls", err, calls)
+	}
+
+	now = now.Add(DefaultTTL)
+	if err := s.Do(httptest.NewRecorder(), "john", "k1", "other", create); err != nil || calls != 3 {
+		t.Errorf("expected an expired key to be reusable, got %v, %d calls", err, calls)
+	}
+}
+
+func TestDoInProgress(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	var inner error
+	s.Do(httptest.NewRecorder(), "john", "k1", "fp", func(w http.ResponseWriter) {
+		inner = s.Do(httptest.NewRecorder(), "john", "k1", "fp", func(http.ResponseWriter) {})
+		w.WriteHeader(http.StatusCreated)
+	})
+	if inner != ErrInProgress {
+		t.Errorf("expected ErrInProgress, got %v", inner)
+	}
+}
+
+func TestDoReleasesFailures(t *testing.T) {
+	s := NewService(NewMemoryStore())
+	calls := 0
+	fail := func(w http.ResponseWriter) {
+		calls++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	s.Do(httptest.NewRecorder(), "john", "k1", "fp", fail)
+	s.Do(httptest.NewRecorder(), "john", "k1", "fp", fail)
+	if calls != 2 {
+		t.Errorf("expected a 5xx response not to be stored, got %d calls", calls)
+	}
+
+	func() {
+		defer func() { recover() }()
+		s.Do(httptest.NewRecorder(), "john", "k2", "fp", func(http.ResponseWriter) { panic("boom") })
+	}()
+	if err := s.Do(httptest.NewRecorder(), "john", "k2", "fp", func(http.ResponseWriter) {}); err != nil {
+		t.Errorf("expected the key released after a panic, got %v", err)
+	}
+}
+
+func TestValidKey(t *testing.T) {
+	for key, want := range map[string]bool{
+		"8e03978e-40d5-43e8-bc93-6894a57f9324": true,
+		"":                                     false,
+		strings.Repeat("a", MaxKeyLength+1):    false,
+		"bad\nkey":                             false,
+		"ключ":                                 false,
+	} {
+		if got := ValidKey(key); got != want {
+			t.Errorf("ValidKey(%q) = %v, want %v", key, got, want)
+		}
+	}
+	if err := NewService(NewMemoryStore()).Do(httptest.NewRecorder(), "john", "", "fp", nil); err != ErrInvalidKey {
+		t.Errorf("expected ErrInvalidKey, got %v", err)
+	}
+}
+
+func TestSQLStoreReserve(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	s := NewSQLStore(db)
+	now := time.Now()
+	rec := Record{Scope: "john", Key: "k1", Fingerprint: "fp", ExpiresAt: now.Add(time.Hour)}
+
+	mock.ExpectExec("DELETE FROM idempotency_keys").WithArgs("john", "k1", now).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT IGNORE INTO idempotency_keys").WithArgs("john", "k1", "fp", rec.ExpiresAt).WillReturnResult(sqlmock.NewResult(0, 1))
+	if _, created, err := s.Reserve(rec, now); err != nil || !created {
+		t.Fatalf("expected the key reserved, got %v, %v", created, err)
+	}
+
+	mock.ExpectExec("DELETE FROM idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("INSERT IGNORE INTO idempotency_keys").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT fingerprint, status, content_type, body, expires_at FROM idempotency_keys").
+		WithArgs("john", "k1").
+		WillReturnRows(sqlmock.NewRows([]string{"fingerprint", "status", "content_type", "body", "expires_at"}).
+			AddRow("fp", 201, "application/json", []byte(`{"id":1}`), rec.ExpiresAt))
+	got, created, err := s.Reserve(rec, now)
+	if err != nil || created || got.Response == nil || got.Response.Status != 201 || string(got.Response.Body) != `{"id":1}` {
+		t.Errorf("expected the stored response, got %+v, %v, %v", got, created, err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,108 @@
+// Package idempotency - sql_store.go
+package idempotency
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// SQLStore ключи в таблице idempotency_keys.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore создает хранилище ключей в базе данных.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// Setup создает таблицу ключей, если она еще не существует.
+func (s *SQLStore) Setup() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			scope VARCHAR(255) NOT NULL,
+			idem_key VARCHAR(255) NOT NULL,
+			fingerprint CHAR(64) NOT NULL,
+			status INT NOT NULL DEFAULT 0,
+			content_type VARCHAR(255) NOT NULL DEFAULT '',
+			body MEDIUMBLOB NULL,
+			expires_at DATETIME NOT NULL,
+			PRIMARY KEY (scope, idem_key)
+		)
+	`)
+	return err
+}
+
+func (s *SQLStore) Reserve(rec Record, now time.Time) (Record, bool, error) {
+	// Истекшая запись освобождает ключ, после этого вставка решает, кто первый.
+	if _, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE scope = ? AND idem_key = ? AND expires_at <= ?`,
+		rec.Scope, rec.Key, now); err != nil {
+		return Record{}, false, err
+	}
+	result, err := s.db.Exec(`
+		INSERT IGNORE INTO idempotency_keys (scope, idem_key, fingerprint, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, rec.Scope, rec.Key, rec.Fingerprint, rec.ExpiresAt)
+	if err != nil {
+		return Record{}, false, err
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return Record{}, false, err
+	} else if n == 1 {
+		rec.Response = nil
+		return rec, true, nil
+	}
+
+	existing, err := s.get(rec.Scope, rec.Key)
+	return existing, false, err
+}
+
+func (s *SQLStore) get(scope, key string) (Record, error) {
+	rec := Record{Scope: scope, Key: key}
+	var status int
+	var contentType string
+	var body []byte
+	err := s.db.QueryRow(`
+		SELECT fingerprint, status, content_type, body, expires_at
+		FROM idempotency_keys
+		WHERE scope = ? AND idem_key = ?
+	`, scope, key).Scan(&rec.Fingerprint, &status, &contentType, &body, &rec.ExpiresAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, ErrNotFound
+	} else if err != nil {
+		return Record{}, err
+	}
+	if status != 0 {
+		rec.Response = &Response{Status: status, ContentType: contentType, Body: body}
+	}
+	return rec, nil
+}
+
+func (s *SQLStore) Complete(scope, key string, resp Response) error {
+	result, err := s.db.Exec(`
+		UPDATE idempotency_keys SET status = ?, content_type = ?, body = ?
+		WHERE scope = ? AND idem_key = ?
+	`, resp.Status, resp.ContentType, resp.Body, scope, key)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLStore) Release(scope, key string) error {
+	_, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE scope = ? AND idem_key = ?`, scope, key)
+	return err
+}
+
+func (s *SQLStore) Purge(now time.Time) (int, error) {
+	result, err := s.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= ?`, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
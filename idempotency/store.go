@@ -0,0 +1,99 @@
+// Package idempotency - store.go
+package idempotency
+
+import (
+	"sync"
+	"time"
+)
+
+// Response сохраненный ответ на запрос.
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Record запись ключа идемпотентности. Scope отделяет ключи разных авторов,
+// Fingerprint хэш тела запроса. Response равен nil, пока запрос выполняется.
+type Record struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	Response    *Response
+	ExpiresAt   time.Time
+}
+
+// Store хранилище ключей идемпотентности. Записи с истекшим ExpiresAt
+// считаются отсутствующими.
+type Store interface {
+	// Reserve атомарно создает запись, если для Scope и Key нет действующей, и
+	// возвращает ее и true. Иначе возвращает существующую запись и false.
+	Reserve(rec Record, now time.Time) (Record, bool, error)
+	// Complete сохраняет ответ на запрос.
+	Complete(scope, key string, resp Response) error
+	// Release удаляет запись, чтобы запрос можно было повторить.
+	Release(scope, key string) error
+	// Purge удаляет истекшие записи и возвращает их число.
+	Purge(now time.Time) (int, error)
+}
+
+// MemoryStore ключи в памяти.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[recordKey]Record
+}
+
+type recordKey struct {
+	scope, key string
+}
+
+// NewMemoryStore создает пустое хранилище ключей в памяти.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[recordKey]Record)}
+}
+
+func (s *MemoryStore) Reserve(rec Record, now time.Time) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := recordKey{rec.Scope, rec.Key}
+	if existing, ok := s.records[k]; ok && existing.ExpiresAt.After(now) {
+		return existing, false, nil
+	}
+	rec.Response = nil
+	s.records[k] = rec
+	return rec, true, nil
+}
+
+func (s *MemoryStore) Complete(scope, key string, resp Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := recordKey{scope, key}
+	rec, ok := s.records[k]
+	if !ok {
+		return ErrNotFound
+	}
+	resp.Body = append([]byte(nil), resp.Body...)
+	rec.Response = &resp
+	s.records[k] = rec
+	return nil
+}
+
+func (s *MemoryStore) Release(scope, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, recordKey{scope, key})
+	return nil
+}
+
+func (s *MemoryStore) Purge(now time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for k, rec := range s.records {
+		if !rec.ExpiresAt.After(now) {
+			delete(s.records, k)
+			n++
+		}
+	}
+	return n, nil
+}
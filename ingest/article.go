@@ -0,0 +1,80 @@
+// Package ingest - article.go
+package ingest
+
+import (
+	"APIGateway/database"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// Article статья источника, приведенная к единому виду.
+type Article struct {
+	ID          string    `json:"id"`
+	Source      string    `json:"source"`
+	Title       string    `json:"title"`
+	Author      string    `json:"author"`
+	Content     string    `json:"content"`
+	PublishedAt time.Time `json:"published_at"`
+	FetchedAt   time.Time `json:"fetched_at"`
+}
+
+// publishedLayouts форматы даты публикации, которые встречаются у источников.
+var publishedLayouts = []string{
+	time.RFC3339,
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// Normalize приводит новость источника source к статье: схлопывает пробелы,
+// разбирает дату публикации и вычисляет идентификатор. Статья без заголовка
+// отбрасывается. Если дату разобрать не удалось, статья датируется fetchedAt.
+func Normalize(source string, n repository.News, fetchedAt time.Time) (Article, bool) {
+	title := collapseSpaces(n.Title)
+	if title == "" {
+		return Article{}, false
+	}
+	published := strings.TrimSpace(n.Published)
+
+	a := Article{
+		ID:          articleID(source, title, published),
+		Source:      source,
+		Title:       title,
+		Author:      collapseSpaces(n.Author),
+		Content:     strings.TrimSpace(n.Content),
+		PublishedAt: fetchedAt.UTC(),
+		FetchedAt:   fetchedAt.UTC(),
+	}
+	for _, layout := range publishedLayouts {
+		if t, err := time.Parse(layout, published); err == nil {
+			a.PublishedAt = t.UTC()
+			break
+		}
+	}
+	return a, true
+}
+
+// News возвращает статью в формате ответа /news.
+func (a Article) News() repository.News {
+	return repository.News{
+		Title:     a.Title,
+		Author:    a.Author,
+		Published: a.PublishedAt.Format(time.RFC3339),
+		Content:   a.Content,
+		Source:    a.Source,
+	}
+}
+
+// articleID идентификатор статьи: одна и та же статья при повторных опросах
+// получает тот же идентификатор.
+func articleID(source, title, published string) string {
+	sum := sha256.Sum256([]byte(source + "\x00" + strings.ToLower(title) + "\x00" + published))
+	return hex.EncodeToString(sum[:])
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,126 @@
+package ingest
+
+import (
+	"APIGateway/database"
+	"APIGateway/httpclient"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	fetchedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	a, ok := Normalize("bbc", repository.News{Title: "  Big\n news ", Author: " Jane  Doe ", Published: "2024-04-30T08:00:00+02:00"}, fetchedAt)
+	if !ok {
+		t.Fatal("expected article to be accepted")
+	}
+	if a.Title != "Big news" || a.Author != "Jane Doe" {
+		t.Errorf("unexpected normalized fields: %+v", a)
+	}
+	if want := time.Date(2024, 4, 30, 6, 0, 0, 0, time.UTC); !a.PublishedAt.Equal(want) {
+		t.Errorf("expected published %s, got %s", want, a.PublishedAt)
+	}
+
+	again, _ := Normalize("bbc", repository.News{Title: "big news", Published: "2024-04-30T08:00:00+02:00"}, fetchedAt.Add(time.Hour))
+	if again.ID != a.ID {
+		t.Errorf("expected the same article to keep its id")
+	}
+
+	undated, _ := Normalize("bbc", repository.News{Title: "Undated", Published: "yesterday"}, fetchedAt)
+	if !undated.PublishedAt.Equal(fetchedAt) {
+		t.Errorf("expected undated article to use fetch time, got %s", undated.PublishedAt)
+	}
+
+	if _, ok := Normalize("bbc", repository.News{Title: "   "}, fetchedAt); ok {
+		t.Errorf("expected article without title to be dropped")
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	articles := []Article{
+		{ID: "1", Source: "bbc", Title: "Election results", Author: "Jane Doe", PublishedAt: base},
+		{ID: "2", Source: "nyt", Title: "Weather", PublishedAt: base.Add(time.Hour)},
+		{ID: "3", Source: "bbc", Title: "Election debate", Author: "John", PublishedAt: base.Add(2 * time.Hour)},
+	}
+
+	inserted, _ := store.Save(articles)
+	if len(inserted) != 3 {
+		t.Errorf("expected 3 inserted articles, got %d", len(inserted))
+	}
+	if inserted, _ := store.Save(articles[:1]); len(inserted) != 0 {
+		t.Errorf("expected duplicates to be skipped, got %d", len(inserted))
+	}
+
+	page, total, _ := store.List(Query{Search: "election", Page: 1, PageSize: 1})
+	if total != 2 || len(page) != 1 || page[0].ID != "3" {
+		t.Errorf("unexpected first page: %+v (total %d)", page, total)
+	}
+	page, _, _ = store.List(Query{Search: "election", Page: 2, PageSize: 1})
+	if len(page) != 1 || page[0].ID != "1" {
+		t.Errorf("unexpected second page: %+v", page)
+	}
+	if page, total, _ := store.List(Query{Source: "nyt", Page: 1, PageSize: 10}); total != 1 || page[0].ID != "2" {
+		t.Errorf("unexpected source filter result: %+v", page)
+	}
+	q := Query{Sources: []string{"BBC", "cnn"}, Authors: []string{"doe", "nobody"}, Before: base.Add(time.Hour)}
+	if page, total, _ := store.List(q); total != 1 || page[0].ID != "1" {
+		t.Errorf("unexpected sources, authors and date result: %+v", page)
+	}
+	if page, total, _ := store.List(Query{After: base.Add(time.Hour)}); total != 2 || page[0].ID != "3" {
+		t.Errorf("unexpected date result: %+v", page)
+	}
+}
+
+func TestSourcesFromEnv(t *testing.T) {
+	fallback := []Source{{Name: "bbc", URL: "http://bbc"}}
+	if sources, _ := SourcesFromEnv(fallback); len(sources) != 1 || sources[0].Name != "bbc" {
+		t.Errorf("expected fallback sources, got %+v", sources)
+	}
+
+	t.Setenv("NEWS_SOURCES", "a=http://a/news|1m, b=http://b/news")
+	sources, err := SourcesFromEnv(fallback)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 2 || sources[0].URL != "http://a/news" || sources[0].Interval != time.Minute || sources[1].Interval != DefaultInterval {
+		t.Errorf("unexpected sources: %+v", sources)
+	}
+
+	t.Setenv("NEWS_SOURCES", "a=http://a|soon")
+	if _, err := SourcesFromEnv(nil); err == nil {
+		t.Errorf("expected error for invalid interval")
+	}
+}
+
+func TestHTTPFetcherRateLimit(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.URL.Query().Get("pageSize") == "" {
+			t.Errorf("expected pageSize parameter")
+		}
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	cfg := httpclient.DefaultConfig()
+	cfg.BaseDelay = time.Millisecond
+	fetcher := NewHTTPFetcher(httpclient.New(cfg))
+	_, err := fetcher.Fetch(context.Background(), Source{Name: "bbc", URL: server.URL})
+
+	var limited *RateLimitError
+	if !errors.As(err, &limited) || limited.RetryAfter != 2*time.Minute {
+		t.Errorf("expected rate limit error with 2m retry, got %v", err)
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("expected a rate-limited source to be requested once, got %d requests", n)
+	}
+}
@@ -0,0 +1,237 @@
+// Package ingest - scheduler.go
+package ingest
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+// DefaultInterval интервал опроса источника, для которого интервал не задан.
+const DefaultInterval = 5 * time.Minute
+
+var (
+	// ErrUnknownSource возвращается при запуске опроса неизвестного источника.
+	ErrUnknownSource = errors.New("unknown news source")
+	// FetchTimeout время на один опрос источника.
+	FetchTimeout = 30 * time.Second
+	// RetryDelay задержка перед первым повтором после ошибки. Следующие повторы
+	// ждут вдвое дольше, но не дольше интервала источника.
+	RetryDelay = 30 * time.Second
+)
+
+// Status состояние опроса источника.
+type Status struct {
+	Source              string     `json:"source"`
+	URL                 string     `json:"url"`
+	Interval            string     `json:"interval"`
+	Running             bool       `json:"running"`
+	LastRunAt           *time.Time `json:"last_run_at,omitempty"`
+	LastSuccessAt       *time.Time `json:"last_success_at,omitempty"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorAt         *time.Time `json:"last_error_at,omitempty"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	LastFetched         int        `json:"last_fetched"`
+	LastStored          int        `json:"last_stored"`
+	TotalStored         int        `json:"total_stored"`
+	NextRunAt           *time.Time `json:"next_run_at,omitempty"`
+	RateLimitedUntil    *time.Time `json:"rate_limited_until,omitempty"`
+}
+
+// Scheduler опрашивает каждый источник по его расписанию и сохраняет новые статьи.
+type Scheduler struct {
+	store   Store
+	fetcher Fetcher
+	// OnNew получает статьи, которых не было в хранилище. Вызывается из горутины источника.
+	OnNew func([]Article)
+	now   func() time.Time
+
+	mu      sync.Mutex
+	sources []*sourceState
+}
+
+// sourceState источник и состояние его опроса. status защищен Scheduler.mu.
+type sourceState struct {
+	Source
+	status  Status
+	trigger chan struct{}
+}
+
+// NewScheduler создает планировщик опроса источников sources.
+func NewScheduler(store Store, fetcher Fetcher, sources ...Source) *Scheduler {
+	s := &Scheduler{store: store, fetcher: fetcher, now: time.Now}
+	for _, source := range sources {
+		if source.Interval <= 0 {
+			source.Interval = DefaultInterval
+		}
+		s.sources = append(s.sources, &sourceState{
+			Source:  source,
+			status:  Status{Source: source.Name, URL: source.URL, Interval: source.Interval.String()},
+			trigger: make(chan struct{}, 1),
+		})
+	}
+	return s
+}
+
+// Run опрашивает источники, пока не закрыт stop. Первый опрос выполняется сразу.
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	var wg sync.WaitGroup
+	for _, st := range s.sources {
+		wg.Add(1)
+		go func(st *sourceState) {
+			defer wg.Done()
+			s.loop(st, stop)
+		}(st)
+	}
+	wg.Wait()
+}
+
+// loop опрашивает источник по расписанию и по запросу.
+func (s *Scheduler) loop(st *sourceState, stop <-chan struct{}) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		case <-st.trigger:
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+		timer.Reset(s.runOnce(st))
+	}
+}
+
+// Trigger запускает внеочередной опрос источника name или всех источников при
+// пустом name. Источник, ограничивший частоту запросов, не опрашивается до
+// истечения ограничения: для него возвращается RateLimitError.
+func (s *Scheduler) Trigger(name string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var triggered []string
+	for _, st := range s.sources {
+		if name != "" && st.Name != name {
+			continue
+		}
+		if until := st.status.RateLimitedUntil; until != nil && until.After(s.now()) {
+			if name != "" {
+				return nil, &RateLimitError{Source: st.Name, RetryAfter: until.Sub(s.now())}
+			}
+			continue
+		}
+		// Запрос, пришедший во время опроса, не копится: хватит одного повтора.
+		select {
+		case st.trigger <- struct{}{}:
+		default:
+		}
+		triggered = append(triggered, st.Name)
+	}
+	if name != "" && len(triggered) == 0 {
+		return nil, ErrUnknownSource
+	}
+	return triggered, nil
+}
+
+// Status возвращает состояние опроса всех источников.
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, len(s.sources))
+	for i, st := range s.sources {
+		statuses[i] = st.status
+	}
+	return statuses
+}
+
+// runOnce опрашивает источник и возвращает задержку до следующего опроса.
+func (s *Scheduler) runOnce(st *sourceState) time.Duration {
+	s.update(st, func(status *Status) { status.Running = true })
+
+	ctx, cancel := context.WithTimeout(context.Background(), FetchTimeout)
+	defer cancel()
+	news, err := s.fetcher.Fetch(ctx, st.Source)
+
+	var inserted []Article
+	if err == nil {
+		fetchedAt := s.now()
+		articles := make([]Article, 0, len(news))
+		for _, n := range news {
+			if a, ok := Normalize(st.Name, n, fetchedAt); ok {
+				articles = append(articles, a)
+			}
+		}
+		inserted, err = s.store.Save(articles)
+	}
+
+	now := s.now()
+	delay := st.Interval
+	s.update(st, func(status *Status) {
+		status.Running = false
+		status.LastRunAt = &now
+		status.RateLimitedUntil = nil
+		if err != nil {
+			status.LastError = err.Error()
+			status.LastErrorAt = &now
+			status.ConsecutiveFailures++
+			delay = s.retryDelay(st, status.ConsecutiveFailures, err)
+			var limited *RateLimitError
+			if errors.As(err, &limited) {
+				until := now.Add(delay)
+				status.RateLimitedUntil = &until
+			}
+		} else {
+			status.LastSuccessAt = &now
+			status.LastError = ""
+			status.ConsecutiveFailures = 0
+			status.LastFetched = len(news)
+			status.LastStored = len(inserted)
+			status.TotalStored += len(inserted)
+		}
+		next := now.Add(delay)
+		status.NextRunAt = &next
+	})
+
+	if err != nil {
+		log.Printf("news ingestion: %s: %v", st.Name, err)
+	}
+	if len(inserted) > 0 && s.OnNew != nil {
+		s.OnNew(inserted)
+	}
+	return delay
+}
+
+// retryDelay задержка перед повтором после failures ошибок подряд. Ограничение
+// частоты источника выдерживается полностью, остальные ошибки повторяются
+// с экспоненциальной задержкой в пределах интервала источника.
+func (s *Scheduler) retryDelay(st *sourceState, failures int, err error) time.Duration {
+	var limited *RateLimitError
+	if errors.As(err, &limited) {
+		if limited.RetryAfter > 0 {
+			return limited.RetryAfter
+		}
+		return st.Interval
+	}
+
+	delay := RetryDelay << uint(failures-1)
+	if delay <= 0 || delay > st.Interval {
+		delay = st.Interval
+	}
+	return delay
+}
+
+// update меняет состояние источника под блокировкой.
+func (s *Scheduler) update(st *sourceState, fn func(status *Status)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(&st.status)
+}
@@ -0,0 +1,121 @@
+package ingest
+
+import (
+	"APIGateway/database"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fetcherFunc позволяет задать Fetcher функцией.
+type fetcherFunc func(ctx context.Context, source Source) ([]repository.News, error)
+
+func (f fetcherFunc) Fetch(ctx context.Context, source Source) ([]repository.News, error) {
+	return f(ctx, source)
+}
+
+func TestSchedulerRunOnce(t *testing.T) {
+	var results []error
+	fetcher := fetcherFunc(func(ctx context.Context, source Source) ([]repository.News, error) {
+		err := results[0]
+		results = results[1:]
+		if err != nil {
+			return nil, err
+		}
+		return []repository.News{{Title: "One"}, {Title: "Two"}}, nil
+	})
+
+	var published []Article
+	s := NewScheduler(NewMemoryStore(), fetcher, Source{Name: "bbc", Interval: 10 * time.Minute})
+	s.OnNew = func(fresh []Article) { published = append(published, fresh...) }
+	st := s.sources[0]
+
+	tt := []struct {
+		name         string
+		err          error
+		wantDelay    time.Duration
+		wantFailures int
+		wantLimited  bool
+	}{
+		{"first success", nil, 10 * time.Minute, 0, false},
+		{"upstream error", errors.New("boom"), RetryDelay, 1, false},
+		{"second error backs off", errors.New("boom"), 2 * RetryDelay, 2, false},
+		{"rate limited", &RateLimitError{Source: "bbc", RetryAfter: time.Hour}, time.Hour, 3, true},
+		{"recovered", nil, 10 * time.Minute, 0, false},
+	}
+
+	for _, tc := range tt {
+		results = append(results, tc.err)
+		delay := s.runOnce(st)
+		status := s.Status()[0]
+
+		if delay != tc.wantDelay {
+			t.Errorf("%s: expected delay %s, got %s", tc.name, tc.wantDelay, delay)
+		}
+		if status.ConsecutiveFailures != tc.wantFailures {
+			t.Errorf("%s: expected %d failures, got %d", tc.name, tc.wantFailures, status.ConsecutiveFailures)
+		}
+		if (status.RateLimitedUntil != nil) != tc.wantLimited {
+			t.Errorf("%s: unexpected rate limit state %v", tc.name, status.RateLimitedUntil)
+		}
+		if tc.err != nil && status.LastError == "" {
+			t.Errorf("%s: expected last error to be recorded", tc.name)
+		}
+	}
+
+	status := s.Status()[0]
+	if status.TotalStored != 2 || status.LastStored != 0 || status.LastSuccessAt == nil {
+		t.Errorf("unexpected final status: %+v", status)
+	}
+	if len(published) != 2 {
+		t.Errorf("expected only new articles to be published, got %d", len(published))
+	}
+}
+
+func TestSchedulerTrigger(t *testing.T) {
+	fetched := make(chan string, 4)
+	fetcher := fetcherFunc(func(ctx context.Context, source Source) ([]repository.News, error) {
+		fetched <- source.Name
+		return nil, nil
+	})
+	s := NewScheduler(NewMemoryStore(), fetcher,
+		Source{Name: "bbc", Interval: time.Hour},
+		Source{Name: "nyt", Interval: time.Hour},
+	)
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go s.Run(stop)
+
+	// Первый опрос каждого источника выполняется при запуске.
+	for i := 0; i < 2; i++ {
+		<-fetched
+	}
+
+	if _, err := s.Trigger("bbc"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case name := <-fetched:
+		if name != "bbc" {
+			t.Errorf("expected bbc to be fetched, got %s", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected triggered fetch")
+	}
+
+	if _, err := s.Trigger("reuters"); !errors.Is(err, ErrUnknownSource) {
+		t.Errorf("expected ErrUnknownSource, got %v", err)
+	}
+
+	until := time.Now().Add(time.Minute)
+	s.update(s.sources[1], func(status *Status) { status.RateLimitedUntil = &until })
+	var limited *RateLimitError
+	if _, err := s.Trigger("nyt"); !errors.As(err, &limited) {
+		t.Errorf("expected RateLimitError, got %v", err)
+	}
+	if triggered, _ := s.Trigger(""); len(triggered) != 1 || triggered[0] != "bbc" {
+		t.Errorf("expected only bbc to be triggered, got %v", triggered)
+	}
+}
@@ -0,0 +1,142 @@
+// Package ingest - source.go
+package ingest
+
+import (
+	"APIGateway/database"
+	"APIGateway/httpclient"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPageSize число статей, запрашиваемых у источника за один опрос.
+const DefaultPageSize = 50
+
+// Source источник новостей и расписание его опроса.
+type Source struct {
+	Name     string
+	URL      string
+	Interval time.Duration
+	PageSize int
+}
+
+// SourcesFromEnv читает источники из NEWS_SOURCES в виде
+// "name=url|interval,name=url|interval". Без интервала источник опрашивается
+// раз в DefaultInterval. Без NEWS_SOURCES возвращается fallback.
+func SourcesFromEnv(fallback []Source) ([]Source, error) {
+	raw := os.Getenv("NEWS_SOURCES")
+	if raw == "" {
+		return fallback, nil
+	}
+
+	var sources []Source
+	for _, item := range strings.Split(raw, ",") {
+		name, rest, ok := strings.Cut(strings.TrimSpace(item), "=")
+		if !ok || name == "" || rest == "" {
+			return nil, fmt.Errorf("invalid news source %q", item)
+		}
+		source := Source{Name: name, URL: rest, Interval: DefaultInterval}
+		if u, every, ok := strings.Cut(rest, "|"); ok {
+			d, err := time.ParseDuration(every)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid interval for news source %q", name)
+			}
+			source.URL, source.Interval = u, d
+		}
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+// Fetcher получает новости источника.
+type Fetcher interface {
+	Fetch(ctx context.Context, source Source) ([]repository.News, error)
+}
+
+// RateLimitError возвращается, когда источник ограничил частоту запросов.
+// RetryAfter ноль, если источник не сообщил, когда повторить запрос.
+type RateLimitError struct {
+	Source     string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("%s: rate limited, retry after %s", e.Source, e.RetryAfter)
+	}
+	return fmt.Sprintf("%s: rate limited", e.Source)
+}
+
+// HTTPFetcher получает новости по HTTP в формате сервисов новостей: {"news": [...]}.
+type HTTPFetcher struct {
+	Client *httpclient.Client
+}
+
+// NewHTTPFetcher создает HTTPFetcher поверх клиента с повторами и выключателями.
+func NewHTTPFetcher(client *httpclient.Client) *HTTPFetcher {
+	return &HTTPFetcher{Client: client}
+}
+
+func (f *HTTPFetcher) Fetch(ctx context.Context, source Source) ([]repository.News, error) {
+	u, err := url.Parse(source.URL)
+	if err != nil {
+		return nil, err
+	}
+	pageSize := source.PageSize
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	query := u.Query()
+	query.Set("page", "1")
+	query.Set("pageSize", strconv.Itoa(pageSize))
+	u.RawQuery = query.Encode()
+
+	// Ответ 429 не повторяется клиентом: планировщик сам откладывает опрос
+	// источника на время из Retry-After.
+	req, err := http.NewRequestWithContext(httpclient.WithoutRateLimitRetry(ctx), http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return nil, &RateLimitError{Source: source.Name, RetryAfter: retryAfter(resp.Header.Get("Retry-After"), time.Now())}
+	case resp.StatusCode != http.StatusOK:
+		io.Copy(io.Discard, resp.Body)
+		return nil, fmt.Errorf("%s: unexpected status %d", source.Name, resp.StatusCode)
+	}
+
+	var body struct {
+		News []repository.News `json:"news"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("%s: %w", source.Name, err)
+	}
+	return body.News, nil
+}
+
+// retryAfter разбирает заголовок Retry-After: число секунд или HTTP-дату.
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,118 @@
+// Package ingest - sql_store.go
+package ingest
+
+import (
+	"database/sql"
+	"strings"
+)
+
+// SQLStore статьи в таблице news_articles.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore создает хранилище статей в базе данных.
+func NewSQLStore(db *sql.DB) *SQLStore {
+	return &SQLStore{db: db}
+}
+
+// Setup создает таблицу статей, если она еще не существует.
+func (s *SQLStore) Setup() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS news_articles (
+			id CHAR(64) PRIMARY KEY,
+			source VARCHAR(64) NOT NULL,
+			title VARCHAR(512) NOT NULL,
+			author VARCHAR(255),
+			content TEXT,
+			published_at DATETIME NOT NULL,
+			fetched_at DATETIME NOT NULL,
+			INDEX idx_news_articles_published (published_at)
+		)
+	`)
+	return err
+}
+
+func (s *SQLStore) Save(articles []Article) ([]Article, error) {
+	var inserted []Article
+	for _, a := range articles {
+		res, err := s.db.Exec(`
+			INSERT IGNORE INTO news_articles (id, source, title, author, content, published_at, fetched_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, a.ID, a.Source, a.Title, a.Author, a.Content, a.PublishedAt, a.FetchedAt)
+		if err != nil {
+			return inserted, err
+		}
+		if n, err := res.RowsAffected(); err == nil && n > 0 {
+			inserted = append(inserted, a)
+		}
+	}
+	return inserted, nil
+}
+
+func (s *SQLStore) List(q Query) ([]Article, int, error) {
+	where := ` WHERE 1 = 1`
+	var args []interface{}
+	if q.Source != "" {
+		where += ` AND source = ?`
+		args = append(args, q.Source)
+	}
+	if len(q.Sources) > 0 {
+		where += ` AND source IN (?` + strings.Repeat(`, ?`, len(q.Sources)-1) + `)`
+		for _, source := range q.Sources {
+			args = append(args, source)
+		}
+	}
+	if len(q.Authors) > 0 {
+		conds := make([]string, len(q.Authors))
+		for i, name := range q.Authors {
+			conds[i] = `LOWER(author) LIKE ?`
+			args = append(args, "%"+strings.ToLower(name)+"%")
+		}
+		where += ` AND (` + strings.Join(conds, ` OR `) + `)`
+	}
+	if !q.After.IsZero() {
+		where += ` AND published_at >= ?`
+		args = append(args, q.After)
+	}
+	if !q.Before.IsZero() {
+		where += ` AND published_at < ?`
+		args = append(args, q.Before)
+	}
+	if q.Search != "" {
+		where += ` AND title LIKE ?`
+		args = append(args, "%"+q.Search+"%")
+	}
+
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM news_articles`+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	limit := q.PageSize
+	if limit <= 0 {
+		limit = total
+	}
+	rows, err := s.db.Query(`
+		SELECT id, source, title, author, content, published_at, fetched_at
+		FROM news_articles`+where+`
+		ORDER BY published_at DESC, id
+		LIMIT ? OFFSET ?
+	`, append(args, limit, q.offset())...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var articles []Article
+	for rows.Next() {
+		var a Article
+		var author, content sql.NullString
+		if err := rows.Scan(&a.ID, &a.Source, &a.Title, &author, &content, &a.PublishedAt, &a.FetchedAt); err != nil {
+			return nil, 0, err
+		}
+		a.Author, a.Content = author.String, content.String
+		articles = append(articles, a)
+	}
+	return articles, total, rows.Err()
+}
@@ -0,0 +1,136 @@
+// Package ingest - store.go
+package ingest
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Query параметры выборки статей. Search ищет подстроку в заголовке без учета регистра.
+type Query struct {
+	Search string
+	Source string
+	// Sources и Authors отбирают статьи любого из источников и авторов. Авторы
+	// сравниваются по подстроке без учета регистра.
+	Sources []string
+	Authors []string
+	// After и Before ограничивают дату публикации: After включительно, Before нет.
+	After    time.Time
+	Before   time.Time
+	Page     int
+	PageSize int
+}
+
+// match проверяет статью по условиям запроса, кроме Search.
+func (q Query) match(a Article) bool {
+	if q.Source != "" && a.Source != q.Source {
+		return false
+	}
+	if len(q.Sources) > 0 && !containsFold(q.Sources, a.Source) {
+		return false
+	}
+	if len(q.Authors) > 0 {
+		author := strings.ToLower(a.Author)
+		found := false
+		for _, name := range q.Authors {
+			if strings.Contains(author, strings.ToLower(name)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !q.After.IsZero() && a.PublishedAt.Before(q.After) {
+		return false
+	}
+	return q.Before.IsZero() || a.PublishedAt.Before(q.Before)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// offset возвращает смещение страницы. Страницы нумеруются с единицы.
+func (q Query) offset() int {
+	if q.Page <= 1 {
+		return 0
+	}
+	return (q.Page - 1) * q.PageSize
+}
+
+// Store хранилище статей.
+type Store interface {
+	// Save сохраняет статьи и возвращает те, которых в хранилище еще не было.
+	Save(articles []Article) ([]Article, error)
+	// List возвращает страницу статей от новых к старым и общее число подходящих статей.
+	List(q Query) ([]Article, int, error)
+}
+
+// MemoryStore статьи в памяти.
+type MemoryStore struct {
+	mu       sync.RWMutex
+	articles map[string]Article
+}
+
+// NewMemoryStore создает пустое хранилище статей в памяти.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{articles: make(map[string]Article)}
+}
+
+func (s *MemoryStore) Save(articles []Article) ([]Article, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var inserted []Article
+	for _, a := range articles {
+		if _, ok := s.articles[a.ID]; ok {
+			continue
+		}
+		s.articles[a.ID] = a
+		inserted = append(inserted, a)
+	}
+	return inserted, nil
+}
+
+func (s *MemoryStore) List(q Query) ([]Article, int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	search := strings.ToLower(q.Search)
+	var matched []Article
+	for _, a := range s.articles {
+		if !q.match(a) {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(a.Title), search) {
+			continue
+		}
+		matched = append(matched, a)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if !matched[i].PublishedAt.Equal(matched[j].PublishedAt) {
+			return matched[i].PublishedAt.After(matched[j].PublishedAt)
+		}
+		return matched[i].ID < matched[j].ID
+	})
+
+	total := len(matched)
+	start := q.offset()
+	if start > total {
+		start = total
+	}
+	end := total
+	if q.PageSize > 0 && start+q.PageSize < total {
+		end = start + q.PageSize
+	}
+	return matched[start:end], total, nil
+}
@@ -1,46 +1,164 @@
 package main
 
 import (
+	"APIGateway/apikey"
+	"APIGateway/auth"
+	"APIGateway/authz"
+	"APIGateway/censor"
 	"APIGateway/database"
 	"APIGateway/handlers"
+	"APIGateway/idempotency"
+	"APIGateway/ingest"
 	"APIGateway/middleware"
+	"APIGateway/quota"
+	"APIGateway/search"
+	"APIGateway/stream"
 	"database/sql"
 	_ "github.com/go-sql-driver/mysql"
 	"log"
 	"net/http"
+	"net/url"
+	"time"
 )
 
 func main() {
-	db, err := sql.Open("mysql", "root:love@tcp(127.0.0.1:3306)/mydatabase")
+	db, err := sql.Open("mysql", "root:love@tcp(127.0.0.1:3306)/mydatabase?parseTime=true")
 	if err != nil {
 		log.Fatal(err)
 	}
 	defer db.Close()
 
-	repo := repository.NewRepository(db)
+	moderator, err := censor.EngineFromEnv()
+	if err != nil {
+		log.Fatal("Cannot load dictionary:", err)
+	}
+	go moderator.Watch(5*time.Second, nil)
+	repository.Moderator = moderator
+
+	// Изменения комментариев рассылаются в потоки /comments/stream и в поисковый
+	// индекс этого процесса.
+	events := stream.NewHub(stream.DefaultBuffer, stream.DefaultHistory)
+	commentIndex := search.NewIndex()
+	repo := repository.NewPublishingRepository(db, repository.Publishers{events, search.CommentIndexer{Index: commentIndex}})
 	err = repo.SetupDatabase()
 	if err != nil {
 		log.Fatal("Cannot setup database:", err)
 	}
+	if err := search.IndexComments(commentIndex, repo); err != nil {
+		log.Fatal("Cannot index comments:", err)
+	}
 
 	handler := handlers.NewHandler(repo)
+	handler.Users = repository.NewUserRepository(db)
+	handler.Events = events
+	handler.News = stream.NewNewsFeed(stream.DefaultBuffer, stream.DefaultHistory)
+
+	articles := ingest.NewSQLStore(db)
+	if err := articles.Setup(); err != nil {
+		log.Fatal("Cannot setup news store:", err)
+	}
+	sources, err := ingest.SourcesFromEnv([]ingest.Source{
+		{Name: "bbc", URL: handlers.BBCAPI, Interval: 5 * time.Minute},
+		{Name: "nyt", URL: handlers.NYTAPI, Interval: 10 * time.Minute},
+	})
+	if err != nil {
+		log.Fatal("Cannot configure news sources:", err)
+	}
+	scheduler := ingest.NewScheduler(articles, ingest.NewHTTPFetcher(handlers.Upstream), sources...)
+	newsIndex := search.NewIndex()
+	if err := search.IndexArticles(newsIndex, articles); err != nil {
+		log.Fatal("Cannot index news:", err)
+	}
+	scheduler.OnNew = func(fresh []ingest.Article) {
+		newsIndex.AddArticles(fresh)
+		for _, a := range fresh {
+			handler.News.Publish(a.News())
+		}
+	}
+	go scheduler.Run(nil)
+	handler.Articles = articles
+	handler.Ingest = scheduler
+	handler.NewsIndex = newsIndex
+	handler.CommentIndex = commentIndex
+
+	idempotencyKeys := idempotency.NewSQLStore(db)
+	if err := idempotencyKeys.Setup(); err != nil {
+		log.Fatal("Cannot setup idempotency keys:", err)
+	}
+	handler.Idempotency = idempotency.NewServiceFromEnv(idempotencyKeys)
+	go handler.Idempotency.Run(time.Hour, nil)
 
 	if handlers.NewsServiceURL == "" || handlers.CommentsServiceURL == "" {
 		log.Fatal("NEWS_SERVICE_URL or COMMENT_SERVICE_URL not set")
 	}
+	commentService, err := url.Parse(handlers.CommentsServiceURL)
+	if err != nil {
+		log.Fatal("Invalid COMMENT_SERVICE_URL:", err)
+	}
+
+	// Без ключей NewsAPI шлюз работает, но /forward-news отвечает 503.
+	if handlers.NewsAPI, err = quota.NewClientFromEnv(); err != nil {
+		log.Println("NewsAPI disabled:", err)
+	}
+
+	verifier, err := auth.VerifierFromEnv(handler.Tokens.Secret)
+	if err != nil {
+		log.Fatal("Cannot configure token verification:", err)
+	}
+	authenticator := middleware.NewAuthenticator(verifier)
+
+	keyStore := apikey.NewSQLStore(db)
+	if err := keyStore.Setup(); err != nil {
+		log.Fatal("Cannot setup API keys:", err)
+	}
+	authenticator.Keys = apikey.NewServiceFromEnv(keyStore)
+	go authenticator.Keys.Run(time.Minute, nil)
+
+	// route регистрирует обработчик с уровнем доступа access.
+	route := func(path string, access middleware.Access, h http.HandlerFunc) {
+		http.Handle(path, middleware.LoggingMiddleware(authenticator.Protect(access, h)))
+	}
 
-	newsHandler := middleware.LoggingMiddleware(http.HandlerFunc(handler.NewsHandler))
-	newsDetailHandler := middleware.LoggingMiddleware(http.HandlerFunc(handler.NewsDetailHandler))
-	newsFilterHandler := middleware.LoggingMiddleware(http.HandlerFunc(handler.NewsFilterHandler))
-	addCommentHandler := middleware.LoggingMiddleware(http.HandlerFunc(handler.AddComment))
-	getCommentsHandler := middleware.LoggingMiddleware(http.HandlerFunc(handler.GetComments))
-	forwardNewsRequestHandler := middleware.LoggingMiddleware(http.HandlerFunc(handler.ForwardNewsRequest))
-	http.Handle("/forward-news", forwardNewsRequestHandler)
-	http.Handle("/comments/get", getCommentsHandler)
-	http.Handle("/news", newsHandler)
-	http.Handle("/news/details", newsDetailHandler)
-	http.Handle("/news/filter", newsFilterHandler)
-	http.Handle("/comments/add", addCommentHandler)
+	route("/health", middleware.Public, handler.HealthHandler)
+	newsRead := middleware.Public.WithScope(apikey.ScopeNewsRead)
+	route("/forward-news", newsRead, handler.ForwardNewsRequest)
+	route("/forward-news/quota", middleware.RequirePermission(authz.ViewQuota), handler.QuotaStatusHandler)
+	route("/news", newsRead, handler.NewsHandler)
+	route("/news/details", newsRead, handler.NewsDetailHandler)
+	route("/news/filter", newsRead, handler.NewsFilterHandler)
+	route("/news/rss", newsRead, handler.NewsRSS)
+	route("/news/atom", newsRead, handler.NewsAtom)
+	route("/search", newsRead, handler.Search)
+	route("/news/ingest/status", middleware.RequirePermission(authz.ManageIngestion), handler.IngestStatus)
+	route("/news/ingest/trigger", middleware.RequirePermission(authz.ManageIngestion), handler.TriggerIngest)
+	route("/comments/get", middleware.Public.WithScope(apikey.ScopeCommentsRead), handler.GetComments)
+	route("/comments/stream", middleware.Public.WithScope(apikey.ScopeCommentsRead), handler.StreamComments)
+	route("/comments/rss", middleware.Public.WithScope(apikey.ScopeCommentsRead), handler.CommentsRSS)
+	route("/comments/atom", middleware.Public.WithScope(apikey.ScopeCommentsRead), handler.CommentsAtom)
+	route("/live", middleware.Public.WithScope(apikey.ScopeCommentsRead), handler.Live)
+	// Анонимные комментарии разрешает сам обработчик, поэтому маршрут открыт.
+	route("/comments/add", middleware.Public.WithScope(apikey.ScopeCommentsWrite), handler.AddComment)
+	// Править и удалять свои комментарии может автор, поэтому права проверяет обработчик.
+	route("/comments/edit", middleware.Authenticated, handler.EditComment)
+	route("/comments/delete", middleware.Authenticated, handler.DeleteComment)
+	route("/comments/revisions", middleware.Authenticated, handler.CommentRevisions)
+	route("/comments/hide", middleware.RequirePermission(authz.HideAnyComment), handler.HideComment)
+	route("/comments/vote", middleware.RequirePermission(authz.ReactToComments), handler.VoteComment)
+	route("/comments/react", middleware.RequirePermission(authz.ReactToComments), handler.ReactToComment)
+	route("/comments/report", middleware.RequirePermission(authz.ReportComments), handler.ReportComment)
+	review := middleware.RequirePermission(authz.ReviewComments)
+	route("/moderation/pending", review, handler.PendingComments)
+	route("/moderation/approve", review, handler.ApproveComment)
+	route("/moderation/reject", review, handler.RejectComment)
+	route("/moderation/log", review, handler.ModerationLog)
+	route("/moderation/reported", review, handler.ReportedComments)
+	route("/auth/register", middleware.Public, handler.Register)
+	route("/auth/login", middleware.Public, handler.Login)
+	route("/admin/users/role", middleware.RequirePermission(authz.ManageUsers), handler.SetUserRole)
+	// Запросы под /comment-service/ уходят сервису комментариев с подписанным
+	// заголовком идентичности; права проверяет сам сервис.
+	forward := http.StripPrefix("/comment-service", middleware.Forward(commentService, auth.IdentitySecretFromEnv()))
+	route("/comment-service/", middleware.Public, forward.ServeHTTP)
 
 	http.ListenAndServe(":8080", nil)
 
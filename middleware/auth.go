@@ -0,0 +1,176 @@
+// Package middleware - auth.go
+package middleware
+
+import (
+	"APIGateway/apikey"
+	"APIGateway/auth"
+	"APIGateway/authz"
+	"APIGateway/response"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Access уровень доступа к маршруту.
+type Access struct {
+	// Authenticated требует действительный токен.
+	Authenticated bool
+	// Roles требует хотя бы одну из перечисленных ролей.
+	Roles []string
+	// Permission требует право из матрицы authz.Matrix.
+	Permission authz.Permission
+	// Scope область, открывающая маршрут для ключей API. Без нее ключи не принимаются.
+	Scope string
+}
+
+// RequirePermission маршрут доступен только пользователям с правом perm.
+func RequirePermission(perm authz.Permission) Access {
+	return Access{Authenticated: true, Permission: perm}
+}
+
+// WithScope открывает маршрут для ключей API с областью scope.
+func (a Access) WithScope(scope string) Access {
+	a.Scope = scope
+	return a
+}
+
+var (
+	// Public маршрут доступен всем. Действительный токен все равно проверяется,
+	// и утверждения передаются обработчику.
+	Public = Access{}
+	// Authenticated маршрут доступен только с действительным токеном.
+	Authenticated = Access{Authenticated: true}
+)
+
+// RequireRoles маршрут доступен только пользователям с одной из ролей roles.
+func RequireRoles(roles ...string) Access {
+	return Access{Authenticated: true, Roles: roles}
+}
+
+// TokenVerifier проверяет токен и возвращает его утверждения.
+type TokenVerifier interface {
+	Verify(token string) (*auth.Claims, error)
+}
+
+// Authenticator проверяет токены JWT и ключи API на шлюзе.
+type Authenticator struct {
+	Verifier TokenVerifier
+	// Keys проверяет ключи API. nil отключает прием ключей.
+	Keys *apikey.Service
+}
+
+// NewAuthenticator создает Authenticator с проверяющим verifier.
+func NewAuthenticator(verifier TokenVerifier) *Authenticator {
+	return &Authenticator{Verifier: verifier}
+}
+
+// Protect является обработчиком middleware, проверяющим ключ API из заголовка
+// X-API-Key или токен из заголовка Authorization и уровень доступа access.
+// Утверждения кладутся в контекст запроса, откуда их берет Forward;
+// присланный клиентом заголовок auth.IdentityHeader всегда удаляется.
+func (a *Authenticator) Protect(access Access, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Del(auth.IdentityHeader)
+
+		var claims *auth.Claims
+		if raw := r.Header.Get(apikey.Header); raw != "" {
+			var ok bool
+			if claims, ok = a.checkAPIKey(w, r, raw, access); !ok {
+				return
+			}
+		} else if token, ok := auth.BearerToken(r.Header.Get("Authorization")); ok {
+			var err error
+			claims, err = a.Verifier.Verify(token)
+			if err != nil && access.Authenticated {
+				unauthorized(w, r, "Invalid or expired token")
+				return
+			}
+		}
+
+		if access.Authenticated && claims == nil {
+			unauthorized(w, r, "Authentication required")
+			return
+		}
+		if (len(access.Roles) > 0 && !hasAnyRole(claims, access.Roles)) ||
+			(access.Permission != "" && !authz.Can(authz.RolesOf(claims), access.Permission)) {
+			writeError(w, r, http.StatusForbidden, "Insufficient permissions")
+			return
+		}
+
+		if claims != nil {
+			r = r.WithContext(auth.WithClaims(r.Context(), claims))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkAPIKey проверяет ключ API, его область и лимит запросов. При отказе
+// пишет ответ и возвращает false.
+func (a *Authenticator) checkAPIKey(w http.ResponseWriter, r *http.Request, raw string, access Access) (*auth.Claims, bool) {
+	if a.Keys == nil {
+		writeError(w, r, http.StatusUnauthorized, "API keys are not accepted")
+		return nil, false
+	}
+
+	key, rate, err := a.Keys.Authenticate(raw)
+	if rate.Limit > 0 {
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(rate.Limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(rate.Remaining))
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(rate.Reset.Unix(), 10))
+	}
+	switch {
+	case errors.Is(err, apikey.ErrRateLimited):
+		retry := int(time.Until(rate.Reset).Seconds()) + 1
+		w.Header().Set("Retry-After", strconv.Itoa(retry))
+		writeError(w, r, http.StatusTooManyRequests, "API key rate limit exceeded")
+		return nil, false
+	case errors.Is(err, apikey.ErrInvalidKey), errors.Is(err, apikey.ErrRevoked):
+		writeError(w, r, http.StatusUnauthorized, "Invalid API key")
+		return nil, false
+	case err != nil:
+		writeError(w, r, http.StatusInternalServerError, "Failed to check API key")
+		return nil, false
+	case access.Scope == "" || !key.HasScope(access.Scope):
+		writeError(w, r, http.StatusForbidden, "API key does not allow this request")
+		return nil, false
+	}
+	// Ключ с правом записи действует как комментатор, остальные — как читатели.
+	// Название ключа выбирает его владелец, поэтому именем в утверждениях служит
+	// идентификатор ключа: ключ "alice" не должен выступать от имени alice.
+	role := authz.Reader
+	if key.HasScope(apikey.ScopeCommentsWrite) {
+		role = authz.Commenter
+	}
+	return &auth.Claims{Subject: key.Subject(), Username: key.Subject(), Roles: []string{role}}, true
+}
+
+// TrustedIdentity является обработчиком middleware для сервисов за шлюзом:
+// переносит утверждения из заголовка auth.IdentityHeader в контекст запроса,
+// если заголовок подписан секретом secret. Неподписанный заголовок игнорируется.
+func TrustedIdentity(secret []byte, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if claims := auth.Identity(r.Header, secret); claims != nil {
+			r = r.WithContext(auth.WithClaims(r.Context(), claims))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func hasAnyRole(claims *auth.Claims, roles []string) bool {
+	for _, role := range roles {
+		if claims.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, message string) {
+	w.Header().Set("WWW-Authenticate", "Bearer")
+	writeError(w, r, http.StatusUnauthorized, message)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, code int, message string) {
+	response.Error(w, r, code, message)
+}
@@ -0,0 +1,151 @@
+// auth_test.go
+package middleware
+
+import (
+	"APIGateway/apikey"
+	"APIGateway/auth"
+	"APIGateway/authz"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProtect(t *testing.T) {
+	issuer := auth.NewIssuer([]byte("secret"), time.Hour)
+	user, _ := issuer.Issue(1, "john")
+	moderator, _ := issuer.Issue(2, "mod", authz.Moderator)
+	authenticator := NewAuthenticator(issuer)
+
+	tt := []struct {
+		name       string
+		access     Access
+		token      string
+		wantStatus int
+		wantUser   string
+	}{
+		{"public anonymous", Public, "", http.StatusOK, ""},
+		{"public with token", Public, user, http.StatusOK, "john"},
+		{"public with invalid token", Public, "garbage", http.StatusOK, ""},
+		{"authenticated anonymous", Authenticated, "", http.StatusUnauthorized, ""},
+		{"authenticated with invalid token", Authenticated, "garbage", http.StatusUnauthorized, ""},
+		{"authenticated", Authenticated, user, http.StatusOK, "john"},
+		{"missing role", RequireRoles("moderator"), user, http.StatusForbidden, ""},
+		{"missing permission", RequirePermission(authz.ReviewComments), user, http.StatusForbidden, ""},
+		{"permission granted", RequirePermission(authz.ReviewComments), moderator, http.StatusOK, "mod"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var gotUser, gotHeader string
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if claims := auth.ClaimsFromContext(r.Context()); claims != nil {
+					gotUser = claims.Username
+				}
+				if claims := auth.Identity(r.Header, []byte("identity")); claims != nil {
+					gotHeader = claims.Username
+				}
+			})
+
+			req := httptest.NewRequest("GET", "/", nil)
+			// Заголовок идентичности от клиента должен быть отброшен.
+			auth.SetIdentity(req.Header, &auth.Claims{Username: "mallory", Roles: []string{"moderator"}}, []byte("identity"))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rr := httptest.NewRecorder()
+			authenticator.Protect(tc.access, next).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			if gotUser != tc.wantUser {
+				t.Errorf("expected identity %q, got %q", tc.wantUser, gotUser)
+			}
+			if gotHeader != "" {
+				t.Errorf("expected client identity header to be dropped, got %q", gotHeader)
+			}
+		})
+	}
+}
+
+func TestTrustedIdentity(t *testing.T) {
+	secret := []byte("identity")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"claims":{"name":"x","roles":["admin"]},"exp":9999999999}`))
+
+	tt := []struct {
+		name     string
+		header   func(h http.Header)
+		wantUser string
+	}{
+		{"signed", func(h http.Header) { auth.SetIdentity(h, &auth.Claims{Subject: "1", Username: "john"}, secret) }, "john"},
+		{"other secret", func(h http.Header) { auth.SetIdentity(h, &auth.Claims{Username: "john"}, []byte("other")) }, ""},
+		{"unsigned", func(h http.Header) { h.Set(auth.IdentityHeader, forged) }, ""},
+		{"forged signature", func(h http.Header) { h.Set(auth.IdentityHeader, forged+".c2lnbmF0dXJl") }, ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var got *auth.Claims
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = auth.ClaimsFromContext(r.Context())
+			})
+			req := httptest.NewRequest("GET", "/", nil)
+			tc.header(req.Header)
+			TrustedIdentity(secret, next).ServeHTTP(httptest.NewRecorder(), req)
+
+			gotUser := ""
+			if got != nil {
+				gotUser = got.Username
+			}
+			if gotUser != tc.wantUser {
+				t.Errorf("expected user %q, got %q", tc.wantUser, gotUser)
+			}
+		})
+	}
+}
+
+func TestProtectAPIKey(t *testing.T) {
+	keys := apikey.NewService(apikey.NewMemoryStore())
+	raw, _, _ := keys.Issue("partner", []string{apikey.ScopeNewsRead}, 0)
+	limited, limitedKey, _ := keys.Issue("partner", []string{apikey.ScopeNewsRead}, 1)
+	authenticator := &Authenticator{Verifier: auth.NewIssuer([]byte("secret"), time.Hour), Keys: keys}
+
+	newsRead := Public.WithScope(apikey.ScopeNewsRead)
+	tt := []struct {
+		name       string
+		access     Access
+		key        string
+		wantStatus int
+	}{
+		{"scope allowed", newsRead, limited, http.StatusOK},
+		{"rate limited", newsRead, limited, http.StatusTooManyRequests},
+		{"invalid key", newsRead, "gwk_00000000_secret", http.StatusUnauthorized},
+		{"scope missing", Public.WithScope(apikey.ScopeCommentsWrite), raw, http.StatusForbidden},
+		{"route without scope", Public, raw, http.StatusForbidden},
+		{"role route", RequireRoles("moderator").WithScope(apikey.ScopeNewsRead), raw, http.StatusForbidden},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var got *auth.Claims
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = auth.ClaimsFromContext(r.Context())
+			})
+
+			req := httptest.NewRequest("GET", "/news", nil)
+			req.Header.Set(apikey.Header, tc.key)
+			rr := httptest.NewRecorder()
+			authenticator.Protect(tc.access, next).ServeHTTP(rr, req)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			// Название ключа не становится именем пользователя.
+			if tc.wantStatus == http.StatusOK && (got == nil || got.Subject != limitedKey.Subject() || got.Username != limitedKey.Subject()) {
+				t.Errorf("expected claims for %s, got %+v", limitedKey.Subject(), got)
+			}
+		})
+	}
+}
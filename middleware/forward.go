@@ -0,0 +1,26 @@
+// Package middleware - forward.go
+package middleware
+
+import (
+	"APIGateway/auth"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// Forward является обработчиком, пересылающим запрос сервису target.
+// Утверждения, которые Protect положил в контекст, уходят сервису в заголовке
+// auth.IdentityHeader, подписанном секретом secret; сервис принимает их через
+// TrustedIdentity. Без утверждений или секрета заголовок не передается.
+func Forward(target *url.URL, secret []byte) http.Handler {
+	proxy := httputil.NewSingleHostReverseProxy(target)
+	director := proxy.Director
+	proxy.Director = func(r *http.Request) {
+		director(r)
+		auth.SetIdentity(r.Header, auth.ClaimsFromContext(r.Context()), secret)
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		writeError(w, r, http.StatusBadGateway, "Upstream service unavailable")
+	}
+	return proxy
+}
@@ -0,0 +1,71 @@
+// forward_test.go
+package middleware
+
+import (
+	"APIGateway/auth"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestForwardIdentity(t *testing.T) {
+	secret := []byte("identity")
+	issuer := auth.NewIssuer([]byte("secret"), time.Hour)
+	token, _ := issuer.Issue(1, "john", "commenter")
+
+	// Сервис комментариев за шлюзом доверяет только подписанному заголовку.
+	var gotUser, gotPath string
+	service := httptest.NewServer(TrustedIdentity(secret, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotUser, gotPath = "", r.URL.Path
+		if claims := auth.ClaimsFromContext(r.Context()); claims != nil {
+			gotUser = claims.Subject + "/" + claims.Username
+		}
+	})))
+	defer service.Close()
+	target, _ := url.Parse(service.URL)
+
+	gateway := NewAuthenticator(issuer).Protect(Public, http.StripPrefix("/comment-service", Forward(target, secret)))
+
+	tt := []struct {
+		name     string
+		token    string
+		wantUser string
+	}{
+		{"authenticated", token, "1/john"},
+		{"anonymous", "", ""},
+		{"invalid token", "garbage", ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/comment-service/comments/add", nil)
+			// Присланный клиентом заголовок не должен дойти до сервиса, даже с верной подписью.
+			auth.SetIdentity(req.Header, &auth.Claims{Subject: "9", Username: "mallory", Roles: []string{"admin"}}, secret)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rr := httptest.NewRecorder()
+			gateway.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusOK {
+				t.Fatalf("expected status 200, got %v", rr.Code)
+			}
+			if gotPath != "/comments/add" {
+				t.Errorf("expected path /comments/add, got %q", gotPath)
+			}
+			if gotUser != tc.wantUser {
+				t.Errorf("expected service to see %q, got %q", tc.wantUser, gotUser)
+			}
+		})
+	}
+
+	// Недоступный сервис дает 502.
+	service.Close()
+	rr := httptest.NewRecorder()
+	gateway.ServeHTTP(rr, httptest.NewRequest("GET", "/comment-service/comments/get", nil))
+	if rr.Code != http.StatusBadGateway {
+		t.Errorf("expected status 502, got %v", rr.Code)
+	}
+}
@@ -2,7 +2,8 @@
 package middleware
 
 import (
-	"context"
+	"APIGateway/response"
+	"bufio"
 	"github.com/google/uuid"
 	"log"
 	"net"
@@ -10,10 +11,6 @@ import (
 	"time"
 )
 
-type contextKey string
-
-var requestIDKey = contextKey("requestID")
-
 type statusWriter struct {
 	http.ResponseWriter
 	status int
@@ -24,11 +21,26 @@ func (w *statusWriter) WriteHeader(status int) {
 	w.ResponseWriter.WriteHeader(status)
 }
 
+// Hijack передает соединение обработчику, например для WebSocket.
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := http.NewResponseController(w.ResponseWriter).Hijack()
+	if err == nil {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return conn, rw, err
+}
+
+// Unwrap открывает исходный ResponseWriter для http.ResponseController,
+// чтобы потоковые ответы могли сбрасывать буфер.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 // LoggingMiddleware является обработчиком middleware, ведущим журнал запросов.
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		requestID := uuid.New().String()
-		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		ctx := response.WithRequestID(r.Context(), requestID)
 
 		clientIP, _, err := net.SplitHostPort(r.RemoteAddr)
 		if err != nil {
@@ -54,7 +66,7 @@ func RequestIDMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			requestID = uuid.NewString()
 		}
 
-		ctx := context.WithValue(r.Context(), requestIDKey, requestID)
+		ctx := response.WithRequestID(r.Context(), requestID)
 		w.Header().Set("X-Request-ID", requestID)
 
 		next(w, r.WithContext(ctx))
@@ -2,10 +2,11 @@
 package main
 
 import (
+	"APIGateway/quota"
 	"encoding/json"
-	"io/ioutil"
 	"log"
 	"net/http"
+	"net/url"
 )
 
 type NewsAPIResponse struct {
@@ -22,16 +23,10 @@ type NewsAPIResponse struct {
 	} `json:"articles"`
 }
 
-func handleNews(w http.ResponseWriter, r *http.Request) {
-	resp, err := http.Get("https://newsapi.org/v2/top-headlines?country=us&apiKey=818fab7db99641809f117b29d2ffcfe8")
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	defer resp.Body.Close()
+var newsAPI *quota.Client
 
-	body, err := ioutil.ReadAll(resp.Body)
+func handleNews(w http.ResponseWriter, r *http.Request) {
+	body, cached, err := newsAPI.Get("/top-headlines", url.Values{"country": {"us"}})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -51,12 +46,22 @@ func handleNews(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if cached {
+		w.Header().Set("X-Cache", "HIT")
+	}
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(data)
 }
 
 func main() {
+	var err error
+	newsAPI, err = quota.NewClientFromEnv()
+	if err != nil {
+		log.Fatal("Cannot configure NewsAPI:", err)
+	}
+
 	http.HandleFunc("/news", handleNews)
+	// Состояние квоты не публикуется: проверки прав у сервиса новостей нет.
 
 	log.Fatal(http.ListenAndServe(":8080", nil))
 }
@@ -0,0 +1,213 @@
+// Package quota - newsapi.go
+package quota
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultNewsAPIURL = "https://newsapi.org/v2"
+	defaultLimit      = 100
+	defaultWindow     = 24 * time.Hour
+	// DefaultCacheTTL и DefaultCacheSize ограничивают запасной кэш ответов.
+	DefaultCacheTTL  = time.Hour
+	DefaultCacheSize = 256
+)
+
+// ErrNoKeys возвращается, если в NEWSAPI_KEYS не задано ни одного ключа.
+var ErrNoKeys = errors.New("no NewsAPI keys configured")
+
+// Client выполняет запросы к NewsAPI с учетом квот и отдает кэш,
+// когда квота исчерпана или апстрим недоступен.
+type Client struct {
+	BaseURL    string
+	Manager    *Manager
+	HTTPClient *http.Client
+	// CacheTTL время, в течение которого ответ отдается из кэша, CacheSize
+	// наибольшее число запросов в кэше. При переполнении вытесняется самый старый ответ.
+	CacheTTL  time.Duration
+	CacheSize int
+
+	mu    sync.RWMutex
+	cache map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	body      []byte
+	fetchedAt time.Time
+}
+
+// NewClient создает клиента NewsAPI.
+func NewClient(baseURL string, manager *Manager) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Manager:    manager,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+		CacheTTL:   DefaultCacheTTL,
+		CacheSize:  DefaultCacheSize,
+		cache:      make(map[string]cachedResponse),
+	}
+}
+
+// NewClientFromEnv создает клиента по переменным окружения NEWSAPI_URL,
+// NEWSAPI_KEYS (через запятую), NEWSAPI_QUOTA, NEWSAPI_QUOTA_WINDOW,
+// NEWSAPI_CACHE_TTL и NEWSAPI_CACHE_SIZE.
+// Без ключей возвращает ErrNoKeys.
+func NewClientFromEnv() (*Client, error) {
+	baseURL := os.Getenv("NEWSAPI_URL")
+	if baseURL == "" {
+		baseURL = defaultNewsAPIURL
+	}
+
+	var keys []string
+	for _, key := range strings.Split(os.Getenv("NEWSAPI_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, ErrNoKeys
+	}
+
+	limit, err := strconv.Atoi(os.Getenv("NEWSAPI_QUOTA"))
+	if err != nil || limit <= 0 {
+		limit = defaultLimit
+	}
+
+	window, err := time.ParseDuration(os.Getenv("NEWSAPI_QUOTA_WINDOW"))
+	if err != nil || window <= 0 {
+		window = defaultWindow
+	}
+
+	c := NewClient(baseURL, NewManager(keys, limit, window))
+	if ttl, err := time.ParseDuration(os.Getenv("NEWSAPI_CACHE_TTL")); err == nil && ttl > 0 {
+		c.CacheTTL = ttl
+	}
+	if n, err := strconv.Atoi(os.Getenv("NEWSAPI_CACHE_SIZE")); err == nil && n >= 0 {
+		c.CacheSize = n
+	}
+	return c, nil
+}
+
+// Get запрашивает path у NewsAPI. Второе значение сообщает, что ответ взят из кэша.
+func (c *Client) Get(path string, query url.Values) ([]byte, bool, error) {
+	cacheKey := path + "?" + query.Encode()
+
+	for {
+		key, err := c.Manager.Acquire()
+		if err != nil {
+			return c.fromCache(cacheKey, err)
+		}
+
+		body, status, err := c.do(path, query, key)
+		if err != nil {
+			return c.fromCache(cacheKey, err)
+		}
+
+		switch {
+		case status == http.StatusOK:
+			c.store(cacheKey, body)
+			return body, false, nil
+		case isQuotaResponse(status, body):
+			c.Manager.MarkExhausted(key)
+		default:
+			return c.fromCache(cacheKey, fmt.Errorf("newsapi responded with status code: %d", status))
+		}
+	}
+}
+
+// do выполняет один запрос к NewsAPI с указанным ключом.
+func (c *Client) do(path string, query url.Values, key string) ([]byte, int, error) {
+	req, err := http.NewRequest("GET", c.BaseURL+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("X-Api-Key", key)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, resp.StatusCode, nil
+}
+
+// store сохраняет ответ в кэш, вытесняя самый старый ответ при переполнении.
+func (c *Client) store(cacheKey string, body []byte) {
+	if c.CacheSize <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.cache[cacheKey]; !ok && len(c.cache) >= c.CacheSize {
+		oldest := ""
+		for key, cached := range c.cache {
+			if oldest == "" || cached.fetchedAt.Before(c.cache[oldest].fetchedAt) {
+				oldest = key
+			}
+		}
+		delete(c.cache, oldest)
+	}
+	c.cache[cacheKey] = cachedResponse{body: body, fetchedAt: time.Now()}
+}
+
+// fromCache возвращает закэшированный ответ или исходную ошибку, если кэша нет
+// или ответ старше CacheTTL.
+func (c *Client) fromCache(cacheKey string, cause error) ([]byte, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached, ok := c.cache[cacheKey]
+	if !ok {
+		return nil, false, cause
+	}
+	if c.CacheTTL > 0 && time.Since(cached.fetchedAt) > c.CacheTTL {
+		delete(c.cache, cacheKey)
+		return nil, false, cause
+	}
+	return cached.body, true, nil
+}
+
+// isQuotaResponse определяет, что NewsAPI отказал из-за исчерпанной квоты ключа.
+func isQuotaResponse(status int, body []byte) bool {
+	if status == http.StatusTooManyRequests {
+		return true
+	}
+	if status != http.StatusUnauthorized {
+		return false
+	}
+	var apiErr struct {
+		Code string `json:"code"`
+	}
+	json.Unmarshal(body, &apiErr)
+	return apiErr.Code == "apiKeyExhausted" || apiErr.Code == "rateLimited"
+}
+
+// StatusHandler отдает оставшуюся квоту по ключам в формате JSON.
+func (c *Client) StatusHandler(w http.ResponseWriter, r *http.Request) {
+	c.mu.RLock()
+	cached := len(c.cache)
+	c.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"remaining":      c.Manager.Remaining(),
+		"keys":           c.Manager.Status(),
+		"cached_entries": cached,
+	})
+}
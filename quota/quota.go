@@ -0,0 +1,130 @@
+// Package quota - quota.go
+package quota
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrQuotaExhausted возвращается, когда у всех ключей закончилась квота.
+var ErrQuotaExhausted = errors.New("upstream quota exhausted")
+
+// Manager отслеживает количество запросов по каждому ключу в пределах окна
+// и распределяет запросы между ключами по кругу.
+type Manager struct {
+	mu     sync.Mutex
+	keys   []*keyState
+	limit  int
+	window time.Duration
+	next   int
+	now    func() time.Time
+}
+
+type keyState struct {
+	key         string
+	used        int
+	windowStart time.Time
+	exhausted   bool
+}
+
+// KeyStatus состояние квоты одного ключа.
+type KeyStatus struct {
+	Key       string    `json:"key"`
+	Used      int       `json:"used"`
+	Remaining int       `json:"remaining"`
+	ResetAt   time.Time `json:"reset_at"`
+}
+
+// NewManager создает менеджер квот для списка ключей.
+func NewManager(keys []string, limit int, window time.Duration) *Manager {
+	m := &Manager{limit: limit, window: window, now: time.Now}
+	for _, key := range keys {
+		m.keys = append(m.keys, &keyState{key: key})
+	}
+	return m
+}
+
+// reset начинает новое окно для ключа, если текущее истекло.
+func (m *Manager) reset(k *keyState, now time.Time) {
+	if k.windowStart.IsZero() || now.Sub(k.windowStart) >= m.window {
+		k.windowStart = now
+		k.used = 0
+		k.exhausted = false
+	}
+}
+
+func (m *Manager) remaining(k *keyState) int {
+	if k.exhausted || k.used >= m.limit {
+		return 0
+	}
+	return m.limit - k.used
+}
+
+// Acquire возвращает следующий ключ с оставшейся квотой и учитывает запрос.
+func (m *Manager) Acquire() (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	for i := 0; i < len(m.keys); i++ {
+		k := m.keys[(m.next+i)%len(m.keys)]
+		m.reset(k, now)
+		if m.remaining(k) > 0 {
+			k.used++
+			m.next = (m.next + i + 1) % len(m.keys)
+			return k.key, nil
+		}
+	}
+	return "", ErrQuotaExhausted
+}
+
+// MarkExhausted помечает ключ исчерпанным до конца текущего окна,
+// например, когда апстрим ответил 429.
+func (m *Manager) MarkExhausted(key string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, k := range m.keys {
+		if k.key == key {
+			m.reset(k, m.now())
+			k.exhausted = true
+		}
+	}
+}
+
+// Remaining возвращает суммарный остаток квоты по всем ключам.
+func (m *Manager) Remaining() int {
+	total := 0
+	for _, s := range m.Status() {
+		total += s.Remaining
+	}
+	return total
+}
+
+// Status возвращает состояние квоты по каждому ключу. Ключи маскируются.
+func (m *Manager) Status() []KeyStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	statuses := make([]KeyStatus, 0, len(m.keys))
+	for _, k := range m.keys {
+		m.reset(k, now)
+		statuses = append(statuses, KeyStatus{
+			Key:       maskKey(k.key),
+			Used:      k.used,
+			Remaining: m.remaining(k),
+			ResetAt:   k.windowStart.Add(m.window),
+		})
+	}
+	return statuses
+}
+
+// maskKey оставляет видимыми только последние четыре символа ключа.
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
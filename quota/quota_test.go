@@ -0,0 +1,209 @@
+// quota_test.go
+package quota
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestManagerRotatesKeys(t *testing.T) {
+	m := NewManager([]string{"key-a", "key-b"}, 2, time.Hour)
+
+	var got []string
+	for i := 0; i < 4; i++ {
+		key, err := m.Acquire()
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		got = append(got, key)
+	}
+
+	want := []string{"key-a", "key-b", "key-a", "key-b"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("acquire %d: got %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	if _, err := m.Acquire(); err != ErrQuotaExhausted {
+		t.Errorf("expected ErrQuotaExhausted, got %v", err)
+	}
+}
+
+func TestManagerWindowReset(t *testing.T) {
+	now := time.Now()
+	m := NewManager([]string{"key-a"}, 1, time.Hour)
+	m.now = func() time.Time { return now }
+
+	if _, err := m.Acquire(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if _, err := m.Acquire(); err != ErrQuotaExhausted {
+		t.Fatalf("expected ErrQuotaExhausted, got %v", err)
+	}
+
+	now = now.Add(time.Hour)
+	if _, err := m.Acquire(); err != nil {
+		t.Errorf("expected quota to reset after window, got %v", err)
+	}
+	if m.Remaining() != 0 {
+		t.Errorf("expected 0 remaining, got %d", m.Remaining())
+	}
+}
+
+// stubNewsAPI имитирует NewsAPI: ключ "limited" всегда получает 429.
+type stubNewsAPI struct {
+	mu    sync.Mutex
+	calls map[string]int
+	down  bool
+}
+
+func (s *stubNewsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := r.Header.Get("X-Api-Key")
+	s.calls[key]++
+	switch {
+	case s.down:
+		w.WriteHeader(http.StatusBadGateway)
+	case key == "limited":
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"status":"error","code":"rateLimited"}`))
+	default:
+		w.Write([]byte(`{"status":"ok","articles":[{"title":"` + r.URL.Query().Get("country") + `"}]}`))
+	}
+}
+
+func TestClientRotatesOnRateLimit(t *testing.T) {
+	stub := &stubNewsAPI{calls: map[string]int{}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	client := NewClient(server.URL, NewManager([]string{"limited", "good"}, 10, time.Hour))
+
+	body, cached, err := client.Get("/top-headlines", url.Values{"country": {"us"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if cached {
+		t.Errorf("expected fresh response")
+	}
+	if string(body) != `{"status":"ok","articles":[{"title":"us"}]}` {
+		t.Errorf("unexpected body: %s", body)
+	}
+
+	// Исчерпанный ключ больше не используется до конца окна.
+	client.Get("/top-headlines", url.Values{"country": {"us"}})
+	if stub.calls["limited"] != 1 {
+		t.Errorf("expected limited key to be called once, got %d", stub.calls["limited"])
+	}
+	if stub.calls["good"] != 2 {
+		t.Errorf("expected good key to be called twice, got %d", stub.calls["good"])
+	}
+}
+
+func TestClientServesCacheWhenExhausted(t *testing.T) {
+	stub := &stubNewsAPI{calls: map[string]int{}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	client := NewClient(server.URL, NewManager([]string{"good"}, 1, time.Hour))
+	query := url.Values{"country": {"us"}}
+
+	fresh, _, err := client.Get("/top-headlines", query)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	body, cached, err := client.Get("/top-headlines", query)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !cached || string(body) != string(fresh) {
+		t.Errorf("expected cached response, got cached=%v body=%s", cached, body)
+	}
+	if stub.calls["good"] != 1 {
+		t.Errorf("expected one upstream call, got %d", stub.calls["good"])
+	}
+
+	if _, _, err := client.Get("/top-headlines", url.Values{"country": {"gb"}}); err != ErrQuotaExhausted {
+		t.Errorf("expected ErrQuotaExhausted for uncached query, got %v", err)
+	}
+}
+
+func TestClientServesCacheWhenUpstreamDown(t *testing.T) {
+	stub := &stubNewsAPI{calls: map[string]int{}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	client := NewClient(server.URL, NewManager([]string{"good"}, 10, time.Hour))
+	query := url.Values{"country": {"us"}}
+	client.Get("/top-headlines", query)
+
+	stub.down = true
+	if _, cached, err := client.Get("/top-headlines", query); err != nil || !cached {
+		t.Errorf("expected cached response, got cached=%v err=%v", cached, err)
+	}
+}
+
+func TestStatusHandler(t *testing.T) {
+	client := NewClient("http://unused", NewManager([]string{"secret-key-1234"}, 5, time.Hour))
+	client.Manager.Acquire()
+
+	rec := httptest.NewRecorder()
+	client.StatusHandler(rec, httptest.NewRequest("GET", "/quota", nil))
+
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK {
+		t.Fatalf("unexpected status code: %d", rec.Code)
+	}
+	for _, want := range []string{`"remaining":4`, `"key":"****1234"`} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %s in %s", want, body)
+		}
+	}
+}
+
+func TestNewClientFromEnv(t *testing.T) {
+	t.Setenv("NEWSAPI_KEYS", " , ")
+	if _, err := NewClientFromEnv(); err != ErrNoKeys {
+		t.Errorf("expected ErrNoKeys, got %v", err)
+	}
+
+	t.Setenv("NEWSAPI_KEYS", "a, b")
+	c, err := NewClientFromEnv()
+	if err != nil || len(c.Manager.Status()) != 2 {
+		t.Errorf("expected two keys, got %v", err)
+	}
+}
+
+func TestClientCacheLimits(t *testing.T) {
+	stub := &stubNewsAPI{calls: map[string]int{}}
+	server := httptest.NewServer(stub)
+	defer server.Close()
+
+	client := NewClient(server.URL, NewManager([]string{"good"}, 2, time.Hour))
+	client.CacheSize = 1
+	client.Get("/top-headlines", url.Values{"country": {"us"}})
+	client.Get("/top-headlines", url.Values{"country": {"gb"}})
+
+	// Квота исчерпана: в кэше остался только последний ответ.
+	if _, cached, err := client.Get("/top-headlines", url.Values{"country": {"us"}}); cached || err == nil {
+		t.Errorf("expected the oldest response evicted, got cached=%v err=%v", cached, err)
+	}
+	if _, cached, _ := client.Get("/top-headlines", url.Values{"country": {"gb"}}); !cached {
+		t.Error("expected the newest response cached")
+	}
+
+	client.CacheTTL = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	if _, cached, err := client.Get("/top-headlines", url.Values{"country": {"gb"}}); cached || err == nil {
+		t.Errorf("expected an expired response not to be served, got cached=%v err=%v", cached, err)
+	}
+}
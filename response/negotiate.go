@@ -0,0 +1,79 @@
+// Package response - negotiate.go
+package response
+
+import (
+	"strconv"
+	"strings"
+)
+
+// mediaRange элемент заголовка Accept.
+type mediaRange struct {
+	typ, subtype string
+	q            float64
+}
+
+// parseAccept разбирает заголовок Accept. Элементы с некорректным весом пропускаются.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		typ, subtype, ok := strings.Cut(strings.ToLower(strings.TrimSpace(params[0])), "/")
+		if !ok || typ == "" || subtype == "" {
+			continue
+		}
+
+		mr := mediaRange{typ: typ, subtype: subtype, q: 1}
+		for _, param := range params[1:] {
+			name, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.ToLower(name) != "q" {
+				continue
+			}
+			q, err := strconv.ParseFloat(value, 64)
+			if err != nil || q < 0 || q > 1 {
+				mr.q = -1
+			} else {
+				mr.q = q
+			}
+		}
+		if mr.q >= 0 {
+			ranges = append(ranges, mr)
+		}
+	}
+	return ranges
+}
+
+// Negotiate выбирает из offers тип содержимого, который клиент принимает с
+// наибольшим весом. Вес типа берется из самого точного подходящего элемента
+// Accept; при равных весах побеждает тип, стоящий в offers раньше. Пустой
+// Accept означает первый тип из offers. ok ложно, если клиент не принимает
+// ни один из типов.
+func Negotiate(accept string, offers []string) (mediaType string, ok bool) {
+	if strings.TrimSpace(accept) == "" {
+		return offers[0], true
+	}
+	ranges := parseAccept(accept)
+
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		typ, subtype, _ := strings.Cut(offer, "/")
+		q, specificity := 0.0, -1
+		for _, mr := range ranges {
+			s := -1
+			switch {
+			case mr.typ == typ && mr.subtype == subtype:
+				s = 2
+			case mr.typ == typ && mr.subtype == "*":
+				s = 1
+			case mr.typ == "*" && mr.subtype == "*":
+				s = 0
+			}
+			if s > specificity {
+				q, specificity = mr.q, s
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, best != ""
+}
@@ -0,0 +1,114 @@
+// Package response - problem.go
+package response
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// Problem описание ошибки по RFC 7807. Code машиночитаемый код ошибки,
+// RequestID идентификатор запроса для поиска в журналах.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	RequestID string `json:"requestId,omitempty"`
+	// Extensions дополнительные поля ошибки, которые записываются рядом со стандартными.
+	Extensions map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON записывает дополнительные поля на верхний уровень объекта.
+// Стандартные поля дополнительными не перекрываются.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	base, err := json.Marshal(problem(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return base, err
+	}
+
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(base, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range p.Extensions {
+		if _, ok := fields[key]; ok {
+			continue
+		}
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		fields[key] = raw
+	}
+	return json.Marshal(fields)
+}
+
+// codes коды ошибок по умолчанию для HTTP-статусов.
+var codes = map[int]string{
+	http.StatusBadRequest:            "bad_request",
+	http.StatusUnauthorized:          "unauthenticated",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusNotAcceptable:         "not_acceptable",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "payload_too_large",
+	http.StatusUnsupportedMediaType:  "unsupported_media_type",
+	http.StatusUnprocessableEntity:   "unprocessable_entity",
+	http.StatusTooManyRequests:       "rate_limited",
+	http.StatusInternalServerError:   "internal_error",
+	http.StatusBadGateway:            "bad_gateway",
+	http.StatusServiceUnavailable:    "unavailable",
+	http.StatusGatewayTimeout:        "gateway_timeout",
+}
+
+// CodeFor возвращает код ошибки по умолчанию для статуса.
+func CodeFor(status int) string {
+	if code, ok := codes[status]; ok {
+		return code
+	}
+	if status >= 500 {
+		return "internal_error"
+	}
+	return "error"
+}
+
+// Error отправляет ошибку со статусом status, описанием detail и кодом по умолчанию.
+func Error(w http.ResponseWriter, r *http.Request, status int, detail string) {
+	WriteProblem(w, r, Problem{Status: status, Detail: detail})
+}
+
+// WriteProblem отправляет ошибку в формате application/problem+json. Пустые
+// поля Type, Title, Code, Instance и RequestID заполняются по статусу и запросу.
+// Ошибки всегда отправляются в JSON, независимо от заголовка Accept.
+func WriteProblem(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(p.Status)
+	}
+	if p.Code == "" {
+		p.Code = CodeFor(p.Status)
+	}
+	if p.Instance == "" {
+		p.Instance = r.URL.Path
+	}
+	if p.RequestID == "" {
+		p.RequestID = RequestID(r.Context())
+	}
+
+	body, err := marshalJSON(r, p)
+	if err != nil {
+		http.Error(w, p.Detail, p.Status)
+		return
+	}
+	setRequestID(w, r)
+	w.Header().Set("Content-Type", ProblemJSON)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	w.Write(body)
+}
@@ -0,0 +1,19 @@
+// Package response - requestid.go
+package response
+
+import "context"
+
+type contextKey string
+
+const requestIDKey = contextKey("requestID")
+
+// WithRequestID сохраняет идентификатор запроса в контексте.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey, id)
+}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey).(string)
+	return id
+}
@@ -0,0 +1,119 @@
+// Package response - response.go
+package response
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Типы содержимого ответов.
+const (
+	JSON        = "application/json"
+	MessagePack = "application/msgpack"
+	CSV         = "text/csv"
+	ProblemJSON = "application/problem+json"
+)
+
+// formats значения параметра format, заменяющего заголовок Accept.
+var formats = map[string]string{"json": JSON, "msgpack": MessagePack, "csv": CSV}
+
+// Envelope обертка успешных ответов в JSON и MessagePack.
+type Envelope struct {
+	RequestID string      `json:"requestId,omitempty"`
+	Data      interface{} `json:"data"`
+}
+
+// Table ответ, который можно выгрузить в CSV: заголовок и строки таблицы.
+type Table interface {
+	Table() (header []string, rows [][]string)
+}
+
+// Write отправляет payload со статусом code в формате, согласованном с клиентом:
+// по параметру format (json, msgpack, csv) или по заголовку Accept. JSON и
+// MessagePack оборачиваются в Envelope с идентификатором запроса; CSV доступен
+// только для Table. Параметр pretty включает отступы в JSON.
+func Write(w http.ResponseWriter, r *http.Request, code int, payload interface{}) {
+	offers := []string{JSON, MessagePack}
+	table, isTable := payload.(Table)
+	if isTable {
+		offers = append(offers, CSV)
+	}
+
+	mediaType, ok := "", false
+	if format := r.URL.Query().Get("format"); format != "" {
+		mediaType = formats[format]
+		ok = mediaType != "" && (mediaType != CSV || isTable)
+		if !ok {
+			Error(w, r, http.StatusBadRequest, "Unsupported format "+strconv.Quote(format))
+			return
+		}
+	} else if mediaType, ok = Negotiate(r.Header.Get("Accept"), offers); !ok {
+		Error(w, r, http.StatusNotAcceptable, "Supported media types: "+strings.Join(offers, ", "))
+		return
+	}
+
+	var body []byte
+	var err error
+	envelope := Envelope{RequestID: RequestID(r.Context()), Data: payload}
+	switch mediaType {
+	case MessagePack:
+		body, err = MarshalMsgpack(envelope)
+	case CSV:
+		body, err = marshalCSV(table)
+		mediaType += "; charset=utf-8"
+	default:
+		body, err = marshalJSON(r, envelope)
+	}
+	if err != nil {
+		Error(w, r, http.StatusInternalServerError, "Failed to encode response")
+		return
+	}
+
+	setRequestID(w, r)
+	w.Header().Add("Vary", "Accept")
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(code)
+	w.Write(body)
+}
+
+// Decode читает ответ в Envelope и раскладывает данные в v.
+func Decode(body io.Reader, v interface{}) error {
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(body).Decode(&envelope); err != nil {
+		return err
+	}
+	return json.Unmarshal(envelope.Data, v)
+}
+
+// marshalJSON кодирует значение в JSON, с отступами при параметре pretty.
+func marshalJSON(r *http.Request, v interface{}) ([]byte, error) {
+	if pretty, _ := strconv.ParseBool(r.URL.Query().Get("pretty")); pretty {
+		body, err := json.MarshalIndent(v, "", "  ")
+		return append(body, '\n'), err
+	}
+	body, err := json.Marshal(v)
+	return append(body, '\n'), err
+}
+
+func marshalCSV(table Table) ([]byte, error) {
+	header, rows := table.Table()
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(header)
+	writer.WriteAll(rows)
+	return buf.Bytes(), writer.Error()
+}
+
+// setRequestID повторяет идентификатор запроса в заголовке X-Request-ID.
+func setRequestID(w http.ResponseWriter, r *http.Request) {
+	if id := RequestID(r.Context()); id != "" {
+		w.Header().Set("X-Request-ID", id)
+	}
+}
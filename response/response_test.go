@@ -0,0 +1,166 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{JSON, MessagePack, CSV}
+	tt := []struct {
+		accept string
+		want   string
+		ok     bool
+	}{
+		{"", JSON, true},
+		{"*/*", JSON, true},
+		{"application/msgpack", MessagePack, true},
+		{"text/csv, application/json;q=0.5", CSV, true},
+		{"application/*;q=0.2, application/msgpack", MessagePack, true},
+		{"text/*", CSV, true},
+		{"application/json;q=0, */*;q=0.1", MessagePack, true},
+		{"application/xml", "", false},
+		{"application/json;q=abc", "", false},
+	}
+	for _, tc := range tt {
+		got, ok := Negotiate(tc.accept, offers)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("Negotiate(%q) = %q, %v; want %q, %v", tc.accept, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestMarshalMsgpack(t *testing.T) {
+	tt := []struct {
+		name  string
+		value interface{}
+		want  []byte
+	}{
+		{"nil", nil, []byte{0xc0}},
+		{"bool", true, []byte{0xc3}},
+		{"positive fixint", 5, []byte{0x05}},
+		{"negative fixint", -3, []byte{0xfd}},
+		{"int16", 1000, []byte{0xd1, 0x03, 0xe8}},
+		{"float", 1.5, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"fixstr", "ok", []byte{0xa2, 'o', 'k'}},
+		{"array", []int{1, 2}, []byte{0x92, 0x01, 0x02}},
+		{"sorted map", map[string]int{"b": 2, "a": 1}, []byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x02}},
+		{"str8", strings.Repeat("x", 40), append([]byte{0xd9, 40}, strings.Repeat("x", 40)...)},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := MarshalMsgpack(tc.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tc.want) {
+				t.Errorf("expected % x, got % x", tc.want, got)
+			}
+		})
+	}
+}
+
+type testTable []string
+
+func (t testTable) Table() ([]string, [][]string) {
+	rows := make([][]string, len(t))
+	for i, v := range t {
+		rows[i] = []string{v}
+	}
+	return []string{"value"}, rows
+}
+
+func TestWrite(t *testing.T) {
+	tt := []struct {
+		name            string
+		target          string
+		accept          string
+		payload         interface{}
+		wantStatus      int
+		wantContentType string
+		wantBody        string
+	}{
+		{"json envelope", "/", "", map[string]int{"n": 1}, http.StatusOK, JSON, `{"requestId":"req-1","data":{"n":1}}` + "\n"},
+		{"pretty json", "/?pretty=true", "", map[string]int{"n": 1}, http.StatusOK, JSON, "{\n  \"requestId\": \"req-1\",\n  \"data\": {\n    \"n\": 1\n  }\n}\n"},
+		{"msgpack", "/", MessagePack, true, http.StatusOK, MessagePack, "\x82\xa4data\xc3\xa9requestId\xa5req-1"},
+		{"csv table", "/", "text/csv", testTable{"a,b", "c"}, http.StatusOK, "text/csv; charset=utf-8", "value\n\"a,b\"\nc\n"},
+		{"csv by format", "/?format=csv", "application/json", testTable{"c"}, http.StatusOK, "text/csv; charset=utf-8", "value\nc\n"},
+		{"csv not available", "/", "text/csv", map[string]int{}, http.StatusNotAcceptable, ProblemJSON, ""},
+		{"unknown format", "/?format=xml", "", map[string]int{}, http.StatusBadRequest, ProblemJSON, ""},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tc.target, nil)
+			req = req.WithContext(WithRequestID(req.Context(), "req-1"))
+			req.Header.Set("Accept", tc.accept)
+			rr := httptest.NewRecorder()
+			Write(rr, req, http.StatusOK, tc.payload)
+
+			if rr.Code != tc.wantStatus {
+				t.Errorf("expected status %v, got %v", tc.wantStatus, rr.Code)
+			}
+			if got := rr.Header().Get("Content-Type"); got != tc.wantContentType {
+				t.Errorf("expected content type %q, got %q", tc.wantContentType, got)
+			}
+			if tc.wantBody != "" && rr.Body.String() != tc.wantBody {
+				t.Errorf("expected body %q, got %q", tc.wantBody, rr.Body.String())
+			}
+			if got := rr.Header().Get("X-Request-ID"); got != "req-1" {
+				t.Errorf("expected X-Request-ID req-1, got %q", got)
+			}
+		})
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	req := httptest.NewRequest("POST", "/comments/add", nil)
+	req = req.WithContext(WithRequestID(req.Context(), "req-1"))
+	rr := httptest.NewRecorder()
+	WriteProblem(rr, req, Problem{
+		Status:     http.StatusForbidden,
+		Detail:     "Forbidden content",
+		Code:       "forbidden_content",
+		Extensions: map[string]interface{}{"decision": "blocked", "status": 500},
+	})
+
+	if rr.Code != http.StatusForbidden || rr.Header().Get("Content-Type") != ProblemJSON {
+		t.Fatalf("unexpected response %v %q", rr.Code, rr.Header().Get("Content-Type"))
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal(rr.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]interface{}{
+		"type":      "about:blank",
+		"title":     "Forbidden",
+		"status":    float64(403),
+		"detail":    "Forbidden content",
+		"instance":  "/comments/add",
+		"code":      "forbidden_content",
+		"requestId": "req-1",
+		"decision":  "blocked",
+	}
+	for key, value := range want {
+		if got[key] != value {
+			t.Errorf("expected %s = %v, got %v", key, value, got[key])
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	Error(rr, req, http.StatusTooManyRequests, "Slow down")
+	if !strings.Contains(rr.Body.String(), `"code":"rate_limited"`) {
+		t.Errorf("expected default code for 429, got %s", rr.Body.String())
+	}
+}
+
+func TestDecode(t *testing.T) {
+	var v struct{ N int }
+	if err := Decode(strings.NewReader(`{"requestId":"x","data":{"N":3}}`), &v); err != nil || v.N != 3 {
+		t.Errorf("expected N = 3, got %+v, %v", v, err)
+	}
+}
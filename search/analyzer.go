@@ -0,0 +1,82 @@
+// Package search - analyzer.go
+package search
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Token слово текста, приведенное к термину индекса.
+type Token struct {
+	Term string
+	// Position номер слова в тексте. Служебные слова не индексируются,
+	// но занимают позицию, поэтому фразы сохраняют расстояние между словами.
+	Position int
+	// Start и End границы слова в рунах исходного текста.
+	Start, End int
+}
+
+// Analyze разбивает текст на слова, переводит их в нижний регистр, отбрасывает
+// служебные слова и сокращает до основы: кириллицу русским стеммером, латиницу
+// английским. Слова из цифр и смешанных алфавитов не сокращаются.
+func Analyze(text string) []Token {
+	var tokens []Token
+	runes := []rune(text)
+	position := 0
+	for i := 0; i < len(runes); {
+		if !isWordRune(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && isWordRune(runes[i]) {
+			i++
+		}
+
+		word := normalizeWord(runes[start:i])
+		if !stopWords[word] {
+			tokens = append(tokens, Token{Term: stem(word), Position: position, Start: start, End: i})
+		}
+		position++
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// normalizeWord переводит слово в нижний регистр и заменяет ё на е.
+func normalizeWord(runes []rune) string {
+	var b strings.Builder
+	for _, r := range runes {
+		r = unicode.ToLower(r)
+		if r == 'ё' {
+			r = 'е'
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// stem выбирает стеммер по алфавиту слова.
+func stem(word string) string {
+	cyrillic, latin := false, false
+	for _, r := range word {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic = true
+		case r >= 'a' && r <= 'z':
+			latin = true
+		default:
+			return word
+		}
+	}
+	switch {
+	case cyrillic && !latin:
+		return stemRussian(word)
+	case latin && !cyrillic:
+		return stemEnglish(word)
+	}
+	return word
+}
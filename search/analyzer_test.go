@@ -0,0 +1,72 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStemEnglish(t *testing.T) {
+	tt := map[string]string{
+		"caresses":       "caress",
+		"ponies":         "poni",
+		"cats":           "cat",
+		"agreed":         "agre",
+		"hopping":        "hop",
+		"filing":         "file",
+		"happy":          "happi",
+		"relational":     "relat",
+		"electricity":    "electr",
+		"adjustment":     "adjust",
+		"running":        "run",
+		"runs":           "run",
+		"generalization": "gener",
+		"is":             "is",
+	}
+	for word, want := range tt {
+		if got := stemEnglish(word); got != want {
+			t.Errorf("stemEnglish(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestStemRussian(t *testing.T) {
+	tt := map[string]string{
+		"новости":      "новост",
+		"новостей":     "новост",
+		"новость":      "новост",
+		"выборы":       "выбор",
+		"выборах":      "выбор",
+		"красивая":     "красив",
+		"красивые":     "красив",
+		"прочитавшись": "прочита",
+		"читали":       "чита",
+		"важнейший":    "важн",
+		"длинный":      "длин",
+	}
+	for word, want := range tt {
+		if got := stemRussian(word); got != want {
+			t.Errorf("stemRussian(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	tokens := Analyze("The Running of Ёлки и Новости 2024")
+
+	var terms []string
+	var positions []int
+	for _, token := range tokens {
+		terms = append(terms, token.Term)
+		positions = append(positions, token.Position)
+	}
+	if want := []string{"run", "елк", "новост", "2024"}; !reflect.DeepEqual(terms, want) {
+		t.Errorf("expected terms %v, got %v", want, terms)
+	}
+	// Служебные слова занимают позиции.
+	if want := []int{1, 3, 5, 6}; !reflect.DeepEqual(positions, want) {
+		t.Errorf("expected positions %v, got %v", want, positions)
+	}
+	if tokens[1].Start != 15 || tokens[1].End != 19 {
+		t.Errorf("expected rune offsets 15-19 for Ёлки, got %d-%d", tokens[1].Start, tokens[1].End)
+	}
+}
@@ -0,0 +1,304 @@
+// Package search - filter.go
+package search
+
+import (
+	"APIGateway/ingest"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Языки статей, которые различает фильтр.
+const (
+	LangRussian = "ru"
+	LangEnglish = "en"
+)
+
+// Filter условия отбора статей. Статья подходит, если совпадает со всеми
+// заданными условиями; несколько источников или авторов объединяются через "или".
+type Filter struct {
+	Sources []string
+	// Authors подстроки имени автора без учета регистра.
+	Authors []string
+	// After и Before ограничивают дату публикации: After включительно, Before нет.
+	After  time.Time
+	Before time.Time
+	Lang   string
+	// Include и Exclude слова и фразы, которые должны и не должны встречаться
+	// в заголовке или тексте. Слова сравниваются по основам, как в поиске.
+	Include [][]Token
+	Exclude [][]Token
+}
+
+// ParseFilter разбирает запрос фильтра. Запрос состоит из условий через пробел:
+//
+//	source:bbc          источник статьи
+//	author:"John Smith" автор статьи
+//	after:2024-01-01    опубликована не раньше даты
+//	before:2024-02-01   опубликована раньше даты
+//	lang:ru             язык статьи, ru или en
+//	word, "some phrase" слово или фраза в заголовке или тексте
+//	-word, -"phrase"    слово или фраза, которых в статье нет
+//
+// Даты принимаются в виде 2006-01-02 или RFC 3339.
+func ParseFilter(s string) (Filter, error) {
+	var f Filter
+	terms, err := splitFilter(s)
+	if err != nil {
+		return Filter{}, err
+	}
+
+	for _, term := range terms {
+		if field, value, ok := filterField(term.text); ok && !term.quoted {
+			if term.negated {
+				return Filter{}, fmt.Errorf("negation is not supported for %q", field)
+			}
+			if err := f.set(field, value); err != nil {
+				return Filter{}, err
+			}
+			continue
+		}
+
+		tokens := Analyze(term.text)
+		if len(tokens) == 0 {
+			continue
+		}
+		if term.negated {
+			f.Exclude = append(f.Exclude, tokens)
+		} else {
+			f.Include = append(f.Include, tokens)
+		}
+	}
+
+	if !f.After.IsZero() && !f.Before.IsZero() && !f.After.Before(f.Before) {
+		return Filter{}, fmt.Errorf("'after' must be earlier than 'before'")
+	}
+	return f, nil
+}
+
+// set задает условие field.
+func (f *Filter) set(field, value string) error {
+	if value == "" {
+		return fmt.Errorf("%q requires a value", field)
+	}
+	switch field {
+	case "source":
+		f.Sources = append(f.Sources, strings.ToLower(value))
+	case "author":
+		f.Authors = append(f.Authors, strings.ToLower(value))
+	case "after", "before":
+		t, err := parseFilterDate(value)
+		if err != nil {
+			return fmt.Errorf("invalid %q date %q", field, value)
+		}
+		if field == "after" {
+			f.After = t
+		} else {
+			f.Before = t
+		}
+	case "lang":
+		value = strings.ToLower(value)
+		if value != LangRussian && value != LangEnglish {
+			return fmt.Errorf("unsupported language %q", value)
+		}
+		f.Lang = value
+	default:
+		return fmt.Errorf("unknown filter %q", field)
+	}
+	return nil
+}
+
+func parseFilterDate(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// filterTerm условие запроса до разбора.
+type filterTerm struct {
+	text    string
+	negated bool
+	quoted  bool
+}
+
+// splitFilter разбивает запрос на условия по пробелам с учетом кавычек.
+// Кавычки могут окружать и условие целиком, и значение поля: author:"John Smith".
+func splitFilter(s string) ([]filterTerm, error) {
+	var terms []filterTerm
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		var term filterTerm
+		if runes[i] == '-' {
+			term.negated = true
+			i++
+		}
+		var b strings.Builder
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			if runes[i] != '"' {
+				b.WriteRune(runes[i])
+				i++
+				continue
+			}
+			// Значение поля в кавычках остается полем, остальное в кавычках — фраза.
+			term.quoted = b.Len() == 0
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("unterminated quote")
+			}
+			b.WriteString(string(runes[i+1 : end]))
+			i = end + 1
+		}
+		term.text = b.String()
+		if term.text != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms, nil
+}
+
+// filterField выделяет поле из условия вида field:value. Полем считается
+// только латинское слово, поэтому время вида 10:30 остается словом.
+func filterField(text string) (field, value string, ok bool) {
+	field, value, ok = strings.Cut(text, ":")
+	if !ok || field == "" {
+		return "", "", false
+	}
+	for _, r := range field {
+		if r < 'a' || r > 'z' {
+			return "", "", false
+		}
+	}
+	return field, value, true
+}
+
+// Empty сообщает, что фильтр не задает условий.
+func (f Filter) Empty() bool {
+	return len(f.Sources) == 0 && len(f.Authors) == 0 && f.After.IsZero() && f.Before.IsZero() &&
+		f.Lang == "" && len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// Query возвращает условия фильтра, которые может проверить хранилище статей:
+// источники, авторов и даты. Язык и слова проверяет только Match.
+func (f Filter) Query() ingest.Query {
+	return ingest.Query{Sources: f.Sources, Authors: f.Authors, After: f.After, Before: f.Before}
+}
+
+// Match проверяет, подходит ли статья под фильтр.
+func (f Filter) Match(a ingest.Article) bool {
+	if len(f.Sources) > 0 && !containsString(f.Sources, strings.ToLower(a.Source)) {
+		return false
+	}
+	if len(f.Authors) > 0 {
+		author := strings.ToLower(a.Author)
+		found := false
+		for _, name := range f.Authors {
+			if strings.Contains(author, name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !f.After.IsZero() && a.PublishedAt.Before(f.After) {
+		return false
+	}
+	if !f.Before.IsZero() && !a.PublishedAt.Before(f.Before) {
+		return false
+	}
+	if f.Lang != "" && DetectLanguage(a.Title+" "+a.Content) != f.Lang {
+		return false
+	}
+	if len(f.Include) == 0 && len(f.Exclude) == 0 {
+		return true
+	}
+
+	// Текст продолжает нумерацию заголовка с пропуском, чтобы фраза их не склеивала.
+	tokens := Analyze(a.Title)
+	offset := 1
+	if len(tokens) > 0 {
+		offset = tokens[len(tokens)-1].Position + 2
+	}
+	for _, t := range Analyze(a.Content) {
+		t.Position += offset
+		tokens = append(tokens, t)
+	}
+	for _, phrase := range f.Include {
+		if !containsPhrase(tokens, phrase) {
+			return false
+		}
+	}
+	for _, phrase := range f.Exclude {
+		if containsPhrase(tokens, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+// containsPhrase проверяет, что слова фразы встречаются в тексте на тех же
+// расстояниях, что и в запросе. Фраза из одного слова ищется где угодно.
+func containsPhrase(tokens, phrase []Token) bool {
+	positions := make(map[int]string, len(tokens))
+	for _, t := range tokens {
+		positions[t.Position] = t.Term
+	}
+	first := phrase[0]
+	for _, t := range tokens {
+		if t.Term != first.Term {
+			continue
+		}
+		found := true
+		for _, p := range phrase[1:] {
+			if positions[t.Position+p.Position-first.Position] != p.Term {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectLanguage определяет язык текста по преобладающему алфавиту: ru для
+// кириллицы, en для латиницы. Пустая строка означает, что букв в тексте нет.
+func DetectLanguage(text string) string {
+	cyrillic, latin := 0, 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	switch {
+	case cyrillic == 0 && latin == 0:
+		return ""
+	case cyrillic >= latin:
+		return LangRussian
+	}
+	return LangEnglish
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
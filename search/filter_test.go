@@ -0,0 +1,109 @@
+package search
+
+import (
+	"APIGateway/database"
+	"APIGateway/ingest"
+	"testing"
+	"time"
+)
+
+func TestParseFilter(t *testing.T) {
+	tt := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{"empty", "", false},
+		{"all fields", `source:bbc source:nyt author:"John Smith" after:2024-01-01 before:2024-02-01T00:00:00Z lang:en election -sport -"red card"`, false},
+		{"time is a keyword", "10:30", false},
+		{"unknown field", "color:red", true},
+		{"missing value", "source:", true},
+		{"invalid date", "after:yesterday", true},
+		{"inverted range", "after:2024-02-01 before:2024-01-01", true},
+		{"unsupported language", "lang:de", true},
+		{"negated field", "-source:bbc", true},
+		{"unterminated quote", `"red card`, true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseFilter(tc.query)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+
+	f, _ := ParseFilter(`source:BBC author:"John Smith" -"red card" goal`)
+	if len(f.Sources) != 1 || f.Sources[0] != "bbc" || len(f.Authors) != 1 || f.Authors[0] != "john smith" {
+		t.Errorf("unexpected fields: %+v", f)
+	}
+	if len(f.Exclude) != 1 || len(f.Exclude[0]) != 2 || len(f.Include) != 1 {
+		t.Errorf("unexpected keywords: %+v", f)
+	}
+}
+
+func TestFilterMatch(t *testing.T) {
+	published := "2024-01-15T10:00:00Z"
+	article := func(source string, n repository.News) ingest.Article {
+		if n.Published == "" {
+			n.Published = published
+		}
+		a, _ := ingest.Normalize(source, n, time.Now())
+		return a
+	}
+	football := article("bbc", repository.News{Title: "Football final", Author: "John Smith", Content: "A red card decided the match."})
+	elections := article("nyt", repository.News{Title: "Выборы в парламент", Author: "Анна", Content: "Итоги голосования подведены."})
+
+	tt := []struct {
+		query   string
+		article ingest.Article
+		want    bool
+	}{
+		{"", football, true},
+		{"source:bbc", football, true},
+		{"source:nyt source:cnn", football, false},
+		{"author:smith", football, true},
+		{"author:anna", football, false},
+		{"after:2024-01-15", football, true},
+		{"after:2024-01-16", football, false},
+		{"before:2024-01-15", football, false},
+		{"lang:en", football, true},
+		{"lang:ru", elections, true},
+		{"lang:ru", football, false},
+		{"finals", football, true},
+		{"выбор парламента", elections, true},
+		{"-sport", football, true},
+		{"-matches", football, false},
+		{`"red card"`, football, true},
+		{`-"red card"`, football, false},
+		{`"card red"`, football, false},
+		{`"final red"`, football, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.query, func(t *testing.T) {
+			f, err := ParseFilter(tc.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := f.Match(tc.article); got != tc.want {
+				t.Errorf("expected %v, got %v for %q", tc.want, got, tc.article.Title)
+			}
+		})
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	tt := map[string]string{
+		"Hello world":            LangEnglish,
+		"Привет, мир":            LangRussian,
+		"Новости BBC на русском": LangRussian,
+		"2024":                   "",
+	}
+	for text, want := range tt {
+		if got := DetectLanguage(text); got != want {
+			t.Errorf("DetectLanguage(%q) = %q, want %q", text, got, want)
+		}
+	}
+}
@@ -0,0 +1,325 @@
+// Package search - index.go
+package search
+
+import (
+	"html"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Параметры ранжирования BM25.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+	// titleBoost вес совпадения в заголовке относительно совпадения в тексте.
+	titleBoost = 2
+)
+
+// HighlightRunes наибольшая длина фрагмента с подсветкой.
+var HighlightRunes = 200
+
+// Document документ индекса. Value возвращается в результатах поиска как есть.
+type Document struct {
+	ID    string
+	Title string
+	Text  string
+	Value interface{}
+}
+
+// Hit найденный документ.
+type Hit struct {
+	ID    string  `json:"id"`
+	Score float64 `json:"score"`
+	// Highlight фрагмент текста, в котором совпавшие слова обернуты в <mark>.
+	// Остальной текст экранирован для HTML.
+	Highlight string      `json:"highlight"`
+	Item      interface{} `json:"item"`
+}
+
+type document struct {
+	Document
+	// length взвешенное число терминов документа.
+	length float64
+	// freqs взвешенная частота каждого термина.
+	freqs map[string]float64
+}
+
+// Index обратный индекс с ранжированием BM25. Index безопасен для
+// одновременного использования.
+type Index struct {
+	mu   sync.RWMutex
+	docs map[string]*document
+	// postings позиции термина в каждом документе.
+	postings map[string]map[string][]int
+	total    float64
+}
+
+// NewIndex создает пустой индекс.
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[string]*document),
+		postings: make(map[string]map[string][]int),
+	}
+}
+
+// Len возвращает число документов индекса.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.docs)
+}
+
+// Add добавляет документ или заменяет документ с тем же ID.
+func (ix *Index) Add(doc Document) {
+	d := &document{Document: doc, freqs: make(map[string]float64)}
+	positions := make(map[string][]int)
+
+	title := Analyze(doc.Title)
+	// Текст продолжает нумерацию заголовка с пропуском, чтобы фраза не
+	// склеивала конец заголовка с началом текста.
+	offset := 1
+	for _, t := range title {
+		positions[t.Term] = append(positions[t.Term], t.Position)
+		d.freqs[t.Term] += titleBoost
+		d.length += titleBoost
+		offset = t.Position + 2
+	}
+	for _, t := range Analyze(doc.Text) {
+		positions[t.Term] = append(positions[t.Term], offset+t.Position)
+		d.freqs[t.Term]++
+		d.length++
+	}
+
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(doc.ID)
+	ix.docs[doc.ID] = d
+	ix.total += d.length
+	for term, p := range positions {
+		if ix.postings[term] == nil {
+			ix.postings[term] = make(map[string][]int)
+		}
+		ix.postings[term][doc.ID] = p
+	}
+}
+
+// Remove удаляет документ из индекса.
+func (ix *Index) Remove(id string) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.remove(id)
+}
+
+// remove удаляет документ. Вызывается под ix.mu.
+func (ix *Index) remove(id string) {
+	d, ok := ix.docs[id]
+	if !ok {
+		return
+	}
+	for term := range d.freqs {
+		delete(ix.postings[term], id)
+		if len(ix.postings[term]) == 0 {
+			delete(ix.postings, term)
+		}
+	}
+	ix.total -= d.length
+	delete(ix.docs, id)
+}
+
+// query разобранный поисковый запрос.
+type query struct {
+	terms   []string
+	phrases [][]Token
+}
+
+// parseQuery разбирает запрос: слова в кавычках образуют фразу, которая должна
+// встретиться в документе подряд, остальные слова должны встретиться в любом месте.
+func parseQuery(s string) query {
+	var q query
+	seen := make(map[string]bool)
+	add := func(tokens []Token) {
+		for _, t := range tokens {
+			if !seen[t.Term] {
+				seen[t.Term] = true
+				q.terms = append(q.terms, t.Term)
+			}
+		}
+	}
+
+	parts := strings.Split(s, `"`)
+	for i, part := range parts {
+		tokens := Analyze(part)
+		add(tokens)
+		// Нечетные части стоят внутри кавычек. Незакрытая кавычка не образует фразу.
+		if i%2 == 1 && i < len(parts)-1 && len(tokens) > 1 {
+			q.phrases = append(q.phrases, tokens)
+		}
+	}
+	return q
+}
+
+// Search ищет документы, содержащие все слова и фразы запроса, и возвращает
+// страницу из limit результатов после offset, упорядоченных по BM25, и общее
+// число найденных документов. limit 0 возвращает все результаты.
+func (ix *Index) Search(s string, limit, offset int) ([]Hit, int) {
+	q := parseQuery(s)
+	if len(q.terms) == 0 {
+		return nil, 0
+	}
+
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+
+	// Кандидаты ищутся от самого редкого термина.
+	terms := append([]string(nil), q.terms...)
+	sort.Slice(terms, func(i, j int) bool { return len(ix.postings[terms[i]]) < len(ix.postings[terms[j]]) })
+
+	var hits []Hit
+	for id := range ix.postings[terms[0]] {
+		if !ix.matches(id, terms[1:], q.phrases) {
+			continue
+		}
+		hits = append(hits, Hit{ID: id, Score: ix.score(ix.docs[id], q.terms)})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+
+	total := len(hits)
+	if offset < 0 {
+		offset = 0
+	}
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	hits = hits[offset:end]
+
+	marked := make(map[string]bool, len(q.terms))
+	for _, term := range q.terms {
+		marked[term] = true
+	}
+	for i := range hits {
+		d := ix.docs[hits[i].ID]
+		hits[i].Item = d.Value
+		if fragment, ok := highlight(d.Text, marked); ok {
+			hits[i].Highlight = fragment
+		} else {
+			hits[i].Highlight, _ = highlight(d.Title, marked)
+		}
+	}
+	return hits, total
+}
+
+// matches проверяет, что документ содержит все термины и фразы.
+func (ix *Index) matches(id string, terms []string, phrases [][]Token) bool {
+	for _, term := range terms {
+		if _, ok := ix.postings[term][id]; !ok {
+			return false
+		}
+	}
+	for _, phrase := range phrases {
+		if !ix.hasPhrase(id, phrase) {
+			return false
+		}
+	}
+	return true
+}
+
+// hasPhrase проверяет, что слова фразы стоят в документе на тех же расстояниях,
+// что и в запросе.
+func (ix *Index) hasPhrase(id string, phrase []Token) bool {
+	first := phrase[0]
+	for _, start := range ix.postings[first.Term][id] {
+		found := true
+		for _, t := range phrase[1:] {
+			if !containsInt(ix.postings[t.Term][id], start+t.Position-first.Position) {
+				found = false
+				break
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(sorted []int, v int) bool {
+	i := sort.SearchInts(sorted, v)
+	return i < len(sorted) && sorted[i] == v
+}
+
+// score вычисляет BM25 документа для терминов запроса.
+func (ix *Index) score(d *document, terms []string) float64 {
+	n := float64(len(ix.docs))
+	avg := ix.total / n
+	var score float64
+	for _, term := range terms {
+		tf := d.freqs[term]
+		df := float64(len(ix.postings[term]))
+		idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+		score += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*d.length/avg))
+	}
+	return score
+}
+
+// highlight вырезает из текста фрагмент вокруг первого совпавшего слова
+// и оборачивает совпавшие слова в <mark>. ok ложно, если совпадений нет.
+func highlight(text string, marked map[string]bool) (fragment string, ok bool) {
+	runes := []rune(text)
+	var matched []Token
+	for _, t := range Analyze(text) {
+		if marked[t.Term] {
+			matched = append(matched, t)
+		}
+	}
+	if len(matched) == 0 {
+		return "", false
+	}
+
+	// Фрагмент начинается с начала слова незадолго до первого совпадения.
+	start := matched[0].Start - HighlightRunes/4
+	if start < 0 {
+		start = 0
+	}
+	for start > 0 && isWordRune(runes[start-1]) {
+		start--
+	}
+	end := start + HighlightRunes
+	if end > len(runes) {
+		end = len(runes)
+	}
+	for end < len(runes) && end > matched[0].End && isWordRune(runes[end]) {
+		end--
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("…")
+	}
+	pos := start
+	for _, t := range matched {
+		if t.Start < start || t.End > end {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:t.Start])))
+		b.WriteString("<mark>")
+		b.WriteString(html.EscapeString(string(runes[t.Start:t.End])))
+		b.WriteString("</mark>")
+		pos = t.End
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:end])))
+	if end < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
@@ -0,0 +1,130 @@
+package search
+
+import (
+	"APIGateway/database"
+	"testing"
+)
+
+func newTestIndex() *Index {
+	ix := NewIndex()
+	ix.Add(Document{ID: "1", Title: "Выборы в парламент", Text: "Итоги выборов подведены. Новый парламент соберется в мае."})
+	ix.Add(Document{ID: "2", Title: "Погода", Text: "В мае ожидается теплая погода, выборы погоде не помешают."})
+	ix.Add(Document{ID: "3", Title: "Football results", Text: "The local team won the final match."})
+	ix.Add(Document{ID: "4", Title: "Finals", Text: "Teams are preparing for the finals <next week>."})
+	return ix
+}
+
+func ids(hits []Hit) []string {
+	var ids []string
+	for _, h := range hits {
+		ids = append(ids, h.ID)
+	}
+	return ids
+}
+
+func TestIndexSearch(t *testing.T) {
+	ix := newTestIndex()
+
+	tt := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"title match ranks higher", "выборы", []string{"1", "2"}},
+		{"inflected form", "парламента", []string{"1"}},
+		{"all words required", "выборы погода", []string{"2"}},
+		{"stemmed english", "teams finals", []string{"4", "3"}},
+		{"phrase", `"final match"`, []string{"3"}},
+		{"phrase with stop word", `"won the final"`, []string{"3"}},
+		{"phrase order matters", `"match final"`, nil},
+		{"phrase does not span title and text", `"погода в мае"`, nil},
+		{"only stop words", "the и", nil},
+		{"unknown word", "election", nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			hits, total := ix.Search(tc.query, 0, 0)
+			got := ids(hits)
+			if len(got) != len(tc.want) || total != len(tc.want) {
+				t.Fatalf("expected %v, got %v (total %d)", tc.want, got, total)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("expected %v, got %v", tc.want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestIndexPagination(t *testing.T) {
+	ix := newTestIndex()
+
+	hits, total := ix.Search("выборы", 1, 1)
+	if total != 2 || len(hits) != 1 || hits[0].ID != "2" {
+		t.Errorf("expected second hit of 2, got %v (total %d)", ids(hits), total)
+	}
+	if hits, _ := ix.Search("выборы", 10, 5); len(hits) != 0 {
+		t.Errorf("expected empty page, got %v", ids(hits))
+	}
+}
+
+func TestIndexUpdateAndRemove(t *testing.T) {
+	ix := newTestIndex()
+
+	ix.Add(Document{ID: "3", Title: "Chess", Text: "A quiet game."})
+	if hits, _ := ix.Search("match", 0, 0); len(hits) != 0 {
+		t.Errorf("expected replaced document to drop old terms, got %v", ids(hits))
+	}
+	ix.Remove("4")
+	if hits, _ := ix.Search("finals", 0, 0); len(hits) != 0 {
+		t.Errorf("expected removed document to disappear, got %v", ids(hits))
+	}
+	if ix.Len() != 3 {
+		t.Errorf("expected 3 documents, got %d", ix.Len())
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	ix := newTestIndex()
+
+	hits, _ := ix.Search("finals", 0, 0)
+	if len(hits) != 2 {
+		t.Fatalf("expected 2 hits, got %v", ids(hits))
+	}
+	want := "Teams are preparing for the <mark>finals</mark> &lt;next week&gt;."
+	if hits[0].Highlight != want {
+		t.Errorf("expected highlight %q, got %q", want, hits[0].Highlight)
+	}
+
+	HighlightRunes = 20
+	defer func() { HighlightRunes = 200 }()
+	fragment, _ := highlight("Один два три четыре пять шесть семь восемь девять десять", map[string]bool{"восем": true})
+	if want := "…семь <mark>восемь</mark> девять…"; fragment != want {
+		t.Errorf("expected fragment %q, got %q", want, fragment)
+	}
+}
+
+func TestCommentIndexer(t *testing.T) {
+	ix := NewIndex()
+	indexer := CommentIndexer{Index: ix}
+
+	comment := repository.Comment{ID: 7, NewsID: 1, Text: "Отличная статья", Status: repository.StatusApproved}
+	indexer.Publish(repository.CommentEvent{Type: repository.EventCreated, Comment: comment})
+	hits, _ := ix.Search("статьи", 0, 0)
+	if len(hits) != 1 || hits[0].Item.(repository.Comment).ID != 7 {
+		t.Fatalf("expected comment 7, got %+v", hits)
+	}
+
+	comment.Text = "Так себе"
+	indexer.Publish(repository.CommentEvent{Type: repository.EventUpdated, Comment: comment})
+	if hits, _ := ix.Search("статья", 0, 0); len(hits) != 0 {
+		t.Errorf("expected updated text to replace the old one, got %v", ids(hits))
+	}
+
+	indexer.Publish(repository.CommentEvent{Type: repository.EventDeleted, Comment: repository.Comment{ID: 7, NewsID: 1}})
+	if ix.Len() != 0 {
+		t.Errorf("expected deleted comment to be removed, got %d documents", ix.Len())
+	}
+}
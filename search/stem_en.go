@@ -0,0 +1,214 @@
+// Package search - stem_en.go
+package search
+
+import "strings"
+
+// stemEnglish сокращает английское слово до основы по алгоритму Портера.
+// Слова короче трех букв и слова не из латиницы возвращаются без изменений.
+func stemEnglish(word string) string {
+	if len(word) <= 2 {
+		return word
+	}
+	for i := 0; i < len(word); i++ {
+		if word[i] < 'a' || word[i] > 'z' {
+			return word
+		}
+	}
+
+	word = enStep1a(word)
+	word = enStep1b(word)
+	word = enStep1c(word)
+	word = enReplace(word, enStep2, 0)
+	word = enReplace(word, enStep3, 0)
+	word = enStep4(word)
+	word = enStep5(word)
+	return word
+}
+
+// enConsonant сообщает, стоит ли в позиции i согласная. Y считается согласной
+// в начале слова и после гласной.
+func enConsonant(w string, i int) bool {
+	switch w[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !enConsonant(w, i-1)
+	}
+	return true
+}
+
+// enMeasure число последовательностей гласная-согласная в основе.
+func enMeasure(w string) int {
+	m, i := 0, 0
+	for i < len(w) && enConsonant(w, i) {
+		i++
+	}
+	for i < len(w) {
+		for i < len(w) && !enConsonant(w, i) {
+			i++
+		}
+		if i == len(w) {
+			break
+		}
+		for i < len(w) && enConsonant(w, i) {
+			i++
+		}
+		m++
+	}
+	return m
+}
+
+func enHasVowel(w string) bool {
+	for i := range w {
+		if !enConsonant(w, i) {
+			return true
+		}
+	}
+	return false
+}
+
+// enDoubleConsonant сообщает, оканчивается ли основа двойной согласной.
+func enDoubleConsonant(w string) bool {
+	n := len(w)
+	return n >= 2 && w[n-1] == w[n-2] && enConsonant(w, n-1)
+}
+
+// enCVC сообщает, оканчивается ли основа на согласную-гласную-согласную,
+// где последняя согласная не w, x и y.
+func enCVC(w string) bool {
+	n := len(w)
+	if n < 3 || !enConsonant(w, n-1) || enConsonant(w, n-2) || !enConsonant(w, n-3) {
+		return false
+	}
+	switch w[n-1] {
+	case 'w', 'x', 'y':
+		return false
+	}
+	return true
+}
+
+func enStep1a(w string) string {
+	switch {
+	case strings.HasSuffix(w, "sses"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ies"):
+		return w[:len(w)-2]
+	case strings.HasSuffix(w, "ss"):
+		return w
+	case strings.HasSuffix(w, "s"):
+		return w[:len(w)-1]
+	}
+	return w
+}
+
+func enStep1b(w string) string {
+	if strings.HasSuffix(w, "eed") {
+		if enMeasure(w[:len(w)-3]) > 0 {
+			return w[:len(w)-1]
+		}
+		return w
+	}
+
+	var stem string
+	switch {
+	case strings.HasSuffix(w, "ed") && enHasVowel(w[:len(w)-2]):
+		stem = w[:len(w)-2]
+	case strings.HasSuffix(w, "ing") && enHasVowel(w[:len(w)-3]):
+		stem = w[:len(w)-3]
+	default:
+		return w
+	}
+
+	switch {
+	case strings.HasSuffix(stem, "at"), strings.HasSuffix(stem, "bl"), strings.HasSuffix(stem, "iz"):
+		return stem + "e"
+	case enDoubleConsonant(stem):
+		switch stem[len(stem)-1] {
+		case 'l', 's', 'z':
+			return stem
+		}
+		return stem[:len(stem)-1]
+	case enMeasure(stem) == 1 && enCVC(stem):
+		return stem + "e"
+	}
+	return stem
+}
+
+func enStep1c(w string) string {
+	if strings.HasSuffix(w, "y") && enHasVowel(w[:len(w)-1]) {
+		return w[:len(w)-1] + "i"
+	}
+	return w
+}
+
+// suffixRule замена окончания основы.
+type suffixRule struct {
+	suffix, replacement string
+}
+
+var enStep2 = []suffixRule{
+	{"ational", "ate"}, {"tional", "tion"}, {"enci", "ence"}, {"anci", "ance"},
+	{"izer", "ize"}, {"bli", "ble"}, {"alli", "al"}, {"entli", "ent"},
+	{"eli", "e"}, {"ousli", "ous"}, {"ization", "ize"}, {"ation", "ate"},
+	{"ator", "ate"}, {"alism", "al"}, {"iveness", "ive"}, {"fulness", "ful"},
+	{"ousness", "ous"}, {"aliti", "al"}, {"iviti", "ive"}, {"biliti", "ble"},
+}
+
+var enStep3 = []suffixRule{
+	{"icate", "ic"}, {"ative", ""}, {"alize", "al"}, {"iciti", "ic"},
+	{"ical", "ic"}, {"ful", ""}, {"ness", ""},
+}
+
+var enStep4Suffixes = []string{
+	"ement", "ment", "ent", "ance", "ence", "able", "ible", "ant", "ion",
+	"ism", "ate", "iti", "ous", "ive", "ize", "al", "er", "ic", "ou",
+}
+
+// enReplace заменяет самое длинное подходящее окончание, если мера основы
+// больше minMeasure. Найденное окончание больше не ищется среди коротких.
+func enReplace(w string, rules []suffixRule, minMeasure int) string {
+	best := -1
+	for i, rule := range rules {
+		if strings.HasSuffix(w, rule.suffix) && (best < 0 || len(rule.suffix) > len(rules[best].suffix)) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return w
+	}
+	stem := w[:len(w)-len(rules[best].suffix)]
+	if enMeasure(stem) > minMeasure {
+		return stem + rules[best].replacement
+	}
+	return w
+}
+
+func enStep4(w string) string {
+	for _, suffix := range enStep4Suffixes {
+		if !strings.HasSuffix(w, suffix) {
+			continue
+		}
+		stem := w[:len(w)-len(suffix)]
+		if enMeasure(stem) <= 1 {
+			return w
+		}
+		if suffix == "ion" && !strings.HasSuffix(stem, "s") && !strings.HasSuffix(stem, "t") {
+			return w
+		}
+		return stem
+	}
+	return w
+}
+
+func enStep5(w string) string {
+	if strings.HasSuffix(w, "e") {
+		stem := w[:len(w)-1]
+		if m := enMeasure(stem); m > 1 || m == 1 && !enCVC(stem) {
+			w = stem
+		}
+	}
+	if strings.HasSuffix(w, "ll") && enMeasure(w) > 1 {
+		w = w[:len(w)-1]
+	}
+	return w
+}
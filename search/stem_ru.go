@@ -0,0 +1,148 @@
+// Package search - stem_ru.go
+package search
+
+import "sort"
+
+// Окончания русского стеммера Snowball. Окончания групп "after а/я" снимаются,
+// только если им предшествует а или я.
+var (
+	ruPerfectiveGerund = ruEndings([]string{"в", "вши", "вшись"}, []string{"ив", "ивши", "ившись", "ыв", "ывши", "ывшись"})
+	ruReflexive        = ruEndings(nil, []string{"ся", "сь"})
+	ruAdjective        = ruEndings(nil, []string{
+		"ее", "ие", "ые", "ое", "ими", "ыми", "ей", "ий", "ый", "ой", "ем", "им", "ым", "ом",
+		"его", "ого", "ему", "ому", "их", "ых", "ую", "юю", "ая", "яя", "ою", "ею",
+	})
+	ruParticiple = ruEndings([]string{"ем", "нн", "вш", "ющ", "щ"}, []string{"ивш", "ывш", "ующ"})
+	ruVerb       = ruEndings(
+		[]string{"ла", "на", "ете", "йте", "ли", "й", "л", "ем", "н", "ло", "но", "ет", "ют", "ны", "ть", "ешь", "нно"},
+		[]string{"ила", "ыла", "ена", "ейте", "уйте", "ите", "или", "ыли", "ей", "уй", "ил", "ыл", "им", "ым", "ен",
+			"ило", "ыло", "ено", "ят", "ует", "уют", "ит", "ыт", "ены", "ить", "ыть", "ишь", "ую", "ю"},
+	)
+	ruNoun = ruEndings(nil, []string{
+		"а", "ев", "ов", "ие", "ье", "е", "иями", "ями", "ами", "еи", "ии", "и", "ией", "ей", "ой", "ий", "й",
+		"иям", "ям", "ием", "ем", "ам", "ом", "о", "у", "ах", "иях", "ях", "ы", "ь", "ию", "ью", "ю", "ия", "ья", "я",
+	})
+	ruSuperlative  = ruEndings(nil, []string{"ейш", "ейше"})
+	ruDerivational = ruEndings(nil, []string{"ост", "ость"})
+	ruNN           = []rune("нн")
+)
+
+type ruEnding struct {
+	runes []rune
+	// afterAYa окончание снимается только после а или я.
+	afterAYa bool
+}
+
+// ruEndings объединяет группы окончаний, упорядочивая их от длинных к коротким.
+func ruEndings(afterAYa, plain []string) []ruEnding {
+	var endings []ruEnding
+	for _, e := range afterAYa {
+		endings = append(endings, ruEnding{runes: []rune(e), afterAYa: true})
+	}
+	for _, e := range plain {
+		endings = append(endings, ruEnding{runes: []rune(e)})
+	}
+	sort.SliceStable(endings, func(i, j int) bool { return len(endings[i].runes) > len(endings[j].runes) })
+	return endings
+}
+
+func isRuVowel(r rune) bool {
+	switch r {
+	case 'а', 'е', 'и', 'о', 'у', 'ы', 'э', 'ю', 'я':
+		return true
+	}
+	return false
+}
+
+// stemRussian сокращает русское слово до основы по алгоритму Snowball.
+// Слово должно быть в нижнем регистре, ё заменена на е.
+func stemRussian(word string) string {
+	w := []rune(word)
+
+	// rv начало области после первой гласной, r2 — области R2.
+	rv := len(w)
+	for i, r := range w {
+		if isRuVowel(r) {
+			rv = i + 1
+			break
+		}
+	}
+	r1 := ruRegion(w, 0)
+	r2 := ruRegion(w, r1)
+
+	if stem, ok := ruRemove(w, rv, ruPerfectiveGerund); ok {
+		w = stem
+	} else {
+		if stem, ok := ruRemove(w, rv, ruReflexive); ok {
+			w = stem
+		}
+		if stem, ok := ruRemove(w, rv, ruAdjective); ok {
+			w = stem
+			if stem, ok := ruRemove(w, rv, ruParticiple); ok {
+				w = stem
+			}
+		} else if stem, ok := ruRemove(w, rv, ruVerb); ok {
+			w = stem
+		} else if stem, ok := ruRemove(w, rv, ruNoun); ok {
+			w = stem
+		}
+	}
+
+	if len(w) > rv && w[len(w)-1] == 'и' {
+		w = w[:len(w)-1]
+	}
+	if stem, ok := ruRemove(w, r2, ruDerivational); ok {
+		w = stem
+	}
+
+	if stem, ok := ruRemove(w, rv, ruSuperlative); ok {
+		w = stem
+	}
+	switch {
+	case ruHasSuffix(w, ruNN) && len(w)-2 >= rv:
+		w = w[:len(w)-1]
+	case len(w) > rv && w[len(w)-1] == 'ь':
+		w = w[:len(w)-1]
+	}
+	return string(w)
+}
+
+// ruRegion начало области после первой согласной, следующей за гласной, начиная с from.
+func ruRegion(w []rune, from int) int {
+	for i := from + 1; i < len(w); i++ {
+		if !isRuVowel(w[i]) && isRuVowel(w[i-1]) {
+			return i + 1
+		}
+	}
+	return len(w)
+}
+
+// ruRemove снимает самое длинное окончание из endings, лежащее в области от start.
+func ruRemove(w []rune, start int, endings []ruEnding) ([]rune, bool) {
+	for _, e := range endings {
+		if !ruHasSuffix(w, e.runes) {
+			continue
+		}
+		cut := len(w) - len(e.runes)
+		if cut < start {
+			return w, false
+		}
+		if e.afterAYa && (cut-1 < start || w[cut-1] != 'а' && w[cut-1] != 'я') {
+			return w, false
+		}
+		return w[:cut], true
+	}
+	return w, false
+}
+
+func ruHasSuffix(w, suffix []rune) bool {
+	if len(suffix) > len(w) {
+		return false
+	}
+	for i := range suffix {
+		if w[len(w)-len(suffix)+i] != suffix[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,77 @@
+// Package search - sync.go
+package search
+
+import (
+	"APIGateway/database"
+	"APIGateway/ingest"
+	"strconv"
+)
+
+// Типы индексируемых документов.
+const (
+	TypeNews     = "news"
+	TypeComments = "comments"
+)
+
+// reindexBatch число комментариев, загружаемых за один запрос при построении индекса.
+const reindexBatch = 500
+
+// ArticleDocument документ индекса новостей для статьи.
+func ArticleDocument(a ingest.Article) Document {
+	return Document{ID: a.ID, Title: a.Title, Text: a.Content, Value: a.News()}
+}
+
+// CommentDocument документ индекса комментариев.
+func CommentDocument(c repository.Comment) Document {
+	return Document{ID: strconv.Itoa(c.ID), Text: c.Text, Value: c}
+}
+
+// AddArticles добавляет статьи в индекс новостей.
+func (ix *Index) AddArticles(articles []ingest.Article) {
+	for _, a := range articles {
+		ix.Add(ArticleDocument(a))
+	}
+}
+
+// IndexArticles строит индекс новостей по всем статьям хранилища.
+func IndexArticles(ix *Index, store ingest.Store) error {
+	articles, _, err := store.List(ingest.Query{})
+	if err != nil {
+		return err
+	}
+	ix.AddArticles(articles)
+	return nil
+}
+
+// CommentIndexer поддерживает индекс комментариев в актуальном состоянии по
+// событиям репозитория. В индекс попадают только одобренные комментарии.
+type CommentIndexer struct {
+	Index *Index
+}
+
+// Publish применяет событие комментария к индексу.
+func (c CommentIndexer) Publish(event repository.CommentEvent) {
+	if event.Type == repository.EventDeleted {
+		c.Index.Remove(strconv.Itoa(event.Comment.ID))
+		return
+	}
+	c.Index.Add(CommentDocument(event.Comment))
+}
+
+// IndexComments строит индекс комментариев по всем одобренным комментариям репозитория.
+func IndexComments(ix *Index, repo repository.RepositoryInterface) error {
+	afterID := 0
+	for {
+		comments, err := repo.GetApprovedComments(afterID, reindexBatch)
+		if err != nil {
+			return err
+		}
+		for _, c := range comments {
+			ix.Add(CommentDocument(c))
+			afterID = c.ID
+		}
+		if len(comments) < reindexBatch {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Package spam - detector.go
+package spam
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Причины начисления баллов спама.
+const (
+	ReasonDuplicate    = "duplicate_text"
+	ReasonLinks        = "too_many_links"
+	ReasonCaps         = "excessive_caps"
+	ReasonBurst        = "burst_posting"
+	ReasonCrossPosting = "cross_posting"
+)
+
+// Config пороги и веса детектора.
+type Config struct {
+	// DuplicateWindow окно, в котором одинаковый текст от автора или IP считается дублем.
+	DuplicateWindow time.Duration
+	// BurstWindow окно для подсчета частоты публикаций.
+	BurstWindow time.Duration
+	// BurstLimit число публикаций в BurstWindow, после которого начисляется ReasonBurst.
+	BurstLimit int
+	// CrossPostLimit число разных новостей в BurstWindow для ReasonCrossPosting.
+	CrossPostLimit int
+	// MaxLinks допустимое число ссылок в комментарии.
+	MaxLinks int
+	// CapsRatio доля заглавных букв, начиная с которой текст считается «криком».
+	CapsRatio float64
+	// CapsMinLetters минимальное число букв для проверки заглавных.
+	CapsMinLetters int
+
+	Weights map[string]float64
+}
+
+// DefaultConfig возвращает настройки по умолчанию.
+func DefaultConfig() Config {
+	return Config{
+		DuplicateWindow: 10 * time.Minute,
+		BurstWindow:     time.Minute,
+		BurstLimit:      5,
+		CrossPostLimit:  3,
+		MaxLinks:        2,
+		CapsRatio:       0.7,
+		CapsMinLetters:  10,
+		Weights: map[string]float64{
+			ReasonDuplicate:    0.6,
+			ReasonLinks:        0.4,
+			ReasonCaps:         0.3,
+			ReasonBurst:        0.5,
+			ReasonCrossPosting: 0.4,
+		},
+	}
+}
+
+// Submission публикуемый комментарий.
+type Submission struct {
+	Author string
+	IP     string
+	Text   string
+	NewsID int
+}
+
+// Result оценка спама: от 0 до 1 и причины.
+type Result struct {
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+type entry struct {
+	text   string
+	newsID int
+	at     time.Time
+}
+
+// Detector оценивает комментарии по истории публикаций автора и IP-адреса.
+type Detector struct {
+	cfg Config
+	now func() time.Time
+
+	mu      sync.Mutex
+	history map[string][]entry
+	calls   int
+}
+
+// NewDetector создает детектор с указанными настройками.
+func NewDetector(cfg Config) *Detector {
+	return &Detector{cfg: cfg, now: time.Now, history: make(map[string][]entry)}
+}
+
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// Score оценивает публикацию и запоминает ее в истории.
+func (d *Detector) Score(s Submission) Result {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	d.sweep(now)
+
+	text := fingerprint(s.Text)
+	reasons := make(map[string]bool)
+	for _, key := range keys(s) {
+		recent := d.prune(key, now)
+
+		burst := 0
+		newsIDs := map[int]bool{s.NewsID: true}
+		for _, e := range recent {
+			if e.text == text && now.Sub(e.at) <= d.cfg.DuplicateWindow {
+				reasons[ReasonDuplicate] = true
+			}
+			if now.Sub(e.at) <= d.cfg.BurstWindow {
+				burst++
+				newsIDs[e.newsID] = true
+			}
+		}
+		if burst+1 > d.cfg.BurstLimit {
+			reasons[ReasonBurst] = true
+		}
+		if len(newsIDs) >= d.cfg.CrossPostLimit {
+			reasons[ReasonCrossPosting] = true
+		}
+
+		d.history[key] = append(recent, entry{text: text, newsID: s.NewsID, at: now})
+	}
+
+	if len(linkPattern.FindAllString(s.Text, -1)) > d.cfg.MaxLinks {
+		reasons[ReasonLinks] = true
+	}
+	if isShouting(s.Text, d.cfg) {
+		reasons[ReasonCaps] = true
+	}
+
+	return d.result(reasons)
+}
+
+// result суммирует веса причин в порядке их объявления.
+func (d *Detector) result(reasons map[string]bool) Result {
+	var res Result
+	for _, reason := range []string{ReasonDuplicate, ReasonLinks, ReasonCaps, ReasonBurst, ReasonCrossPosting} {
+		if reasons[reason] {
+			res.Reasons = append(res.Reasons, reason)
+			res.Score += d.cfg.Weights[reason]
+		}
+	}
+	if res.Score > 1 {
+		res.Score = 1
+	}
+	return res
+}
+
+// keys ключи истории публикации: автор и IP-адрес.
+func keys(s Submission) []string {
+	var keys []string
+	if s.Author != "" {
+		keys = append(keys, "author:"+strings.ToLower(s.Author))
+	}
+	if s.IP != "" {
+		keys = append(keys, "ip:"+s.IP)
+	}
+	return keys
+}
+
+// prune удаляет из истории ключа записи старше самого длинного окна.
+func (d *Detector) prune(key string, now time.Time) []entry {
+	keep := d.cfg.DuplicateWindow
+	if d.cfg.BurstWindow > keep {
+		keep = d.cfg.BurstWindow
+	}
+
+	recent := d.history[key][:0]
+	for _, e := range d.history[key] {
+		if now.Sub(e.at) <= keep {
+			recent = append(recent, e)
+		}
+	}
+	return recent
+}
+
+// sweep периодически очищает историю неактивных ключей.
+func (d *Detector) sweep(now time.Time) {
+	d.calls++
+	if d.calls%1000 != 0 {
+		return
+	}
+	for key := range d.history {
+		if recent := d.prune(key, now); len(recent) == 0 {
+			delete(d.history, key)
+		} else {
+			d.history[key] = recent
+		}
+	}
+}
+
+// fingerprint приводит текст к виду для сравнения дублей.
+func fingerprint(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// isShouting определяет текст, написанный преимущественно заглавными буквами.
+func isShouting(text string, cfg Config) bool {
+	letters, upper := 0, 0
+	for _, r := range text {
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+	return letters >= cfg.CapsMinLetters && float64(upper)/float64(letters) >= cfg.CapsRatio
+}
@@ -0,0 +1,79 @@
+// detector_test.go
+package spam
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScore(t *testing.T) {
+	tt := []struct {
+		name    string
+		text    string
+		reasons []string
+	}{
+		{"plain comment", "Nice article, thanks!", nil},
+		{"links", "see http://a.example www.b.example https://c.example", []string{ReasonLinks}},
+		{"shouting", "THIS IS THE BEST NEWS EVER", []string{ReasonCaps}},
+		{"short caps", "OK BBC", nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			d := NewDetector(DefaultConfig())
+			res := d.Score(Submission{Author: "john", IP: "10.0.0.1", Text: tc.text, NewsID: 1})
+			if !reflect.DeepEqual(res.Reasons, tc.reasons) {
+				t.Errorf("got reasons %v, want %v", res.Reasons, tc.reasons)
+			}
+		})
+	}
+}
+
+func TestScoreDuplicates(t *testing.T) {
+	now := time.Now()
+	d := NewDetector(DefaultConfig())
+	d.now = func() time.Time { return now }
+
+	d.Score(Submission{Author: "john", IP: "10.0.0.1", Text: "Buy now", NewsID: 1})
+
+	// Тот же текст с другого аккаунта, но с того же IP.
+	res := d.Score(Submission{Author: "jane", IP: "10.0.0.1", Text: "  buy   NOW ", NewsID: 1})
+	if !reflect.DeepEqual(res.Reasons, []string{ReasonDuplicate}) {
+		t.Errorf("expected duplicate, got %v", res.Reasons)
+	}
+
+	now = now.Add(DefaultConfig().DuplicateWindow + time.Second)
+	res = d.Score(Submission{Author: "john", IP: "10.0.0.2", Text: "Buy now", NewsID: 1})
+	if len(res.Reasons) != 0 {
+		t.Errorf("expected duplicate window to expire, got %v", res.Reasons)
+	}
+}
+
+func TestScoreBurstAcrossNews(t *testing.T) {
+	now := time.Now()
+	d := NewDetector(DefaultConfig())
+	d.now = func() time.Time { return now }
+
+	var res Result
+	for i := 1; i <= 5; i++ {
+		now = now.Add(time.Second)
+		res = d.Score(Submission{Author: "bot", IP: "10.0.0.3", Text: "comment " + strings.Repeat("x", i), NewsID: i})
+	}
+
+	want := []string{ReasonCrossPosting}
+	if !reflect.DeepEqual(res.Reasons, want) {
+		t.Errorf("got reasons %v, want %v", res.Reasons, want)
+	}
+
+	now = now.Add(time.Second)
+	res = d.Score(Submission{Author: "bot", IP: "10.0.0.3", Text: "one more", NewsID: 6})
+	want = []string{ReasonBurst, ReasonCrossPosting}
+	if !reflect.DeepEqual(res.Reasons, want) {
+		t.Errorf("got reasons %v, want %v", res.Reasons, want)
+	}
+	if res.Score != 0.9 {
+		t.Errorf("expected score 0.9, got %v", res.Score)
+	}
+}
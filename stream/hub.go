@@ -0,0 +1,134 @@
+// Package stream - hub.go
+package stream
+
+import (
+	"APIGateway/database"
+	"sync"
+)
+
+// Размеры буферов по умолчанию.
+const (
+	// DefaultBuffer число событий, которое хаб держит для медленного подписчика.
+	DefaultBuffer = 64
+	// DefaultHistory число последних событий, доступных для продолжения потока.
+	DefaultHistory = 1024
+)
+
+// Event событие комментария с порядковым номером для продолжения потока.
+type Event struct {
+	ID uint64
+	repository.CommentEvent
+}
+
+// Hub рассылает события комментариев подписчикам новостей. Каждому подписчику
+// выделяется ограниченный буфер: подписчик, который не успевает его разбирать,
+// отключается и может продолжить поток с последнего полученного события.
+type Hub struct {
+	mu      sync.Mutex
+	buffer  int
+	history []Event
+	limit   int
+	lastID  uint64
+	subs    map[int]map[*Subscription]struct{}
+}
+
+// Subscription подписка на события комментариев одной новости.
+type Subscription struct {
+	// C получает события. Канал закрывается при отключении подписчика.
+	C      <-chan Event
+	ch     chan Event
+	newsID int
+	hub    *Hub
+}
+
+// NewHub создает хаб с буфером buffer событий на подписчика и историей history событий.
+func NewHub(buffer, history int) *Hub {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	if history < 0 {
+		history = 0
+	}
+	return &Hub{
+		buffer: buffer,
+		limit:  history,
+		subs:   make(map[int]map[*Subscription]struct{}),
+	}
+}
+
+// Publish присваивает событию номер и рассылает его подписчикам новости комментария.
+// Publish не блокируется: переполненные подписки закрываются.
+func (h *Hub) Publish(ce repository.CommentEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.lastID++
+	event := Event{ID: h.lastID, CommentEvent: ce}
+	if h.limit > 0 {
+		if len(h.history) == h.limit {
+			h.history = append(h.history[:0], h.history[1:]...)
+		}
+		h.history = append(h.history, event)
+	}
+
+	for sub := range h.subs[ce.Comment.NewsID] {
+		select {
+		case sub.ch <- event:
+		default:
+			h.remove(sub)
+		}
+	}
+}
+
+// Subscribe подписывает на события новости newsID. События после lastID, еще
+// хранящиеся в истории, возвращаются в missed. complete ложно, если часть событий
+// после lastID уже вытеснена из истории или lastID выдан до перезапуска хаба:
+// клиенту следует заново загрузить комментарии.
+func (h *Hub) Subscribe(newsID int, lastID uint64) (sub *Subscription, missed []Event, complete bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	switch {
+	case lastID == 0 || lastID == h.lastID:
+		complete = true
+	case lastID > h.lastID:
+		complete = false
+	default:
+		complete = len(h.history) > 0 && h.history[0].ID <= lastID+1
+	}
+	if lastID > 0 {
+		for _, event := range h.history {
+			if event.ID > lastID && event.Comment.NewsID == newsID {
+				missed = append(missed, event)
+			}
+		}
+	}
+
+	ch := make(chan Event, h.buffer)
+	sub = &Subscription{C: ch, ch: ch, newsID: newsID, hub: h}
+	if h.subs[newsID] == nil {
+		h.subs[newsID] = make(map[*Subscription]struct{})
+	}
+	h.subs[newsID][sub] = struct{}{}
+	return sub, missed, complete
+}
+
+// Close отписывает подписчика. Повторный вызов безопасен.
+func (s *Subscription) Close() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.remove(s)
+}
+
+// remove удаляет подписку и закрывает ее канал. Вызывается под h.mu.
+func (h *Hub) remove(sub *Subscription) {
+	subs := h.subs[sub.newsID]
+	if _, ok := subs[sub]; !ok {
+		return
+	}
+	delete(subs, sub)
+	if len(subs) == 0 {
+		delete(h.subs, sub.newsID)
+	}
+	close(sub.ch)
+}
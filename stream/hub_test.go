@@ -0,0 +1,97 @@
+package stream
+
+import (
+	"APIGateway/database"
+	"testing"
+)
+
+func event(newsID, id int) repository.CommentEvent {
+	return repository.CommentEvent{Type: repository.EventCreated, Comment: repository.Comment{ID: id, NewsID: newsID}}
+}
+
+func TestHubFanOut(t *testing.T) {
+	hub := NewHub(4, 10)
+	a, _, _ := hub.Subscribe(1, 0)
+	b, _, _ := hub.Subscribe(1, 0)
+	other, _, _ := hub.Subscribe(2, 0)
+	defer a.Close()
+	defer b.Close()
+	defer other.Close()
+
+	hub.Publish(event(1, 10))
+
+	for _, sub := range []*Subscription{a, b} {
+		select {
+		case e := <-sub.C:
+			if e.ID != 1 || e.Comment.ID != 10 {
+				t.Errorf("unexpected event %+v", e)
+			}
+		default:
+			t.Errorf("expected event for news 1 subscriber")
+		}
+	}
+	select {
+	case e := <-other.C:
+		t.Errorf("unexpected event for news 2 subscriber: %+v", e)
+	default:
+	}
+}
+
+func TestHubDropsSlowSubscriber(t *testing.T) {
+	hub := NewHub(2, 10)
+	sub, _, _ := hub.Subscribe(1, 0)
+
+	for i := 1; i <= 3; i++ {
+		hub.Publish(event(1, i))
+	}
+
+	var received int
+	for range sub.C {
+		received++
+	}
+	if received != 2 {
+		t.Errorf("expected 2 buffered events before disconnect, got %d", received)
+	}
+	// Повторное закрытие уже отключенной подписки безопасно.
+	sub.Close()
+}
+
+func TestHubResume(t *testing.T) {
+	hub := NewHub(4, 3)
+	for i := 1; i <= 5; i++ {
+		hub.Publish(event(1+i%2, i))
+	}
+	// В истории остались события 3, 4 и 5; новости 2 принадлежат 3 и 5.
+
+	tt := []struct {
+		name         string
+		lastID       uint64
+		wantMissed   []uint64
+		wantComplete bool
+	}{
+		{"fresh", 0, nil, true},
+		{"up to date", 5, nil, true},
+		{"resume from history", 2, []uint64{3, 5}, true},
+		{"history evicted", 1, []uint64{3, 5}, false},
+		{"unknown id after restart", 9, nil, false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			sub, missed, complete := hub.Subscribe(2, tc.lastID)
+			defer sub.Close()
+
+			if complete != tc.wantComplete {
+				t.Errorf("expected complete=%v, got %v", tc.wantComplete, complete)
+			}
+			if len(missed) != len(tc.wantMissed) {
+				t.Fatalf("expected missed %v, got %+v", tc.wantMissed, missed)
+			}
+			for i, e := range missed {
+				if e.ID != tc.wantMissed[i] {
+					t.Errorf("expected missed %v, got event %d at %d", tc.wantMissed, e.ID, i)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+// Package stream - news.go
+package stream
+
+import (
+	"APIGateway/database"
+	"sync"
+)
+
+// NewsFeed рассылает подписчикам новые статьи. Как и в Hub, у каждого подписчика
+// ограниченный буфер, а отставший подписчик отключается.
+type NewsFeed struct {
+	mu     sync.Mutex
+	buffer int
+	subs   map[*NewsSubscription]struct{}
+	// seen ключи уже разосланных статей.
+	seen  map[string]bool
+	order []string
+	limit int
+}
+
+// NewsSubscription подписка на новые статьи.
+type NewsSubscription struct {
+	// C получает статьи. Канал закрывается при отключении подписчика.
+	C    <-chan repository.News
+	ch   chan repository.News
+	feed *NewsFeed
+}
+
+// NewNewsFeed создает ленту с буфером buffer статей на подписчика. Лента помнит
+// history последних статей, чтобы не рассылать их повторно.
+func NewNewsFeed(buffer, history int) *NewsFeed {
+	if buffer <= 0 {
+		buffer = DefaultBuffer
+	}
+	if history <= 0 {
+		history = DefaultHistory
+	}
+	return &NewsFeed{
+		buffer: buffer,
+		subs:   make(map[*NewsSubscription]struct{}),
+		seen:   make(map[string]bool),
+		limit:  history,
+	}
+}
+
+// newsKey определяет статью по заголовку и времени публикации.
+func newsKey(n repository.News) string {
+	return n.Title + "\x00" + n.Published
+}
+
+// Publish рассылает статьи, которых лента еще не видела, и возвращает их число.
+func (f *NewsFeed) Publish(news ...repository.News) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	published := 0
+	for _, n := range news {
+		key := newsKey(n)
+		if f.seen[key] {
+			continue
+		}
+		f.remember(key)
+		published++
+
+		for sub := range f.subs {
+			select {
+			case sub.ch <- n:
+			default:
+				f.remove(sub)
+			}
+		}
+	}
+	return published
+}
+
+// remember запоминает ключ статьи, вытесняя самые старые.
+func (f *NewsFeed) remember(key string) {
+	if len(f.order) == f.limit {
+		delete(f.seen, f.order[0])
+		f.order = f.order[1:]
+	}
+	f.seen[key] = true
+	f.order = append(f.order, key)
+}
+
+// Subscribe подписывает на новые статьи.
+func (f *NewsFeed) Subscribe() *NewsSubscription {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan repository.News, f.buffer)
+	sub := &NewsSubscription{C: ch, ch: ch, feed: f}
+	f.subs[sub] = struct{}{}
+	return sub
+}
+
+// Close отписывает подписчика. Повторный вызов безопасен.
+func (s *NewsSubscription) Close() {
+	s.feed.mu.Lock()
+	defer s.feed.mu.Unlock()
+	s.feed.remove(s)
+}
+
+// remove удаляет подписку и закрывает ее канал. Вызывается под f.mu.
+func (f *NewsFeed) remove(sub *NewsSubscription) {
+	if _, ok := f.subs[sub]; !ok {
+		return
+	}
+	delete(f.subs, sub)
+	close(sub.ch)
+}
@@ -0,0 +1,24 @@
+package stream
+
+import (
+	"APIGateway/database"
+	"testing"
+)
+
+func TestNewsFeedPublishesOnlyNew(t *testing.T) {
+	feed := NewNewsFeed(4, 10)
+	sub := feed.Subscribe()
+	defer sub.Close()
+
+	a := repository.News{Title: "A", Published: "2024-01-01"}
+	b := repository.News{Title: "B", Published: "2024-01-01"}
+	if n := feed.Publish(a, b); n != 2 {
+		t.Errorf("expected 2 new articles, got %d", n)
+	}
+	if n := feed.Publish(a); n != 0 {
+		t.Errorf("expected a repeated article to be skipped, got %d", n)
+	}
+	if len(sub.C) != 2 {
+		t.Errorf("expected 2 articles delivered, got %d", len(sub.C))
+	}
+}
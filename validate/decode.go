@@ -0,0 +1,96 @@
+// Package validate - decode.go
+package validate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrBodyTooLarge возвращается, если тело запроса больше допустимого.
+	ErrBodyTooLarge = errors.New("request body too large")
+	// ErrMalformed возвращается, если тело запроса не является JSON-объектом.
+	ErrMalformed = errors.New("malformed JSON body")
+)
+
+// Validatable запрос, который проверяет свои поля после декодирования.
+type Validatable interface {
+	Validate() error
+}
+
+// DecodeJSON читает из тела запроса не больше maxBytes байт и строго декодирует
+// один JSON-объект в v: неизвестные поля и данные после объекта запрещены.
+// Поля readOnly заполняет сервер, их появление в запросе — ошибка поля с кодом
+// CodeReadOnly. Если v реализует Validatable, после декодирования вызывается Validate.
+//
+// Ошибки: ErrBodyTooLarge, ErrMalformed (обернутая) или Errors.
+func DecodeJSON(w http.ResponseWriter, r *http.Request, v interface{}, maxBytes int64, readOnly ...string) error {
+	body := http.MaxBytesReader(w, r.Body, maxBytes)
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return ErrBodyTooLarge
+	} else if err != nil {
+		return fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return decodeError(err, readOnly)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("%w: unexpected data after JSON object", ErrMalformed)
+	}
+
+	if vv, ok := v.(Validatable); ok {
+		return vv.Validate()
+	}
+	return nil
+}
+
+// decodeError переводит ошибку encoding/json в ошибку поля, если она относится к полю.
+func decodeError(err error, readOnly []string) error {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return Errors{{Field: typeErr.Field, Code: CodeInvalidType, Message: "must be " + typeName(typeErr.Type.Kind().String())}}
+	}
+
+	// encoding/json не экспортирует тип ошибки неизвестного поля.
+	if name, ok := strings.CutPrefix(err.Error(), `json: unknown field "`); ok {
+		name = strings.TrimSuffix(name, `"`)
+		for _, field := range readOnly {
+			if field == name {
+				return Errors{{Field: name, Code: CodeReadOnly, Message: "is set by the server"}}
+			}
+		}
+		return Errors{{Field: name, Code: CodeUnknownField, Message: "is not allowed"}}
+	}
+
+	if err == io.EOF {
+		return fmt.Errorf("%w: empty body", ErrMalformed)
+	}
+	return fmt.Errorf("%w: %v", ErrMalformed, err)
+}
+
+// typeName называет тип поля так, как он выглядит в JSON.
+func typeName(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "a number"
+	case kind == "string":
+		return "a string"
+	case kind == "bool":
+		return "a boolean"
+	case kind == "slice", kind == "array":
+		return "an array"
+	}
+	return "an object"
+}
@@ -0,0 +1,125 @@
+// Package validate - validate.go
+package validate
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Коды ошибок полей.
+const (
+	CodeRequired     = "required"
+	CodeTooShort     = "too_short"
+	CodeTooLong      = "too_long"
+	CodeInvalid      = "invalid"
+	CodeInvalidUTF8  = "invalid_utf8"
+	CodeControlChars = "control_characters"
+	CodeInvalidType  = "invalid_type"
+	CodeUnknownField = "unknown_field"
+	CodeReadOnly     = "read_only"
+	CodeNotFound     = "not_found"
+)
+
+// FieldError ошибка в одном поле запроса. Field путь к полю в JSON.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors ошибки валидации запроса по полям.
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Validator собирает ошибки полей. Нулевое значение готово к работе.
+type Validator struct {
+	errs Errors
+}
+
+// Add добавляет ошибку поля.
+func (v *Validator) Add(field, code, message string) {
+	v.errs = append(v.errs, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Has сообщает, есть ли уже ошибка для поля.
+func (v *Validator) Has(field string) bool {
+	for _, fe := range v.errs {
+		if fe.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Err возвращает накопленные ошибки типа Errors или nil.
+func (v *Validator) Err() error {
+	if len(v.errs) == 0 {
+		return nil
+	}
+	return v.errs
+}
+
+// Text проверяет многострочный текст: корректный UTF-8, отсутствие управляющих
+// символов, кроме перевода строки и табуляции, и длину от min до max символов.
+// Пробельные символы по краям в длине не учитываются.
+func (v *Validator) Text(field, s string, min, max int) {
+	v.str(field, s, min, max, true)
+}
+
+// Line проверяет однострочное значение так же, как Text, но без переводов строки.
+func (v *Validator) Line(field, s string, min, max int) {
+	v.str(field, s, min, max, false)
+}
+
+func (v *Validator) str(field, s string, min, max int, multiline bool) {
+	if !utf8.ValidString(s) {
+		v.Add(field, CodeInvalidUTF8, "must be valid UTF-8")
+		return
+	}
+	for _, r := range s {
+		if isControl(r, multiline) {
+			v.Add(field, CodeControlChars, "must not contain control characters")
+			return
+		}
+	}
+
+	n := utf8.RuneCountInString(strings.TrimSpace(s))
+	switch {
+	case n == 0 && min > 0:
+		v.Add(field, CodeRequired, "must not be empty")
+	case n < min:
+		v.Add(field, CodeTooShort, fmt.Sprintf("must be at least %d characters", min))
+	case max > 0 && n > max:
+		v.Add(field, CodeTooLong, fmt.Sprintf("must be at most %d characters", max))
+	}
+}
+
+// Positive проверяет, что идентификатор больше нуля.
+func (v *Validator) Positive(field string, n int) {
+	if n <= 0 {
+		v.Add(field, CodeInvalid, "must be a positive integer")
+	}
+}
+
+// isControl сообщает, запрещен ли символ в строке. Кроме управляющих символов
+// запрещены символы смены направления текста, которыми подменяют видимый текст.
+func isControl(r rune, multiline bool) bool {
+	switch {
+	case r == '\t':
+		return false
+	case r == '\n' || r == '\r':
+		return !multiline
+	case r >= '\u202a' && r <= '\u202e', r >= '\u2066' && r <= '\u2069':
+		return true
+	}
+	return unicode.IsControl(r)
+}
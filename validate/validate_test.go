@@ -0,0 +1,101 @@
+package validate
+
+import (
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidatorStrings(t *testing.T) {
+	tt := []struct {
+		name      string
+		value     string
+		multiline bool
+		wantCode  string
+	}{
+		{"valid line", "John", false, ""},
+		{"blank", "   ", false, CodeRequired},
+		{"too short", "ab", false, CodeTooShort},
+		{"too long", strings.Repeat("я", 11), false, CodeTooLong},
+		{"max in characters", strings.Repeat("я", 10), false, ""},
+		{"invalid utf8", "bad\xff", false, CodeInvalidUTF8},
+		{"nul byte", "a\x00b", true, CodeControlChars},
+		{"newline in line", "a\nb", false, CodeControlChars},
+		{"newline in text", "a\nb\tc", true, ""},
+		{"bidi override", "abc\u202edef", true, CodeControlChars},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var v Validator
+			if tc.multiline {
+				v.Text("f", tc.value, 3, 10)
+			} else {
+				v.Line("f", tc.value, 3, 10)
+			}
+			var got string
+			if errs, ok := v.Err().(Errors); ok {
+				got = errs[0].Code
+			}
+			if got != tc.wantCode {
+				t.Errorf("expected code %q, got %q", tc.wantCode, got)
+			}
+		})
+	}
+}
+
+type testRequest struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func (r *testRequest) Validate() error {
+	var v Validator
+	v.Line("name", r.Name, 1, 10)
+	v.Positive("count", r.Count)
+	return v.Err()
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tt := []struct {
+		name       string
+		body       string
+		wantErr    error
+		wantFields Errors
+	}{
+		{"valid", `{"name":"a","count":1}`, nil, nil},
+		{"unknown field", `{"name":"a","count":1,"extra":true}`, nil, Errors{{Field: "extra", Code: CodeUnknownField, Message: "is not allowed"}}},
+		{"read-only field", `{"id":5,"name":"a","count":1}`, nil, Errors{{Field: "id", Code: CodeReadOnly, Message: "is set by the server"}}},
+		{"wrong type", `{"name":1}`, nil, Errors{{Field: "name", Code: CodeInvalidType, Message: "must be a string"}}},
+		{"all fields validated", `{"name":"","count":0}`, nil, Errors{
+			{Field: "name", Code: CodeRequired, Message: "must not be empty"},
+			{Field: "count", Code: CodeInvalid, Message: "must be a positive integer"},
+		}},
+		{"empty body", ``, ErrMalformed, nil},
+		{"syntax error", `{"name":`, ErrMalformed, nil},
+		{"trailing data", `{"name":"a","count":1} {}`, ErrMalformed, nil},
+		{"too large", `{"name":"` + strings.Repeat("a", 100) + `","count":1}`, ErrBodyTooLarge, nil},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tc.body))
+			var v testRequest
+			err := DecodeJSON(httptest.NewRecorder(), req, &v, 64, "id")
+
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("expected %v, got %v", tc.wantErr, err)
+				}
+				return
+			}
+			var fields Errors
+			errors.As(err, &fields)
+			if !reflect.DeepEqual(fields, tc.wantFields) {
+				t.Errorf("expected %+v, got %+v (%v)", tc.wantFields, fields, err)
+			}
+		})
+	}
+}